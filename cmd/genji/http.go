@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/badgerengine"
+	"github.com/genjidb/genji/engine/boltengine"
+	"github.com/genjidb/genji/engine/memoryengine"
+)
+
+// httpHandler exposes a database over HTTP.
+//
+// It serves two endpoints:
+//
+//	POST /query                   runs a query and streams the result as newline delimited JSON
+//	POST /tables/{name}/documents inserts a JSON stream or array of documents into a table
+//
+// Every request runs within its own transaction.
+type httpHandler struct {
+	db       *genji.DB
+	readOnly bool
+	timeout  time.Duration
+}
+
+// queryRequest is the body expected by the /query endpoint.
+// Params can either be an array of positional parameters or
+// an object of named parameters.
+type queryRequest struct {
+	Query  string          `json:"query"`
+	Params json.RawMessage `json:"params"`
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeHTTPError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	if r.URL.Path == "/query" {
+		h.handleQuery(w, r)
+		return
+	}
+
+	if table, ok := parseDocumentsPath(r.URL.Path); ok {
+		h.handleInsert(w, r, table)
+		return
+	}
+
+	writeHTTPError(w, http.StatusNotFound, errors.New("not found"))
+}
+
+// parseDocumentsPath extracts the table name from paths of the form /tables/{name}/documents.
+func parseDocumentsPath(path string) (string, bool) {
+	if !strings.HasPrefix(path, "/tables/") || !strings.HasSuffix(path, "/documents") {
+		return "", false
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(path, "/tables/"), "/documents")
+	if name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+
+	return name, true
+}
+
+// begin creates a transaction bound to the request context.
+// If the handler has a timeout, the transaction is canceled once it expires.
+func (h *httpHandler) begin(r *http.Request, writable bool) (*genji.Tx, context.CancelFunc, error) {
+	ctx, cancel := r.Context(), context.CancelFunc(func() {})
+	if h.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+	}
+
+	tx, err := h.db.WithContext(ctx).Begin(writable)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	return tx, cancel, nil
+}
+
+func (h *httpHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
+	var req queryRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeHTTPError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	args, err := decodeParams(req.Params)
+	if err != nil {
+		writeHTTPError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tx, cancel, err := h.begin(r, !h.readOnly)
+	if err != nil {
+		writeHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer cancel()
+	defer tx.Rollback()
+
+	res, err := tx.Query(req.Query, args...)
+	if err != nil {
+		writeHTTPError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer res.Close()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	// once the status has been sent, errors can only be reported
+	// as the last line of the stream.
+	err = writeNDJSON(w, res)
+	if err == nil && !h.readOnly {
+		err = tx.Commit()
+	}
+	if err != nil {
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	}
+}
+
+func (h *httpHandler) handleInsert(w http.ResponseWriter, r *http.Request, table string) {
+	if h.readOnly {
+		writeHTTPError(w, http.StatusForbidden, errors.New("database is read-only"))
+		return
+	}
+
+	tx, cancel, err := h.begin(r, true)
+	if err != nil {
+		writeHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer cancel()
+	defer tx.Rollback()
+
+	_, err = tx.GetTable(table)
+	if err != nil {
+		writeHTTPError(w, http.StatusNotFound, err)
+		return
+	}
+
+	err = executeInsertCommand(tx, quoteIdent(table), r.Body)
+	if err != nil {
+		writeHTTPError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		writeHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeNDJSON encodes every document of the iterator to w as newline delimited JSON.
+func writeNDJSON(w io.Writer, it document.Iterator) error {
+	buf := bufio.NewWriter(w)
+	defer buf.Flush()
+
+	return it.Iterate(func(d document.Document) error {
+		data, err := document.MarshalJSON(d)
+		if err != nil {
+			return err
+		}
+
+		_, err = buf.Write(data)
+		if err != nil {
+			return err
+		}
+
+		return buf.WriteByte('\n')
+	})
+}
+
+// decodeParams converts the raw params of a query request to
+// a list of arguments accepted by genji.Tx.Query.
+func decodeParams(raw json.RawMessage) ([]interface{}, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	switch raw[0] {
+	case '[':
+		var args []interface{}
+		err := json.Unmarshal(raw, &args)
+		return args, err
+	case '{':
+		var named map[string]interface{}
+		err := json.Unmarshal(raw, &named)
+		if err != nil {
+			return nil, err
+		}
+
+		args := make([]interface{}, 0, len(named))
+		for k, v := range named {
+			args = append(args, sql.Named(k, v))
+		}
+		return args, nil
+	}
+
+	return nil, fmt.Errorf("params must be an array or an object, got %s", raw)
+}
+
+// quoteIdent returns name as a quoted identifier that can safely be used in a query.
+func quoteIdent(name string) string {
+	r := strings.NewReplacer(`\`, `\\`, "`", "\\`")
+	return "`" + r.Replace(name) + "`"
+}
+
+func writeHTTPError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+func runHTTPCommand(ctx context.Context, e, dbPath, addr string, readOnly bool, timeout time.Duration) error {
+	var ng engine.Engine
+	var err error
+
+	switch e {
+	case "memory":
+		ng = memoryengine.NewEngine()
+	case "bolt":
+		ng, err = boltengine.NewEngine(dbPath, 0660, nil)
+	case "badger":
+		ng, err = badgerengine.NewEngine(badger.DefaultOptions(dbPath).WithLogger(nil))
+	default:
+		return fmt.Errorf("unknown engine %q", e)
+	}
+	if err != nil {
+		return err
+	}
+
+	db, err := genji.New(ctx, ng)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	srv := http.Server{
+		Addr: addr,
+		Handler: &httpHandler{
+			db:       db,
+			readOnly: readOnly,
+			timeout:  timeout,
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	err = srv.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
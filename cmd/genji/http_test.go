@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	newHandler := func(t *testing.T, readOnly bool) (*genji.DB, http.Handler) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+
+		err = db.Exec(`CREATE TABLE foo; INSERT INTO foo (a) VALUES (1), (2), (3)`)
+		require.NoError(t, err)
+
+		return db, &httpHandler{db: db, readOnly: readOnly}
+	}
+
+	do := func(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("Query", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			body     string
+			code     int
+			expected string
+		}{
+			{"No params", `{"query": "SELECT a FROM foo"}`, http.StatusOK, "{\"a\": 1}\n{\"a\": 2}\n{\"a\": 3}\n"},
+			{"Positional params", `{"query": "SELECT a FROM foo WHERE a > ?", "params": [1]}`, http.StatusOK, "{\"a\": 2}\n{\"a\": 3}\n"},
+			{"Named params", `{"query": "SELECT a FROM foo WHERE a = $a", "params": {"a": 2}}`, http.StatusOK, "{\"a\": 2}\n"},
+			{"No result", `{"query": "SELECT a FROM foo WHERE a > 10"}`, http.StatusOK, ""},
+			{"Bad params", `{"query": "SELECT a FROM foo", "params": 1}`, http.StatusBadRequest, ""},
+			{"Bad query", `{"query": "SELEC a FROM foo"}`, http.StatusBadRequest, ""},
+			{"Bad body", `{"query"`, http.StatusBadRequest, ""},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				db, h := newHandler(t, false)
+				defer db.Close()
+
+				w := do(h, http.MethodPost, "/query", test.body)
+				require.Equal(t, test.code, w.Code)
+				if test.code == http.StatusOK {
+					require.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+					require.Equal(t, test.expected, w.Body.String())
+				}
+			})
+		}
+	})
+
+	t.Run("Query/Writes are committed", func(t *testing.T) {
+		db, h := newHandler(t, false)
+		defer db.Close()
+
+		w := do(h, http.MethodPost, "/query", `{"query": "DELETE FROM foo WHERE a > 1"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		d, err := db.QueryDocument("SELECT COUNT(*) FROM foo")
+		require.NoError(t, err)
+		var count int
+		require.NoError(t, document.Scan(d, &count))
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("Query/Read-only", func(t *testing.T) {
+		db, h := newHandler(t, true)
+		defer db.Close()
+
+		w := do(h, http.MethodPost, "/query", `{"query": "SELECT a FROM foo WHERE a = 1"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "{\"a\": 1}\n", w.Body.String())
+
+		w = do(h, http.MethodPost, "/query", `{"query": "DELETE FROM foo"}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Insert", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			path  string
+			body  string
+			code  int
+			count int
+		}{
+			{"Array", "/tables/foo/documents", `[{"a": 4}, {"a": 5}]`, http.StatusNoContent, 5},
+			{"Stream", "/tables/foo/documents", `{"a": 4} {"a": 5} {"a": 6}`, http.StatusNoContent, 6},
+			{"Invalid document rolls back", "/tables/foo/documents", `{"a": 4} {"a": `, http.StatusBadRequest, 3},
+			{"Unknown table", "/tables/bar/documents", `{"a": 4}`, http.StatusNotFound, 3},
+			{"Unknown path", "/tables/foo", `{"a": 4}`, http.StatusNotFound, 3},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				db, h := newHandler(t, false)
+				defer db.Close()
+
+				w := do(h, http.MethodPost, test.path, test.body)
+				require.Equal(t, test.code, w.Code)
+
+				d, err := db.QueryDocument("SELECT COUNT(*) FROM foo")
+				require.NoError(t, err)
+				var count int
+				require.NoError(t, document.Scan(d, &count))
+				require.Equal(t, test.count, count)
+			})
+		}
+	})
+
+	t.Run("Insert/Read-only", func(t *testing.T) {
+		db, h := newHandler(t, true)
+		defer db.Close()
+
+		w := do(h, http.MethodPost, "/tables/foo/documents", `{"a": 4}`)
+		require.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		db, h := newHandler(t, false)
+		defer db.Close()
+
+		w := do(h, http.MethodGet, "/query", "")
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
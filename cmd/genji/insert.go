@@ -38,7 +38,12 @@ func skipSpaces(r *bufio.Reader) (byte, error) {
 	return c, nil
 }
 
-func executeInsertCommand(db *genji.DB, table string, r io.Reader) error {
+// execer is implemented by both genji.DB and genji.Tx.
+type execer interface {
+	Exec(q string, args ...interface{}) error
+}
+
+func executeInsertCommand(db execer, table string, r io.Reader) error {
 	q := fmt.Sprintf("INSERT INTO %s VALUES ?", table)
 	rd := bufio.NewReader(r)
 	var c byte
@@ -87,6 +87,58 @@ $ curl https://api.github.com/repos/genjidb/genji/issues | genji insert --db my.
 				return runInsertCommand(c.Context, engine, dbPath, table, c.Bool("auto"), args)
 			},
 		},
+		{
+			Name:      "http",
+			Usage:     "Serve the database over HTTP",
+			UsageText: "genji http [options]",
+			Description: `
+The http command starts an HTTP server exposing the database.
+
+Queries are sent to the /query endpoint and results are streamed back as newline delimited JSON:
+
+$ curl -X POST localhost:8080/query -d '{"query": "SELECT * FROM foo WHERE a > ?", "params": [10]}'
+
+Documents can be inserted in bulk by sending a stream or an array of objects to /tables/{name}/documents:
+
+$ curl -X POST localhost:8080/tables/foo/documents -d '[{"a": 1}, {"a": 2}]'
+
+Each request runs in its own transaction. If no database path is provided, an in-memory database is used.`,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "engine",
+					Aliases: []string{"e"},
+					Usage:   "name of the engine to use, options are 'bolt' or 'badger'",
+					Value:   "bolt",
+				},
+				&cli.StringFlag{
+					Name:     "db",
+					Usage:    "path of the database file",
+					Required: false,
+				},
+				&cli.StringFlag{
+					Name:  "addr",
+					Usage: "address to listen on",
+					Value: ":8080",
+				},
+				&cli.BoolFlag{
+					Name:  "read-only",
+					Usage: "run every request in a read-only transaction",
+				},
+				&cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "maximum duration of a request, zero means no timeout",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				dbPath := c.String("db")
+				engine := c.String("engine")
+				if dbPath == "" {
+					engine = "memory"
+				}
+
+				return runHTTPCommand(c.Context, engine, dbPath, c.String("addr"), c.Bool("read-only"), c.Duration("timeout"))
+			},
+		},
 		{
 			Name:  "version",
 			Usage: "Shows Genji and Genji CLI version",
@@ -63,25 +63,37 @@ var commands = []struct {
 	},
 }
 
-// runTablesCmd shows all tables.
+// runTablesCmd shows all tables and views.
 func runTablesCmd(db *genji.DB, cmd []string) error {
 	if len(cmd) > 1 {
 		return fmt.Errorf("usage: .tables")
 	}
 
-	res, err := db.Query("SELECT table_name FROM __genji_tables")
+	for _, q := range []string{"SELECT table_name FROM __genji_tables", "SELECT view_name FROM __genji_views"} {
+		err := printNames(db, q)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printNames prints the first field of every document returned by the query.
+func printNames(db *genji.DB, q string) error {
+	res, err := db.Query(q)
 	if err != nil {
 		return err
 	}
 	defer res.Close()
 
 	return res.Iterate(func(d document.Document) error {
-		var tableName string
-		err = document.Scan(d, &tableName)
+		var name string
+		err = document.Scan(d, &name)
 		if err != nil {
 			return err
 		}
-		fmt.Println(tableName)
+		fmt.Println(name)
 		return nil
 	})
 }
@@ -110,7 +122,7 @@ func displayTableIndex(db *genji.DB, tableName string) error {
 				return err
 			}
 
-			fmt.Printf("%s ON %s (%s)\n", index.IndexName, index.TableName, index.Path)
+			fmt.Printf("%s ON %s (%s)%s\n", index.IndexName, index.TableName, index.Key(), indexClauses(&index))
 
 			return nil
 		})
@@ -132,13 +144,37 @@ func displayAllIndexes(db *genji.DB) error {
 			return err
 		}
 
-		fmt.Printf("%s ON %s (%s)\n", index.IndexName, index.TableName, index.Path)
+		fmt.Printf("%s ON %s (%s)%s\n", index.IndexName, index.TableName, index.Key(), indexClauses(&index))
 
 		return nil
 	})
 
 }
 
+// indexClauses returns the INCLUDE, WITH and WHERE clauses of an index, if any.
+func indexClauses(cfg *database.IndexConfig) string {
+	var s string
+
+	if len(cfg.Include) > 0 {
+		paths := make([]string, len(cfg.Include))
+		for i, p := range cfg.Include {
+			paths[i] = p.String()
+		}
+
+		s += " INCLUDE (" + strings.Join(paths, ", ") + ")"
+	}
+
+	if cfg.Stem {
+		s += " WITH STEMMING"
+	}
+
+	if cfg.Predicate != "" {
+		s += " WHERE " + cfg.Predicate
+	}
+
+	return s
+}
+
 // runIndexesCmd executes all indexes of the database or all indexes of the given table.
 func runIndexesCmd(db *genji.DB, in []string) error {
 	switch len(in) {
@@ -235,10 +271,17 @@ func dumpTable(tx *genji.Tx, tableName string, w io.Writer) error {
 
 		buf.WriteString(" " + fcs[i].Path.String() + " ")
 		buf.WriteString(strings.ToUpper(fcs[i].Type.String()))
+		if fc.IsGenerated() {
+			buf.WriteString(" AS (" + fc.Generated + ")")
+		}
 		if fc.IsPrimaryKey {
 			buf.WriteString(" PRIMARY KEY")
 		}
 
+		if fc.IsAutoIncrement {
+			buf.WriteString(" AUTOINCREMENT")
+		}
+
 		if fc.IsNotNull {
 			buf.WriteString(" NOT NULL")
 		}
@@ -246,10 +289,16 @@ func dumpTable(tx *genji.Tx, tableName string, w io.Writer) error {
 
 	// Fields constraints close parenthesis.
 	if len(fcs) > 0 {
-		buf.WriteString("\n);\n")
-	} else {
-		buf.WriteString(";\n")
+		buf.WriteString("\n)")
+	}
+
+	if ti.Strict {
+		buf.WriteString(" STRICT")
+	}
+	if ti.TTL != nil {
+		buf.WriteString(" WITH TTL " + ti.TTL.String())
 	}
+	buf.WriteString(";\n")
 
 	// Print CREATE TABLE statement.
 	if _, err = buf.WriteTo(w); err != nil {
@@ -263,13 +312,21 @@ func dumpTable(tx *genji.Tx, tableName string, w io.Writer) error {
 	}
 
 	for _, index := range indexes {
+		// internal indexes are created with the table.
+		if strings.HasPrefix(index.Opts.IndexName, "__genji_") {
+			continue
+		}
+
 		u := ""
 		if index.Opts.Unique {
 			u = " UNIQUE"
 		}
+		if index.Opts.FullText {
+			u = " FULLTEXT"
+		}
 
-		_, err = fmt.Fprintf(w, "CREATE%s INDEX %s ON %s (%s);\n", u, index.Opts.IndexName, index.Opts.TableName,
-			index.Opts.Path)
+		_, err = fmt.Fprintf(w, "CREATE%s INDEX %s ON %s (%s)%s;\n", u, index.Opts.IndexName, index.Opts.TableName,
+			index.Opts.Key(), indexClauses(&index.Opts))
 		if err != nil {
 			return err
 		}
@@ -301,6 +358,78 @@ func dumpTable(tx *genji.Tx, tableName string, w io.Writer) error {
 	})
 }
 
+// dumpView displays the definition of the given view as an SQL statement.
+func dumpView(tx *genji.Tx, viewName string, w io.Writer) error {
+	v, err := tx.GetView(viewName)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "CREATE VIEW %s AS %s;\n", v.ViewName, v.Query)
+	return err
+}
+
+// dumpTriggers displays the CREATE TRIGGER statements of the triggers of the given table.
+// If tableName is empty, all the triggers of the database are displayed.
+func dumpTriggers(tx *genji.Tx, tableName string, w io.Writer) error {
+	triggers, err := tx.ListTriggers()
+	if err != nil {
+		return err
+	}
+
+	for _, tr := range triggers {
+		if tableName != "" && tr.TableName != tableName {
+			continue
+		}
+
+		timing := "AFTER"
+		if tr.Before {
+			timing = "BEFORE"
+		}
+
+		var when string
+		if tr.When != "" {
+			when = " WHEN " + tr.When
+		}
+
+		_, err = fmt.Fprintf(w, "CREATE TRIGGER %s %s %s ON %s FOR EACH ROW%s %s;\n",
+			tr.TriggerName, timing, tr.Event, tr.TableName, when, tr.Statement)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dumpSequences displays the CREATE SEQUENCE statements of the sequences of the database.
+// Sequences start from their next value, so that they resume where they stopped once restored.
+func dumpSequences(tx *genji.Tx, w io.Writer) error {
+	seqs, err := tx.ListSequences()
+	if err != nil {
+		return err
+	}
+
+	for _, seq := range seqs {
+		// internal sequences are created with the table.
+		if strings.HasPrefix(seq.SequenceName, "__genji_") {
+			continue
+		}
+
+		start := seq.Start
+		if seq.Called {
+			start = seq.Value + seq.Increment
+		}
+
+		_, err = fmt.Fprintf(w, "CREATE SEQUENCE %s INCREMENT BY %d START WITH %d;\n", seq.SequenceName, seq.Increment, start)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // RunDumpCmd dumps the given tables if provided, otherwise it dumps the whole database.
 func RunDumpCmd(db *genji.DB, tables []string, w io.Writer) error {
 	tx, err := db.Begin(false)
@@ -321,9 +450,18 @@ func RunDumpCmd(db *genji.DB, tables []string, w io.Writer) error {
 			}
 		}
 
-		if err = dumpTable(tx, table, w); err != nil {
-			// If table doesn’t exist we skip it.
-			if errors.Is(err, database.ErrTableNotFound) {
+		err = dumpTable(tx, table, w)
+		if err == nil {
+			// Triggers are created after the documents are inserted,
+			// so that restoring the dump doesn't fire them.
+			err = dumpTriggers(tx, table, w)
+		}
+		if errors.Is(err, database.ErrTableNotFound) {
+			err = dumpView(tx, table, w)
+		}
+		if err != nil {
+			// If table or view doesn’t exist we skip it.
+			if errors.Is(err, database.ErrViewNotFound) {
 				continue
 			}
 			_, err = fmt.Fprintln(w, "ROLLBACK;")
@@ -341,6 +479,11 @@ func RunDumpCmd(db *genji.DB, tables []string, w io.Writer) error {
 
 	// tables slice argument is empty.
 	// Dump database content.
+	if err := dumpSequences(tx, w); err != nil {
+		_, err = fmt.Fprintln(w, "ROLLBACK;")
+		return err
+	}
+
 	res, err := tx.Query("SELECT table_name FROM __genji_tables")
 	if err != nil {
 		_, err = fmt.Fprintln(w, "ROLLBACK;")
@@ -371,6 +514,34 @@ func RunDumpCmd(db *genji.DB, tables []string, w io.Writer) error {
 		return err
 	}
 
+	// Views are dumped after the tables they read.
+	views, err := tx.ListViews()
+	if err != nil {
+		_, err = fmt.Fprintln(w, "ROLLBACK;")
+		return err
+	}
+
+	for _, v := range views {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w, ""); err != nil {
+				return err
+			}
+		}
+		i++
+
+		if err := dumpView(tx, v.ViewName, w); err != nil {
+			_, err = fmt.Fprintln(w, "ROLLBACK;")
+			return err
+		}
+	}
+
+	// Triggers are created after the documents are inserted,
+	// so that restoring the dump doesn't fire them.
+	if err := dumpTriggers(tx, "", w); err != nil {
+		_, err = fmt.Fprintln(w, "ROLLBACK;")
+		return err
+	}
+
 	_, err = fmt.Fprintln(w, "COMMIT;")
 	return err
 }
@@ -490,6 +661,11 @@ func RunSaveCmd(ctx context.Context, db *genji.DB, engineName string, dbPath str
 			return err
 		}
 
+		// internal indexes are created with the table.
+		if strings.HasPrefix(index.IndexName, "__genji_") {
+			return nil
+		}
+
 		err = otherTx.CreateIndex(index)
 		if err != nil {
 			return err
@@ -498,6 +674,47 @@ func RunSaveCmd(ctx context.Context, db *genji.DB, engineName string, dbPath str
 		return nil
 	})
 
+	views, err := tx.ListViews()
+	if err != nil {
+		return err
+	}
+
+	for _, v := range views {
+		err = otherTx.CreateView(v.ViewName, v.Query)
+		if err != nil {
+			return err
+		}
+	}
+
+	seqs, err := tx.ListSequences()
+	if err != nil {
+		return err
+	}
+
+	for _, seq := range seqs {
+		// internal sequences are created with the table.
+		if strings.HasPrefix(seq.SequenceName, "__genji_") {
+			continue
+		}
+
+		err = otherTx.CreateSequence(seq)
+		if err != nil {
+			return err
+		}
+	}
+
+	triggers, err := tx.ListTriggers()
+	if err != nil {
+		return err
+	}
+
+	for _, tr := range triggers {
+		err = otherTx.CreateTrigger(tr)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = otherTx.ReIndexAll()
 	if err != nil {
 		return err
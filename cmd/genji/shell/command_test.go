@@ -174,6 +174,93 @@ func TestRunDumpCmd(t *testing.T) {
 
 }
 
+func TestRunDumpCmdWithView(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test;
+		CREATE VIEW v AS SELECT a FROM test WHERE b > 1;
+		INSERT INTO test (a, b) VALUES (1, 2);
+	`)
+	require.NoError(t, err)
+
+	want := `BEGIN TRANSACTION;
+CREATE TABLE test;
+INSERT INTO test VALUES {"a": 1, "b": 2};
+
+CREATE VIEW v AS SELECT a FROM test WHERE b > 1;
+COMMIT;
+`
+
+	var buf bytes.Buffer
+	err = RunDumpCmd(db, nil, &buf)
+	require.NoError(t, err)
+	require.Equal(t, want, buf.String())
+
+	buf.Reset()
+	err = RunDumpCmd(db, []string{"v"}, &buf)
+	require.NoError(t, err)
+	require.Equal(t, "BEGIN TRANSACTION;\nCREATE VIEW v AS SELECT a FROM test WHERE b > 1;\nCOMMIT;\n", buf.String())
+}
+
+func TestRunDumpCmdWithTrigger(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test;
+		INSERT INTO test (a) VALUES (1);
+		CREATE TRIGGER tr BEFORE UPDATE ON test FOR EACH ROW WHEN new.a < 0 SELECT raise('negative');
+	`)
+	require.NoError(t, err)
+
+	want := `BEGIN TRANSACTION;
+CREATE TABLE test;
+INSERT INTO test VALUES {"a": 1};
+CREATE TRIGGER tr BEFORE UPDATE ON test FOR EACH ROW WHEN new.a < 0 SELECT raise('negative');
+COMMIT;
+`
+
+	var buf bytes.Buffer
+	err = RunDumpCmd(db, []string{"test"}, &buf)
+	require.NoError(t, err)
+	require.Equal(t, want, buf.String())
+
+	buf.Reset()
+	err = RunDumpCmd(db, nil, &buf)
+	require.NoError(t, err)
+	require.Equal(t, want, buf.String())
+}
+
+func TestRunDumpCmdWithSequence(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE SEQUENCE seq INCREMENT BY 2;
+		SELECT nextval('seq');
+		CREATE TABLE test(id INTEGER PRIMARY KEY AUTOINCREMENT);
+		INSERT INTO test VALUES {};
+	`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = RunDumpCmd(db, nil, &buf)
+	require.NoError(t, err)
+	require.Equal(t, `BEGIN TRANSACTION;
+CREATE SEQUENCE seq INCREMENT BY 2 START WITH 3;
+CREATE TABLE test (
+ id INTEGER PRIMARY KEY AUTOINCREMENT
+);
+INSERT INTO test VALUES {"id": 1};
+COMMIT;
+`, buf.String())
+}
+
 func TestSaveCommand(t *testing.T) {
 	tests := []struct {
 		engine string
@@ -3,11 +3,14 @@ package database
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/encoding"
 	"github.com/genjidb/genji/engine"
 	"github.com/genjidb/genji/index"
+	"github.com/genjidb/genji/index/fulltext"
 )
 
 const storePrefix = 't'
@@ -19,12 +22,27 @@ type FieldConstraint struct {
 	IsPrimaryKey bool
 	IsNotNull    bool
 	DefaultValue document.Value
+
+	// If set, the primary key is filled from a sequence when it is missing or null.
+	// Only integer primary keys can be auto-incremented.
+	IsAutoIncrement bool
+
+	// If set, the value of the field is the result of this expression, computed every time
+	// a document is inserted or replaced. Generated fields cannot be written directly.
+	Generated string
+
+	generated Expr
 }
 
 func (f *FieldConstraint) HasDefaultValue() bool {
 	return f.DefaultValue.Type != 0
 }
 
+// IsGenerated returns true if the value of the field is computed from an expression.
+func (f *FieldConstraint) IsGenerated() bool {
+	return f.Generated != ""
+}
+
 // ToDocument returns a document from f.
 func (f *FieldConstraint) ToDocument() document.Document {
 	buf := document.NewFieldBuffer()
@@ -33,9 +51,15 @@ func (f *FieldConstraint) ToDocument() document.Document {
 	buf.Add("type", document.NewIntegerValue(int64(f.Type)))
 	buf.Add("is_primary_key", document.NewBoolValue(f.IsPrimaryKey))
 	buf.Add("is_not_null", document.NewBoolValue(f.IsNotNull))
+	if f.IsAutoIncrement {
+		buf.Add("is_auto_increment", document.NewBoolValue(true))
+	}
 	if f.HasDefaultValue() {
 		buf.Add("default_value", f.DefaultValue)
 	}
+	if f.IsGenerated() {
+		buf.Add("generated", document.NewTextValue(f.Generated))
+	}
 	return buf
 }
 
@@ -69,6 +93,14 @@ func (f *FieldConstraint) ScanDocument(d document.Document) error {
 	}
 	f.IsNotNull = v.V.(bool)
 
+	v, err = d.GetByField("is_auto_increment")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		f.IsAutoIncrement = v.V.(bool)
+	}
+
 	v, err = d.GetByField("default_value")
 	if err != nil && err != document.ErrFieldNotFound {
 		return err
@@ -77,19 +109,33 @@ func (f *FieldConstraint) ScanDocument(d document.Document) error {
 		f.DefaultValue = v
 	}
 
+	v, err = d.GetByField("generated")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		f.Generated = v.V.(string)
+	}
+
 	return nil
 }
 
 // FieldConstraints is a list of field constraints.
 type FieldConstraints []FieldConstraint
 
-// ValidateDocument calls Convert then ensures the document validates against the field constraints.
+// ValidateDocument calls Convert, computes the generated fields, then ensures the document
+// validates against the field constraints.
 func (f FieldConstraints) ValidateDocument(d document.Document) (*document.FieldBuffer, error) {
 	fb, err := f.Convert(d)
 	if err != nil {
 		return nil, err
 	}
 
+	err = f.generate(fb)
+	if err != nil {
+		return nil, err
+	}
+
 	// ensure no field is missing
 	for _, fc := range f {
 		v, err := fc.Path.GetValueFromDocument(fb)
@@ -124,6 +170,76 @@ func (f FieldConstraints) ValidateDocument(d document.Document) (*document.Field
 	return fb, nil
 }
 
+// generate computes the value of every generated field of fb, in the order of declaration.
+// If fb already contains a value for a generated field, it must be equal to the computed one.
+func (f FieldConstraints) generate(fb *document.FieldBuffer) error {
+	for _, fc := range f {
+		if !fc.IsGenerated() {
+			continue
+		}
+
+		if fc.generated == nil {
+			return fmt.Errorf("expression of generated field %q is not parsed", fc.Path)
+		}
+
+		v, err := fc.generated.Eval(fb)
+		if err != nil {
+			return fmt.Errorf("cannot compute generated field %q: %w", fc.Path, err)
+		}
+
+		switch {
+		case fc.Type != 0:
+			v, err = v.CastAs(fc.Type)
+		case v.Type == document.IntegerValue:
+			v, err = v.CastAsDouble()
+		}
+		if err != nil {
+			return fmt.Errorf("cannot compute generated field %q: %w", fc.Path, err)
+		}
+
+		cur, err := fc.Path.GetValueFromDocument(fb)
+		if err == nil {
+			ok, err := cur.IsEqual(v)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("cannot write generated field %q", fc.Path)
+			}
+		} else if err != document.ErrFieldNotFound {
+			return err
+		}
+
+		err = fb.Set(fc.Path, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseGenerated parses the expressions of the generated fields.
+func (f FieldConstraints) parseGenerated(db *Database) error {
+	for i := range f {
+		if !f[i].IsGenerated() {
+			continue
+		}
+
+		if len(f[i].Path) != 1 {
+			return fmt.Errorf("generated field %q must be a top-level field", f[i].Path)
+		}
+
+		var err error
+		f[i].generated, err = db.parseExpr(f[i].Generated)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Convert the document using the field constraints.
 // It converts any path that has a field constraint on it into the specified type.
 // If there is no constraint on an integer field or value, it converts it into a double.
@@ -164,6 +280,34 @@ func (f FieldConstraints) Convert(d document.Document) (*document.FieldBuffer, e
 	return fb, err
 }
 
+// checkDeclared returns an error if d contains a field that is not declared by a field constraint.
+// Fields of nested documents must be declared only if sub-paths of their parent are declared,
+// otherwise their content is not checked.
+func (f FieldConstraints) checkDeclared(d document.Document, parent document.Path) error {
+	return d.Iterate(func(field string, v document.Value) error {
+		path := append(parent[:len(parent):len(parent)], document.PathFragment{FieldName: field})
+
+		var declared, hasSubPaths bool
+		for _, fc := range f {
+			if fc.Path.IsEqual(path) {
+				declared = true
+			} else if pathHasPrefix(fc.Path, path) {
+				hasSubPaths = true
+			}
+		}
+
+		if !declared && !hasSubPaths {
+			return fmt.Errorf("field %q is not declared", path)
+		}
+
+		if hasSubPaths && v.Type == document.DocumentValue {
+			return f.checkDeclared(v.V.(document.Document), path)
+		}
+
+		return nil
+	})
+}
+
 // TableInfo contains information about a table.
 type TableInfo struct {
 	// name of the table.
@@ -173,6 +317,39 @@ type TableInfo struct {
 	readOnly  bool
 
 	FieldConstraints FieldConstraints
+
+	// If set to true, documents containing fields that are not declared
+	// by a field constraint are rejected.
+	Strict bool
+
+	// If set, path of the field containing the expiration time of the documents.
+	// Expired documents are ignored by queries and eventually deleted.
+	TTL document.Path
+}
+
+// ValidateDocument calls FieldConstraints.ValidateDocument and, if the table is strict,
+// ensures every field of the document is declared.
+func (ti *TableInfo) ValidateDocument(d document.Document) (*document.FieldBuffer, error) {
+	fb, err := ti.FieldConstraints.ValidateDocument(d)
+	if err != nil {
+		return nil, err
+	}
+
+	if ti.Strict {
+		err = ti.FieldConstraints.checkDeclared(fb, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if ti.TTL != nil {
+		err = ti.checkTTL(fb)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return fb, nil
 }
 
 // GetPrimaryKey returns the field constraint of the primary key.
@@ -202,6 +379,12 @@ func (ti *TableInfo) ToDocument() document.Document {
 	buf.Add("field_constraints", document.NewArrayValue(vbuf))
 
 	buf.Add("read_only", document.NewBoolValue(ti.readOnly))
+	if ti.Strict {
+		buf.Add("strict", document.NewBoolValue(true))
+	}
+	if ti.TTL != nil {
+		buf.Add("ttl", document.NewArrayValue(pathToArray(ti.TTL)))
+	}
 	return buf
 }
 
@@ -246,6 +429,26 @@ func (ti *TableInfo) ScanDocument(d document.Document) error {
 	}
 
 	ti.readOnly = v.V.(bool)
+
+	v, err = d.GetByField("strict")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		ti.Strict = v.V.(bool)
+	}
+
+	v, err = d.GetByField("ttl")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		ti.TTL, err = arrayToPath(v.V.(document.Array))
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -314,6 +517,54 @@ func (t *tableInfoStore) Get(tx *Transaction, tableName string) (*TableInfo, err
 			},
 		}, nil
 	}
+	if tableName == viewStoreName {
+		return &TableInfo{
+			storeName: []byte(viewStoreName),
+			readOnly:  true,
+			FieldConstraints: []FieldConstraint{
+				{
+					Path: document.Path{
+						document.PathFragment{
+							FieldName: "view_name",
+						},
+					},
+					IsPrimaryKey: true,
+				},
+			},
+		}, nil
+	}
+	if tableName == sequenceStoreName {
+		return &TableInfo{
+			storeName: []byte(sequenceStoreName),
+			readOnly:  true,
+			FieldConstraints: []FieldConstraint{
+				{
+					Path: document.Path{
+						document.PathFragment{
+							FieldName: "sequence_name",
+						},
+					},
+					IsPrimaryKey: true,
+				},
+			},
+		}, nil
+	}
+	if tableName == triggerStoreName {
+		return &TableInfo{
+			storeName: []byte(triggerStoreName),
+			readOnly:  true,
+			FieldConstraints: []FieldConstraint{
+				{
+					Path: document.Path{
+						document.PathFragment{
+							FieldName: "trigger_name",
+						},
+					},
+					IsPrimaryKey: true,
+				},
+			},
+		}, nil
+	}
 	if tableName == indexStoreName {
 		return &TableInfo{
 			storeName: []byte(indexStoreName),
@@ -346,6 +597,11 @@ func (t *tableInfoStore) Get(tx *Transaction, tableName string) (*TableInfo, err
 		return nil, err
 	}
 
+	err = ti.FieldConstraints.parseGenerated(t.db)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ti, nil
 }
 
@@ -396,6 +652,42 @@ type IndexConfig struct {
 
 	// If set, the index is typed and only accepts that type
 	Type document.ValueType
+
+	// If set to true, each element of the array found at Path is indexed
+	// instead of the array itself.
+	ArrayElements bool
+
+	// If set, only the documents for which this expression is truthy are indexed.
+	Predicate string
+
+	// If set, the result of this expression is indexed instead of the value at Path.
+	// The expression must be deterministic.
+	Expr string
+
+	// Paths whose top-level fields are stored in the index alongside the key
+	// of each document, allowing queries to be answered without reading the table.
+	Include []document.Path
+
+	// If set to true, the words of the text found at Path are indexed
+	// instead of the value itself, to be searched using the MATCH operator.
+	FullText bool
+
+	// If set to true, the words of a full-text index are reduced to their stem.
+	Stem bool
+}
+
+// Key returns the indexed path or expression as written in a CREATE INDEX statement.
+// It is used as the key of the index in the map returned by Table.Indexes.
+func (i *IndexConfig) Key() string {
+	if i.Expr != "" {
+		return i.Expr
+	}
+
+	if i.ArrayElements {
+		return i.Path.String() + "[*]"
+	}
+
+	return i.Path.String()
 }
 
 // ToDocument creates a document from an IndexConfig.
@@ -409,6 +701,28 @@ func (i *IndexConfig) ToDocument() document.Document {
 	if i.Type != 0 {
 		buf.Add("type", document.NewIntegerValue(int64(i.Type)))
 	}
+	if i.ArrayElements {
+		buf.Add("array_elements", document.NewBoolValue(true))
+	}
+	if i.Predicate != "" {
+		buf.Add("predicate", document.NewTextValue(i.Predicate))
+	}
+	if i.Expr != "" {
+		buf.Add("expr", document.NewTextValue(i.Expr))
+	}
+	if i.FullText {
+		buf.Add("fulltext", document.NewBoolValue(true))
+	}
+	if i.Stem {
+		buf.Add("stem", document.NewBoolValue(true))
+	}
+	if len(i.Include) > 0 {
+		abuf := document.NewValueBuffer()
+		for _, p := range i.Include {
+			abuf = abuf.Append(document.NewArrayValue(pathToArray(p)))
+		}
+		buf.Add("include", document.NewArrayValue(abuf))
+	}
 	return buf
 }
 
@@ -449,6 +763,65 @@ func (i *IndexConfig) ScanDocument(d document.Document) error {
 		i.Type = document.ValueType(v.V.(int64))
 	}
 
+	v, err = d.GetByField("array_elements")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		i.ArrayElements = v.V.(bool)
+	}
+
+	v, err = d.GetByField("predicate")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		i.Predicate = v.V.(string)
+	}
+
+	v, err = d.GetByField("expr")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		i.Expr = v.V.(string)
+	}
+
+	v, err = d.GetByField("fulltext")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		i.FullText = v.V.(bool)
+	}
+
+	v, err = d.GetByField("stem")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		i.Stem = v.V.(bool)
+	}
+
+	v, err = d.GetByField("include")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		err = v.V.(document.Array).Iterate(func(_ int, v document.Value) error {
+			p, err := arrayToPath(v.V.(document.Array))
+			if err != nil {
+				return err
+			}
+
+			i.Include = append(i.Include, p)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -457,6 +830,310 @@ func (i *IndexConfig) ScanDocument(d document.Document) error {
 type Index struct {
 	*index.Index
 	Opts IndexConfig
+
+	// If set to true, GetDocument returns the fields stored in the index
+	// instead of reading the documents from the table.
+	// It is only valid if the index covers every path the caller reads.
+	IndexOnly bool
+
+	predicate Expr
+	expr      Expr
+	codec     encoding.Codec
+	fulltext  *fulltext.Index
+}
+
+// newIndex returns the index described by opts.
+func newIndex(tx *Transaction, opts IndexConfig) (*Index, error) {
+	idx := Index{
+		Index: index.New(tx.tx, opts.IndexName, index.Options{
+			Unique: opts.Unique,
+			Type:   opts.Type,
+		}),
+		Opts:  opts,
+		codec: tx.db.Codec,
+	}
+
+	if opts.FullText {
+		idx.fulltext = fulltext.New(tx.tx, opts.IndexName, fulltext.Options{
+			Stem: opts.Stem,
+		})
+	}
+
+	var err error
+	if opts.Predicate != "" {
+		idx.predicate, err = tx.db.parseExpr(opts.Predicate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Expr != "" {
+		idx.expr, err = tx.db.parseExpr(opts.Expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &idx, nil
+}
+
+// Predicate returns the parsed predicate of a partial index, or nil if the index is not partial.
+func (i *Index) Predicate() Expr {
+	return i.predicate
+}
+
+// Expr returns the parsed indexed expression, or nil if the index is on a path.
+func (i *Index) Expr() Expr {
+	return i.expr
+}
+
+// FullText returns the full-text index used to search the words of the indexed text,
+// or nil if the index is not a full-text index.
+func (i *Index) FullText() *fulltext.Index {
+	return i.fulltext
+}
+
+// Set associates a value with a key.
+// Full-text indexes index the words of text values and ignore other values.
+func (i *Index) Set(v document.Value, k []byte) error {
+	if i.fulltext == nil {
+		return i.Index.Set(v, k)
+	}
+
+	if v.Type != document.TextValue {
+		return nil
+	}
+
+	return i.fulltext.Set(v.V.(string), k)
+}
+
+// Delete all the references to the key from the index.
+func (i *Index) Delete(v document.Value, k []byte) error {
+	if i.fulltext == nil {
+		return i.Index.Delete(v, k)
+	}
+
+	if v.Type != document.TextValue {
+		return nil
+	}
+
+	return i.fulltext.Delete(v.V.(string), k)
+}
+
+// Truncate deletes all the index data.
+func (i *Index) Truncate() error {
+	if i.fulltext != nil {
+		return i.fulltext.Truncate()
+	}
+
+	return i.Index.Truncate()
+}
+
+// StoredFields returns the top-level fields stored in each entry of a covering index,
+// or nil if the index doesn't store any field.
+// It contains the first field of the indexed path and of every included path.
+func (i *Index) StoredFields() []string {
+	if len(i.Opts.Include) == 0 {
+		return nil
+	}
+
+	paths := i.Opts.Include
+	if i.Opts.Expr == "" {
+		paths = append([]document.Path{i.Opts.Path}, paths...)
+	}
+
+	var fields []string
+	for _, p := range paths {
+		var found bool
+		for _, f := range fields {
+			if f == p[0].FieldName {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			fields = append(fields, p[0].FieldName)
+		}
+	}
+
+	return fields
+}
+
+// entry returns what must be associated with the values of d in the index.
+// For regular indexes, it is the key of the document.
+// For covering indexes, the key is prefixed by its length and followed
+// by the encoded stored fields of d.
+func (i *Index) entry(d document.Document, key []byte) ([]byte, error) {
+	fields := i.StoredFields()
+	if fields == nil {
+		return key, nil
+	}
+
+	var fb document.FieldBuffer
+	for _, f := range fields {
+		v, err := d.GetByField(f)
+		if err == document.ErrFieldNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fb.Add(f, v)
+	}
+
+	buf := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(key))
+	n := binary.PutUvarint(buf, uint64(len(key)))
+	buf = append(buf[:n], key...)
+
+	var payload bytes.Buffer
+	enc := i.codec.NewEncoder(&payload)
+	defer enc.Close()
+	err := enc.EncodeDocument(&fb)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(buf, payload.Bytes()...), nil
+}
+
+// GetDocument returns the document referenced by an entry of the index,
+// as passed to the functions given to AscendGreaterOrEqual and DescendLessOrEqual.
+// If IndexOnly is set to true, the document is built from the fields stored in the entry
+// instead of being read from the table.
+func (i *Index) GetDocument(tb *Table, entry []byte) (document.Document, error) {
+	if len(i.Opts.Include) == 0 {
+		return tb.GetDocument(entry)
+	}
+
+	l, n := binary.Uvarint(entry)
+	if n <= 0 || len(entry) < n+int(l) {
+		return nil, errors.New("corrupted index entry")
+	}
+	key := entry[n : n+int(l)]
+
+	if !i.IndexOnly {
+		return tb.GetDocument(key)
+	}
+
+	info, err := tb.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	// the entry belongs to the caller, it must be copied
+	// as the returned document may be used after the next iteration.
+	entry = append([]byte(nil), entry...)
+
+	var d encodedDocumentWithKey
+	d.Document = i.codec.NewDocument(entry[n+int(l):])
+	d.key = entry[n : n+int(l)]
+	d.pk = info.GetPrimaryKey()
+	return &d, nil
+}
+
+// values returns the values of d that must be stored in the index.
+// If the index is partial and d doesn't satisfy its predicate, it returns nothing.
+// If the index is on an expression, it returns the result of that expression,
+// with integers converted to doubles like the values of unconstrained fields.
+// If the index is on array elements, it returns the distinct elements of the array
+// found at the path of the index, or nothing if that path doesn't contain an array.
+// Otherwise, it returns document.ErrFieldNotFound if there is no value at that path.
+func (i *Index) values(d document.Document) ([]document.Value, error) {
+	if i.predicate != nil {
+		v, err := i.predicate.Eval(d)
+		if err != nil {
+			return nil, err
+		}
+
+		ok, err := v.IsTruthy()
+		if err != nil || !ok {
+			return nil, err
+		}
+	}
+
+	if i.expr != nil {
+		v, err := i.expr.Eval(d)
+		if err != nil {
+			return nil, err
+		}
+
+		if v.Type == document.IntegerValue {
+			v, err = v.CastAsDouble()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return []document.Value{v}, nil
+	}
+
+	v, err := i.Opts.Path.GetValueFromDocument(d)
+	if !i.Opts.ArrayElements {
+		if err != nil {
+			return nil, err
+		}
+
+		return []document.Value{v}, nil
+	}
+
+	if err == document.ErrFieldNotFound || (err == nil && v.Type != document.ArrayValue) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var values []document.Value
+	seen := make(map[string]struct{})
+	err = v.V.(document.Array).Iterate(func(_ int, v document.Value) error {
+		enc, err := i.EncodeValue(v)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := seen[string(enc)]; ok {
+			return nil
+		}
+		seen[string(enc)] = struct{}{}
+
+		values = append(values, v)
+		return nil
+	})
+
+	return values, err
+}
+
+// deleteDocument removes all the entries associated with the given document from the index.
+func (i *Index) deleteDocument(d document.Document, key []byte) error {
+	key, err := i.entry(d, key)
+	if err != nil {
+		return err
+	}
+
+	values, err := i.values(d)
+	if err == document.ErrFieldNotFound {
+		// documents without a value at the path of the index
+		// may have been indexed with a null value.
+		err = i.Delete(document.NewNullValue(), key)
+		if err == engine.ErrKeyNotFound {
+			return nil
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		err = i.Delete(v, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 type indexStore struct {
@@ -581,3 +1258,15 @@ func pathToArray(path document.Path) document.Array {
 
 	return abuf
 }
+
+// pathHasPrefix returns true if path starts with prefix.
+func pathHasPrefix(path, prefix document.Path) bool {
+	return len(path) >= len(prefix) && path[:len(prefix)].IsEqual(prefix)
+}
+
+// replacePathPrefix returns a copy of path where prefix is replaced by newPrefix.
+func replacePathPrefix(path, prefix, newPrefix document.Path) document.Path {
+	p := make(document.Path, 0, len(newPrefix)+len(path)-len(prefix))
+	p = append(p, newPrefix...)
+	return append(p, path[len(prefix):]...)
+}
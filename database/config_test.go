@@ -34,6 +34,54 @@ func TestTableInfo(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestTableInfoValidateDocumentStrict(t *testing.T) {
+	parsePath := func(s ...string) document.Path {
+		var p document.Path
+		for _, f := range s {
+			p = append(p, document.PathFragment{FieldName: f})
+		}
+		return p
+	}
+
+	info := &TableInfo{
+		FieldConstraints: []FieldConstraint{
+			{Path: parsePath("a"), Type: document.IntegerValue},
+			{Path: parsePath("b", "c"), Type: document.TextValue},
+			{Path: parsePath("d"), Type: document.DocumentValue},
+		},
+		Strict: true,
+	}
+
+	tests := []struct {
+		name string
+		json string
+		err  string
+	}{
+		{"declared", `{"a": 1, "b": {"c": "x"}, "d": {"e": 1}}`, ""},
+		{"missing fields", `{}`, ""},
+		{"undeclared field", `{"a": 1, "aa": 1}`, `field "aa" is not declared`},
+		{"undeclared sub-field", `{"b": {"c": "x", "cc": 1}}`, `field "b.cc" is not declared`},
+		{"parent not a document", `{"b": 1}`, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := info.ValidateDocument(document.NewFromJSON([]byte(test.json)))
+			if test.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, test.err)
+			}
+		})
+	}
+
+	// strict is persisted
+	var res TableInfo
+	err := res.ScanDocument(info.ToDocument())
+	require.NoError(t, err)
+	require.True(t, res.Strict)
+}
+
 func TestTableInfoStore(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
 		ng := memoryengine.NewEngine()
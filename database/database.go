@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/genjidb/genji/document/encoding"
 	"github.com/genjidb/genji/engine"
@@ -28,10 +29,43 @@ type Database struct {
 
 	// Codec used to encode documents. Defaults to MessagePack.
 	Codec encoding.Codec
+
+	// ParseExpr parses the expressions stored in the catalog.
+	ParseExpr func(s string) (Expr, error)
+
+	// ParseQuery parses the queries stored in the catalog.
+	ParseQuery func(s string) (Query, error)
+
+	// ParseTrigger parses the triggers stored in the catalog.
+	ParseTrigger func(when, statement string) (Trigger, error)
+
+	// closed to stop the goroutine purging expired documents, if any.
+	stopSweeper chan struct{}
+	sweeperDone chan struct{}
 }
 
 type Options struct {
 	Codec encoding.Codec
+
+	// ParseExpr is used to parse the expressions stored in the catalog,
+	// like the predicates of partial indexes.
+	// If nil, these features are not available.
+	ParseExpr func(s string) (Expr, error)
+
+	// ParseQuery is used to parse the queries stored in the catalog,
+	// like the queries of views.
+	// If nil, views are not available.
+	ParseQuery func(s string) (Query, error)
+
+	// ParseTrigger is used to parse the condition, which can be empty,
+	// and the statement of the triggers stored in the catalog.
+	// If nil, triggers are not available.
+	ParseTrigger func(when, statement string) (Trigger, error)
+
+	// TTLSweepInterval is the interval at which the documents of tables
+	// with a TTL field are purged once expired.
+	// If zero or negative, expired documents are never purged automatically.
+	TTLSweepInterval time.Duration
 }
 
 // New initializes the DB using the given engine.
@@ -41,8 +75,11 @@ func New(ctx context.Context, ng engine.Engine, opts Options) (*Database, error)
 	}
 
 	db := Database{
-		ng:    ng,
-		Codec: opts.Codec,
+		ng:           ng,
+		Codec:        opts.Codec,
+		ParseExpr:    opts.ParseExpr,
+		ParseQuery:   opts.ParseQuery,
+		ParseTrigger: opts.ParseTrigger,
 	}
 
 	ntx, err := db.ng.Begin(ctx, engine.TxOptions{
@@ -53,7 +90,22 @@ func New(ctx context.Context, ng engine.Engine, opts Options) (*Database, error)
 	}
 	defer ntx.Rollback()
 
-	err = db.initInternalStores(ntx)
+	created, err := db.initInternalStores(ntx)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := db.initMetaStore(ntx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.checkCodec(ntx, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.migrate(ntx, meta, created)
 	if err != nil {
 		return nil, err
 	}
@@ -63,27 +115,75 @@ func New(ctx context.Context, ng engine.Engine, opts Options) (*Database, error)
 		return nil, err
 	}
 
+	if opts.TTLSweepInterval > 0 {
+		db.stopSweeper = make(chan struct{})
+		db.sweeperDone = make(chan struct{})
+		go func() {
+			defer close(db.sweeperDone)
+			db.sweep(opts.TTLSweepInterval, db.stopSweeper)
+		}()
+	}
+
 	return &db, nil
 }
 
-func (db *Database) initInternalStores(tx engine.Transaction) error {
+// initInternalStores creates the internal stores if they don't exist.
+// It returns true if the database was empty.
+// The catalog stores are only created for new databases: existing ones
+// get them when they are migrated to the current format version.
+func (db *Database) initInternalStores(tx engine.Transaction) (bool, error) {
+	var created bool
+
 	_, err := tx.GetStore([]byte(tableInfoStoreName))
 	if err == engine.ErrStoreNotFound {
+		created = true
 		err = tx.CreateStore([]byte(tableInfoStoreName))
 	}
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	_, err = tx.GetStore([]byte(indexStoreName))
 	if err == engine.ErrStoreNotFound {
 		err = tx.CreateStore([]byte(indexStoreName))
 	}
-	return err
+	if err != nil {
+		return false, err
+	}
+
+	if !created {
+		return false, nil
+	}
+
+	return true, createCatalogStores(tx)
+}
+
+// createCatalogStores creates the stores of views, triggers and sequences
+// if they don't exist.
+func createCatalogStores(tx engine.Transaction) error {
+	for _, name := range []string{viewStoreName, triggerStoreName, sequenceStoreName} {
+		_, err := tx.GetStore([]byte(name))
+		if err == engine.ErrStoreNotFound {
+			err = tx.CreateStore([]byte(name))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // Close the underlying engine.
+// If expired documents are purged in the background, it waits for the
+// purge in progress to finish.
 func (db *Database) Close() error {
+	if db.stopSweeper != nil {
+		close(db.stopSweeper)
+		<-db.sweeperDone
+		db.stopSweeper = nil
+	}
+
 	return db.ng.Close()
 }
 
@@ -127,12 +227,7 @@ func (db *Database) BeginTx(ctx context.Context, opts *TxOptions) (*Transaction,
 		attached: opts.Attached,
 	}
 
-	tx.tableInfoStore, err = tx.getTableInfoStore()
-	if err != nil {
-		return nil, err
-	}
-
-	tx.indexStore, err = tx.getIndexStore()
+	err = tx.initStores(false)
 	if err != nil {
 		return nil, err
 	}
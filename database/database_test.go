@@ -1,10 +1,19 @@
 package database_test
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/encoding"
+	"github.com/genjidb/genji/document/encoding/compression"
+	"github.com/genjidb/genji/document/encoding/custom"
+	"github.com/genjidb/genji/document/encoding/msgpack"
+	"github.com/genjidb/genji/engine/memoryengine"
 	"github.com/stretchr/testify/require"
 )
 
@@ -67,3 +76,63 @@ func TestConcurrentTransactionManagement(t *testing.T) {
 		t.Fatal("deadlock")
 	}
 }
+
+func TestCodecPersistence(t *testing.T) {
+	msgp := msgpack.NewCodec()
+	cust := custom.NewCodec()
+	snappyMsgp := compression.NewCodec(msgpack.NewCodec(), nil)
+
+	tests := []struct {
+		name   string
+		codecs []encoding.Codec
+		fails  bool
+	}{
+		{"Same codec", []encoding.Codec{msgp, msgp}, false},
+		{"Different codec", []encoding.Codec{msgp, cust}, true},
+		{"Wrapper of the stored codec", []encoding.Codec{msgp, snappyMsgp}, false},
+		{"Wrapped codec after wrapper", []encoding.Codec{msgp, snappyMsgp, msgp}, true},
+		{"Wrapper of another codec", []encoding.Codec{cust, snappyMsgp}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ng := memoryengine.NewEngine()
+			defer ng.Close()
+
+			var err error
+			for _, c := range test.codecs {
+				_, err = database.New(context.Background(), ng, database.Options{Codec: c})
+				if err != nil {
+					break
+				}
+			}
+
+			if !test.fails {
+				require.NoError(t, err)
+				return
+			}
+
+			var e *database.ErrCodecMismatch
+			require.True(t, errors.As(err, &e))
+		})
+	}
+
+	t.Run("genji.New", func(t *testing.T) {
+		ng := memoryengine.NewEngine()
+
+		db, err := genji.New(context.Background(), ng, genji.WithCodec(cust))
+		require.NoError(t, err)
+
+		err = db.Exec(`CREATE TABLE test; INSERT INTO test (a) VALUES ({b: [1, 2]})`)
+		require.NoError(t, err)
+
+		d, err := db.QueryDocument("SELECT a.b[1] AS c FROM test")
+		require.NoError(t, err)
+		var c int
+		require.NoError(t, document.Scan(d, &c))
+		require.Equal(t, 2, c)
+
+		_, err = genji.New(context.Background(), ng)
+		require.Error(t, err)
+	})
+}
@@ -19,6 +19,27 @@ var (
 	// same name as an existing one.
 	ErrIndexAlreadyExists = errors.New("index already exists")
 
+	// ErrViewNotFound is returned when the targeted view doesn't exist.
+	ErrViewNotFound = errors.New("view not found")
+
+	// ErrViewAlreadyExists is returned when attempting to create a view with the
+	// same name as an existing view or table.
+	ErrViewAlreadyExists = errors.New("view already exists")
+
+	// ErrTriggerNotFound is returned when the targeted trigger doesn't exist.
+	ErrTriggerNotFound = errors.New("trigger not found")
+
+	// ErrTriggerAlreadyExists is returned when attempting to create a trigger with the
+	// same name as an existing one.
+	ErrTriggerAlreadyExists = errors.New("trigger already exists")
+
+	// ErrSequenceNotFound is returned when the targeted sequence doesn't exist.
+	ErrSequenceNotFound = errors.New("sequence not found")
+
+	// ErrSequenceAlreadyExists is returned when attempting to create a sequence with the
+	// same name as an existing one.
+	ErrSequenceAlreadyExists = errors.New("sequence already exists")
+
 	// ErrDocumentNotFound is returned when no document is associated with the provided key.
 	ErrDocumentNotFound = errors.New("document not found")
 
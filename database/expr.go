@@ -0,0 +1,72 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/genjidb/genji/document"
+)
+
+// An Expr is an expression stored in the catalog of the database,
+// like the predicate of a partial index.
+// Expressions are stored as text and parsed using the ParseExpr function
+// of the database options, since this package doesn't depend on the SQL layer.
+type Expr interface {
+	// Eval evaluates the expression using d as the current document.
+	Eval(d document.Document) (document.Value, error)
+
+	// Paths returns the paths of the fields read by the expression.
+	// It returns false if they cannot be determined.
+	Paths() ([]document.Path, bool)
+
+	// IsDeterministic returns true if the result of the expression only depends
+	// on the document it is evaluated with.
+	IsDeterministic() bool
+
+	String() string
+}
+
+// parseExpr parses an expression stored in the catalog.
+func (db *Database) parseExpr(s string) (Expr, error) {
+	if db.ParseExpr == nil {
+		return nil, errors.New("cannot parse expression: no expression parser configured")
+	}
+
+	return db.ParseExpr(s)
+}
+
+// A Query is a query stored in the catalog of the database, like the query of a view.
+// Queries are stored as text and parsed using the ParseQuery function
+// of the database options. The concrete type of a Query depends on that function.
+type Query interface {
+	// IsReadOnly returns true if the query doesn't modify the database.
+	IsReadOnly() bool
+}
+
+// parseQuery parses a query stored in the catalog.
+func (db *Database) parseQuery(s string) (Query, error) {
+	if db.ParseQuery == nil {
+		return nil, errors.New("cannot parse query: no query parser configured")
+	}
+
+	return db.ParseQuery(s)
+}
+
+// A Trigger is the condition and the statement of a trigger, stored in the catalog of the database.
+// They are stored as text and parsed using the ParseTrigger function of the database options.
+// Within the condition and the statement, new refers to the document being written and old
+// to the document it replaces or deletes.
+type Trigger interface {
+	// Fire executes the statement if the condition is true, with new and old bound to
+	// newDoc and oldDoc, which can be nil.
+	// It returns the first document returned by the statement, if any.
+	Fire(tx *Transaction, newDoc, oldDoc document.Document) (document.Document, error)
+}
+
+// parseTrigger parses the condition and the statement of a trigger stored in the catalog.
+func (db *Database) parseTrigger(when, statement string) (Trigger, error) {
+	if db.ParseTrigger == nil {
+		return nil, errors.New("cannot parse trigger: no trigger parser configured")
+	}
+
+	return db.ParseTrigger(when, statement)
+}
@@ -0,0 +1,255 @@
+package database
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/document/encoding"
+	"github.com/genjidb/genji/engine"
+)
+
+// The meta store contains information about the database itself.
+// Its values are stored as raw bytes since they must be readable
+// before the codec of the database is known.
+var (
+	metaStoreName  = internalPrefix + "meta"
+	metaCodecKey   = []byte("codec")
+	metaVersionKey = []byte("version")
+)
+
+// ErrCodecMismatch is returned when a database is opened with a codec
+// that cannot decode the documents it contains.
+type ErrCodecMismatch struct {
+	// Name of the codec persisted in the database.
+	Stored string
+	// Name of the codec used to open the database.
+	Got string
+}
+
+func (e ErrCodecMismatch) Error() string {
+	return fmt.Sprintf("database was created with codec %q, cannot open it with codec %q", e.Stored, e.Got)
+}
+
+// initMetaStore creates the meta store if it doesn't exist.
+func (db *Database) initMetaStore(tx engine.Transaction) (engine.Store, error) {
+	st, err := tx.GetStore([]byte(metaStoreName))
+	if err != engine.ErrStoreNotFound {
+		return st, err
+	}
+
+	err = tx.CreateStore([]byte(metaStoreName))
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.GetStore([]byte(metaStoreName))
+}
+
+// legacyCodecName is the name of the codec used by databases
+// created before the codec was persisted in the meta store.
+const legacyCodecName = "msgpack"
+
+// checkCodec ensures the codec of the database can decode the stored documents.
+// The codec name is persisted the first time the database is opened.
+// Databases that already contain tables but no codec name were created
+// before the codec was persisted, and are assumed to use msgpack.
+// If the database was created with a codec wrapped by the current one,
+// the persisted name is replaced by the name of the wrapper, since
+// new documents may not be decodable by the wrapped codec anymore.
+func (db *Database) checkCodec(tx engine.Transaction, st engine.Store) error {
+	name := db.Codec.Name()
+
+	v, err := st.Get(metaCodecKey)
+	switch err {
+	case nil:
+	case engine.ErrKeyNotFound:
+		legacy, err := hasTables(tx)
+		if err != nil {
+			return err
+		}
+
+		v = nil
+		if legacy {
+			v = []byte(legacyCodecName)
+		}
+	default:
+		return err
+	}
+
+	if v != nil {
+		if string(v) == name {
+			return nil
+		}
+
+		if !codecCanDecode(db.Codec, string(v)) {
+			return &ErrCodecMismatch{Stored: string(v), Got: name}
+		}
+	}
+
+	return st.Put(metaCodecKey, []byte(name))
+}
+
+// hasTables returns whether the table info store contains at least one table.
+func hasTables(tx engine.Transaction) (bool, error) {
+	st, err := tx.GetStore([]byte(tableInfoStoreName))
+	if err != nil {
+		return false, err
+	}
+
+	it := st.Iterator(engine.IteratorOptions{})
+	defer it.Close()
+
+	it.Seek(nil)
+	return it.Valid(), it.Err()
+}
+
+// codecCanDecode returns whether c, or any of the codecs it wraps, is named name.
+func codecCanDecode(c encoding.Codec, name string) bool {
+	for {
+		if c.Name() == name {
+			return true
+		}
+
+		w, ok := c.(encoding.Wrapper)
+		if !ok {
+			return false
+		}
+		c = w.Unwrap()
+	}
+}
+
+// A Migration upgrades the on-disk format of a database
+// from the previous version to Version.
+type Migration struct {
+	// Version of the format once the migration is applied.
+	Version uint64
+	// Up applies the migration. It must only use the given transaction.
+	Up func(tx *Transaction) error
+}
+
+// migrations contains the list of registered migrations, sorted by version.
+var migrations []Migration
+
+func init() {
+	// Version 1 adds optional fields to the catalog: strict tables, TTL fields,
+	// generated and autoincrement field constraints, as well as array element,
+	// partial, expression, covering and full-text indexes.
+	// Their absence keeps the previous behavior, so existing entries are left as is,
+	// but older versions of Genji would silently ignore them.
+	RegisterMigration(Migration{
+		Version: 1,
+		Up: func(tx *Transaction) error {
+			return nil
+		},
+	})
+
+	// Version 2 adds the stores of views, triggers and sequences.
+	RegisterMigration(Migration{
+		Version: 2,
+		Up: func(tx *Transaction) error {
+			return createCatalogStores(tx.tx)
+		},
+	})
+}
+
+// RegisterMigration registers a migration step. It must be called during
+// initialization, and versions must be registered in order, starting from 1.
+// The version of the last registered migration is the current format version.
+// It panics if the version doesn't directly follow the previous one.
+func RegisterMigration(m Migration) {
+	if m.Version != FormatVersion()+1 {
+		panic(fmt.Sprintf("database: migration version %d registered after version %d", m.Version, FormatVersion()))
+	}
+
+	if m.Up == nil {
+		panic("database: migration function is nil")
+	}
+
+	migrations = append(migrations, m)
+}
+
+// FormatVersion returns the current format version.
+// Databases created before the introduction of format versioning are considered
+// to be at version 0.
+func FormatVersion() uint64 {
+	if len(migrations) == 0 {
+		return 0
+	}
+
+	return migrations[len(migrations)-1].Version
+}
+
+// migrate upgrades the database to the current format version by running all the migrations
+// within the given transaction.
+// New databases are directly marked as being at the current version.
+func (db *Database) migrate(ntx engine.Transaction, meta engine.Store, created bool) error {
+	current := FormatVersion()
+
+	if created {
+		return putVersion(meta, current)
+	}
+
+	version, err := getVersion(meta)
+	if err != nil {
+		return err
+	}
+
+	if version == current {
+		return nil
+	}
+
+	if version > current {
+		return fmt.Errorf("database format version %d is newer than the supported version %d", version, current)
+	}
+
+	tx := Transaction{
+		db:       db,
+		tx:       ntx,
+		writable: true,
+	}
+
+	for _, m := range migrations {
+		if m.Version <= version {
+			continue
+		}
+
+		// previous migrations may have created internal stores.
+		err = tx.initStores(true)
+		if err != nil {
+			return err
+		}
+
+		err = m.Up(&tx)
+		if err != nil {
+			return fmt.Errorf("migration to version %d failed: %w", m.Version, err)
+		}
+	}
+
+	return putVersion(meta, current)
+}
+
+// getVersion returns the format version stored in the meta store.
+// If there is none, the database predates versioning and is at version 0.
+func getVersion(meta engine.Store) (uint64, error) {
+	v, err := meta.Get(metaVersionKey)
+	if err == engine.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	version, n := binary.Uvarint(v)
+	if n <= 0 {
+		return 0, errors.New("cannot decode database format version")
+	}
+
+	return version, nil
+}
+
+func putVersion(meta engine.Store, version uint64) error {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, version)
+	return meta.Put(metaVersionKey, buf[:n])
+}
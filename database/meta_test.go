@@ -0,0 +1,258 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/encoding/custom"
+	"github.com/genjidb/genji/document/encoding/msgpack"
+	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/stretchr/testify/require"
+)
+
+// withMigrations replaces the registered migrations for the duration of a test.
+func withMigrations(t *testing.T, ms ...Migration) {
+	old := migrations
+	migrations = nil
+	t.Cleanup(func() {
+		migrations = old
+	})
+
+	for _, m := range ms {
+		RegisterMigration(m)
+	}
+}
+
+func storedVersion(t *testing.T, ng engine.Engine) uint64 {
+	tx, err := ng.Begin(context.Background(), engine.TxOptions{})
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	st, err := tx.GetStore([]byte(metaStoreName))
+	require.NoError(t, err)
+
+	v, err := getVersion(st)
+	require.NoError(t, err)
+	return v
+}
+
+func tableExists(t *testing.T, db *Database, name string) bool {
+	tx, err := db.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	_, err = tx.GetTable(name)
+	if errors.Is(err, ErrTableNotFound) {
+		return false
+	}
+	require.NoError(t, err)
+	return true
+}
+
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+	opts := Options{Codec: msgpack.NewCodec()}
+
+	createTable := func(name string) func(tx *Transaction) error {
+		return func(tx *Transaction) error {
+			return tx.CreateTable(name, nil)
+		}
+	}
+
+	t.Run("New databases are at the current version", func(t *testing.T) {
+		var ran bool
+		withMigrations(t, Migration{Version: 1, Up: func(tx *Transaction) error {
+			ran = true
+			return nil
+		}})
+
+		ng := memoryengine.NewEngine()
+		_, err := New(ctx, ng, opts)
+		require.NoError(t, err)
+		require.False(t, ran)
+		require.EqualValues(t, 1, storedVersion(t, ng))
+	})
+
+	t.Run("Old databases are migrated", func(t *testing.T) {
+		withMigrations(t)
+
+		ng := memoryengine.NewEngine()
+		_, err := New(ctx, ng, opts)
+		require.NoError(t, err)
+		require.EqualValues(t, 0, storedVersion(t, ng))
+
+		withMigrations(t,
+			Migration{Version: 1, Up: createTable("foo")},
+			Migration{Version: 2, Up: createTable("bar")},
+		)
+
+		db, err := New(ctx, ng, opts)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, storedVersion(t, ng))
+		require.True(t, tableExists(t, db, "foo"))
+		require.True(t, tableExists(t, db, "bar"))
+
+		// only the new migrations must be applied
+		withMigrations(t,
+			Migration{Version: 1, Up: createTable("foo")},
+			Migration{Version: 2, Up: createTable("bar")},
+			Migration{Version: 3, Up: createTable("baz")},
+		)
+
+		db, err = New(ctx, ng, opts)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, storedVersion(t, ng))
+		require.True(t, tableExists(t, db, "baz"))
+	})
+
+	t.Run("Failed migrations are rolled back", func(t *testing.T) {
+		withMigrations(t)
+
+		ng := memoryengine.NewEngine()
+		_, err := New(ctx, ng, opts)
+		require.NoError(t, err)
+
+		errFail := errors.New("fail")
+		withMigrations(t,
+			Migration{Version: 1, Up: createTable("foo")},
+			Migration{Version: 2, Up: func(tx *Transaction) error { return errFail }},
+		)
+
+		_, err = New(ctx, ng, opts)
+		require.True(t, errors.Is(err, errFail))
+		require.EqualValues(t, 0, storedVersion(t, ng))
+
+		withMigrations(t)
+		db, err := New(ctx, ng, opts)
+		require.NoError(t, err)
+		require.False(t, tableExists(t, db, "foo"))
+	})
+
+	t.Run("Newer databases are rejected", func(t *testing.T) {
+		withMigrations(t, Migration{Version: 1, Up: createTable("foo")})
+
+		ng := memoryengine.NewEngine()
+		_, err := New(ctx, ng, opts)
+		require.NoError(t, err)
+
+		withMigrations(t)
+		_, err = New(ctx, ng, opts)
+		require.Error(t, err)
+	})
+
+	t.Run("Versions must be registered in order", func(t *testing.T) {
+		withMigrations(t)
+
+		require.Panics(t, func() {
+			RegisterMigration(Migration{Version: 2, Up: createTable("foo")})
+		})
+	})
+}
+
+func TestMigrateFormatVersion0(t *testing.T) {
+	ctx := context.Background()
+	opts := Options{Codec: msgpack.NewCodec()}
+
+	// create a database with a table, then remove everything that was
+	// introduced after the first format version.
+	ng := memoryengine.NewEngine()
+	db, err := New(ctx, ng, opts)
+	require.NoError(t, err)
+
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, tx.CreateTable("foo", nil))
+	tb, err := tx.GetTable("foo")
+	require.NoError(t, err)
+	_, err = tb.Insert(document.NewFieldBuffer().Add("a", document.NewIntegerValue(1)))
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	ntx, err := ng.Begin(ctx, engine.TxOptions{Writable: true})
+	require.NoError(t, err)
+	for _, name := range []string{viewStoreName, triggerStoreName, sequenceStoreName} {
+		require.NoError(t, ntx.DropStore([]byte(name)))
+	}
+	meta, err := ntx.GetStore([]byte(metaStoreName))
+	require.NoError(t, err)
+	require.NoError(t, meta.Delete(metaVersionKey))
+	require.NoError(t, ntx.Commit())
+	require.EqualValues(t, 0, storedVersion(t, ng))
+
+	db, err = New(ctx, ng, opts)
+	require.NoError(t, err)
+	require.Equal(t, FormatVersion(), storedVersion(t, ng))
+
+	tx, err = db.Begin(true)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	tb, err = tx.GetTable("foo")
+	require.NoError(t, err)
+	var count int
+	err = tb.Iterate(func(d document.Document) error {
+		count++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	require.NoError(t, tx.CreateSequence(&SequenceInfo{SequenceName: "seq", Start: 1, Increment: 1}))
+	seqs, err := tx.ListSequences()
+	require.NoError(t, err)
+	require.Len(t, seqs, 1)
+}
+
+func TestLegacyCodec(t *testing.T) {
+	ctx := context.Background()
+
+	// newLegacy creates a database and removes the persisted codec name,
+	// like in databases created before it was stored.
+	newLegacy := func(t *testing.T, withTable bool) engine.Engine {
+		ng := memoryengine.NewEngine()
+		db, err := New(ctx, ng, Options{Codec: msgpack.NewCodec()})
+		require.NoError(t, err)
+
+		if withTable {
+			tx, err := db.Begin(true)
+			require.NoError(t, err)
+			require.NoError(t, tx.CreateTable("foo", nil))
+			require.NoError(t, tx.Commit())
+		}
+
+		tx, err := ng.Begin(ctx, engine.TxOptions{Writable: true})
+		require.NoError(t, err)
+		defer tx.Rollback()
+		st, err := tx.GetStore([]byte(metaStoreName))
+		require.NoError(t, err)
+		require.NoError(t, st.Delete(metaCodecKey))
+		require.NoError(t, tx.Commit())
+		return ng
+	}
+
+	t.Run("Tables are assumed to be encoded with msgpack", func(t *testing.T) {
+		ng := newLegacy(t, true)
+
+		_, err := New(ctx, ng, Options{Codec: custom.NewCodec()})
+		var e *ErrCodecMismatch
+		require.True(t, errors.As(err, &e))
+		require.Equal(t, "msgpack", e.Stored)
+
+		_, err = New(ctx, ng, Options{Codec: msgpack.NewCodec()})
+		require.NoError(t, err)
+
+		// the codec name is now persisted
+		_, err = New(ctx, ng, Options{Codec: custom.NewCodec()})
+		require.Error(t, err)
+	})
+
+	t.Run("Empty databases accept any codec", func(t *testing.T) {
+		ng := newLegacy(t, false)
+
+		_, err := New(ctx, ng, Options{Codec: custom.NewCodec()})
+		require.NoError(t, err)
+	})
+}
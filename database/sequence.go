@@ -0,0 +1,368 @@
+package database
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine"
+)
+
+// SequenceInfo holds the definition and the state of a sequence.
+type SequenceInfo struct {
+	SequenceName string
+
+	// Start is the first value returned by the sequence.
+	Start int64
+	// Increment is added to the last value to get the next one. It can be negative
+	// but not zero.
+	Increment int64
+
+	// Value is the last value returned by the sequence.
+	// It is only meaningful if Called is true.
+	Value  int64
+	Called bool
+}
+
+// ToDocument creates a document from a SequenceInfo.
+func (s *SequenceInfo) ToDocument() document.Document {
+	buf := document.NewFieldBuffer()
+
+	buf.Add("sequence_name", document.NewTextValue(s.SequenceName))
+	buf.Add("start", document.NewIntegerValue(s.Start))
+	buf.Add("increment", document.NewIntegerValue(s.Increment))
+	if s.Called {
+		buf.Add("value", document.NewIntegerValue(s.Value))
+	}
+	return buf
+}
+
+// ScanDocument implements the document.Scanner interface.
+func (s *SequenceInfo) ScanDocument(d document.Document) error {
+	f, err := d.GetByField("sequence_name")
+	if err != nil {
+		return err
+	}
+	s.SequenceName = f.V.(string)
+
+	f, err = d.GetByField("start")
+	if err != nil {
+		return err
+	}
+	s.Start = f.V.(int64)
+
+	f, err = d.GetByField("increment")
+	if err != nil {
+		return err
+	}
+	s.Increment = f.V.(int64)
+
+	f, err = d.GetByField("value")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		s.Value = f.V.(int64)
+		s.Called = true
+	}
+
+	return nil
+}
+
+// next computes the next value of the sequence and stores it in s.Value.
+func (s *SequenceInfo) next() (int64, error) {
+	if !s.Called {
+		s.Value = s.Start
+		s.Called = true
+		return s.Value, nil
+	}
+
+	if (s.Increment > 0 && s.Value > math.MaxInt64-s.Increment) ||
+		(s.Increment < 0 && s.Value < math.MinInt64-s.Increment) {
+		return 0, fmt.Errorf("sequence %q reached its limit", s.SequenceName)
+	}
+
+	s.Value += s.Increment
+	return s.Value, nil
+}
+
+// sequenceStore manages the definitions and the state of the sequences.
+type sequenceStore struct {
+	db *Database
+	st engine.Store
+}
+
+func (t *sequenceStore) Insert(info SequenceInfo) error {
+	key := []byte(info.SequenceName)
+	_, err := t.st.Get(key)
+	if err == nil {
+		return ErrSequenceAlreadyExists
+	}
+	if err != engine.ErrKeyNotFound {
+		return err
+	}
+
+	return t.put(info)
+}
+
+func (t *sequenceStore) Replace(info SequenceInfo) error {
+	return t.put(info)
+}
+
+func (t *sequenceStore) put(info SequenceInfo) error {
+	var buf bytes.Buffer
+	enc := t.db.Codec.NewEncoder(&buf)
+	defer enc.Close()
+	err := enc.EncodeDocument(info.ToDocument())
+	if err != nil {
+		return err
+	}
+
+	return t.st.Put([]byte(info.SequenceName), buf.Bytes())
+}
+
+func (t *sequenceStore) Get(sequenceName string) (*SequenceInfo, error) {
+	v, err := t.st.Get([]byte(sequenceName))
+	if err == engine.ErrKeyNotFound {
+		return nil, fmt.Errorf("%w: %q", ErrSequenceNotFound, sequenceName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var info SequenceInfo
+	err = info.ScanDocument(t.db.Codec.NewDocument(v))
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+func (t *sequenceStore) Delete(sequenceName string) error {
+	err := t.st.Delete([]byte(sequenceName))
+	if err == engine.ErrKeyNotFound {
+		return fmt.Errorf("%w: %q", ErrSequenceNotFound, sequenceName)
+	}
+	return err
+}
+
+func (t *sequenceStore) ListAll() ([]*SequenceInfo, error) {
+	it := t.st.Iterator(engine.IteratorOptions{})
+	defer it.Close()
+
+	var list []*SequenceInfo
+	var buf []byte
+	var err error
+	for it.Seek(nil); it.Valid(); it.Next() {
+		buf, err = it.Item().ValueCopy(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		var info SequenceInfo
+		err = info.ScanDocument(t.db.Codec.NewDocument(buf))
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, &info)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (tx *Transaction) getSequenceStore() (*sequenceStore, error) {
+	st, err := tx.tx.GetStore([]byte(sequenceStoreName))
+	if err != nil {
+		return nil, err
+	}
+	return &sequenceStore{
+		st: st,
+		db: tx.db,
+	}, nil
+}
+
+// CreateSequence creates a sequence. The Value and Called fields are stored as is,
+// which allows restoring the state of an existing sequence.
+// If a sequence with the same name already exists, returns ErrSequenceAlreadyExists.
+func (tx *Transaction) CreateSequence(info *SequenceInfo) error {
+	if strings.HasPrefix(info.SequenceName, internalPrefix) {
+		return fmt.Errorf("sequence name must not start with %s", internalPrefix)
+	}
+
+	if info.Increment == 0 {
+		return errors.New("the increment of a sequence cannot be zero")
+	}
+
+	return tx.sequenceStore.Insert(*info)
+}
+
+// GetSequence returns the definition and the state of a sequence.
+// If it doesn't exist, it returns ErrSequenceNotFound.
+func (tx *Transaction) GetSequence(name string) (*SequenceInfo, error) {
+	return tx.sequenceStore.Get(name)
+}
+
+// DropSequence deletes a sequence.
+// If it doesn't exist, it returns ErrSequenceNotFound.
+// Sequences of AUTOINCREMENT fields are dropped with their table.
+func (tx *Transaction) DropSequence(name string) error {
+	if strings.HasPrefix(name, internalPrefix) {
+		return fmt.Errorf("cannot drop internal sequence %q", name)
+	}
+
+	return tx.sequenceStore.Delete(name)
+}
+
+// ListSequences lists all the sequences of the database, sorted by name.
+// It includes the internal sequences of the AUTOINCREMENT fields.
+func (tx *Transaction) ListSequences() ([]*SequenceInfo, error) {
+	return tx.sequenceStore.ListAll()
+}
+
+// NextSequenceValue advances the sequence and returns its new value.
+func (tx *Transaction) NextSequenceValue(name string) (int64, error) {
+	info, err := tx.sequenceStore.Get(name)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := info.next()
+	if err != nil {
+		return 0, err
+	}
+
+	return v, tx.sequenceStore.Replace(*info)
+}
+
+// CurrentSequenceValue returns the last value returned by the sequence.
+// It returns an error if the sequence never returned any value.
+func (tx *Transaction) CurrentSequenceValue(name string) (int64, error) {
+	info, err := tx.sequenceStore.Get(name)
+	if err != nil {
+		return 0, err
+	}
+
+	if !info.Called {
+		return 0, fmt.Errorf("sequence %q has not returned any value yet", name)
+	}
+
+	return info.Value, nil
+}
+
+// autoIncrementSequenceName returns the name of the sequence used to fill the
+// AUTOINCREMENT primary key of a table.
+// It is based on the name of the store, which doesn't change when the table is renamed.
+func autoIncrementSequenceName(info *TableInfo) string {
+	return internalPrefix + "autoincrement_" + hex.EncodeToString(info.storeName)
+}
+
+// checkAutoIncrement ensures the AUTOINCREMENT constraint is only set on an integer primary key.
+func checkAutoIncrement(fc *FieldConstraint) error {
+	if !fc.IsAutoIncrement {
+		return nil
+	}
+
+	if !fc.IsPrimaryKey || fc.Type != document.IntegerValue {
+		return fmt.Errorf("AUTOINCREMENT field %q must be an INTEGER PRIMARY KEY", fc.Path)
+	}
+
+	return nil
+}
+
+// nextAutoIncrement returns the next value of the sequence of the AUTOINCREMENT primary key.
+// If the sequence never returned any value, it continues after the greatest primary key of
+// the table, since documents may have been copied without going through Insert.
+func (t *Table) nextAutoIncrement(info *TableInfo, pk *FieldConstraint) (int64, error) {
+	seq, err := t.tx.sequenceStore.Get(autoIncrementSequenceName(info))
+	if err != nil {
+		return 0, err
+	}
+
+	if !seq.Called {
+		max, ok, err := t.maxPrimaryKey(pk)
+		if err != nil {
+			return 0, err
+		}
+		if ok && max >= seq.Start {
+			seq.Value = max
+			seq.Called = true
+		}
+	}
+
+	v, err := seq.next()
+	if err != nil {
+		return 0, err
+	}
+
+	return v, t.tx.sequenceStore.Replace(*seq)
+}
+
+// fillAutoIncrement sets the AUTOINCREMENT primary key of fb to the next value of
+// the sequence of the table and returns it.
+func (t *Table) fillAutoIncrement(info *TableInfo, pk *FieldConstraint, fb *document.FieldBuffer) (document.Value, error) {
+	n, err := t.nextAutoIncrement(info, pk)
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	v := document.NewIntegerValue(n)
+	return v, fb.Set(pk.Path, v)
+}
+
+// updateAutoIncrement makes sure the sequence of the AUTOINCREMENT primary key
+// never returns v or a smaller value afterwards, when v is written explicitly.
+func (t *Table) updateAutoIncrement(info *TableInfo, v document.Value) error {
+	if v.Type != document.IntegerValue {
+		return nil
+	}
+
+	seq, err := t.tx.sequenceStore.Get(autoIncrementSequenceName(info))
+	if err != nil {
+		return err
+	}
+
+	n := v.V.(int64)
+	if n < seq.Start || (seq.Called && n <= seq.Value) {
+		return nil
+	}
+
+	seq.Value = n
+	seq.Called = true
+	return t.tx.sequenceStore.Replace(*seq)
+}
+
+// maxPrimaryKey returns the greatest integer primary key of the table.
+// It returns false if the table is empty.
+func (t *Table) maxPrimaryKey(pk *FieldConstraint) (int64, bool, error) {
+	it := t.Store.Iterator(engine.IteratorOptions{Reverse: true})
+	defer it.Close()
+
+	it.Seek(nil)
+	if !it.Valid() {
+		return 0, false, it.Err()
+	}
+
+	v, err := it.Item().ValueCopy(nil)
+	if err != nil {
+		return 0, false, err
+	}
+
+	pv, err := pk.Path.GetValueFromDocument(t.tx.db.Codec.NewDocument(v))
+	if err != nil {
+		return 0, false, err
+	}
+	if pv.Type != document.IntegerValue {
+		return 0, false, nil
+	}
+
+	return pv.V.(int64), true, nil
+}
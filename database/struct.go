@@ -0,0 +1,209 @@
+// +build !wasm
+
+package database
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/genjidb/genji/document"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	documentType = reflect.TypeOf((*document.Document)(nil)).Elem()
+	arrayType    = reflect.TypeOf((*document.Array)(nil)).Elem()
+)
+
+// TableInfoFromStruct derives the field constraints of a table from the fields of the struct v,
+// as well as the indexes declared in their "genji" tag.
+// Each struct field is mapped to a field constraint whose type depends on the Go type of the
+// struct field, and whose path is determined by the document.StructFieldTag function.
+// Fields of nested structs are mapped to nested paths and fields of embedded structs are
+// mapped as if they were part of v.
+// The following options can be added to the tag, after the name of the field:
+//
+//	pk             the field is the primary key of the table
+//	notnull        the field is required
+//	default=value  the default value of the field, converted to its type
+//	index          an index is created on the field
+//	unique         a unique index is created on the field
+//
+// For example:
+//
+//	type User struct {
+//	    ID    int64  `genji:"id,pk"`
+//	    Email string `genji:"email,unique,notnull"`
+//	    Age   int    `genji:"age,default=18"`
+//	}
+func TableInfoFromStruct(tableName string, v interface{}) (*TableInfo, []IndexConfig, error) {
+	tp := reflect.TypeOf(v)
+	for tp != nil && tp.Kind() == reflect.Ptr {
+		tp = tp.Elem()
+	}
+
+	if tp == nil || tp.Kind() != reflect.Struct {
+		return nil, nil, errors.New("expected struct or pointer to struct")
+	}
+
+	var info TableInfo
+	var indexes []IndexConfig
+	err := structConstraints(tableName, tp, nil, &info, &indexes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &info, indexes, nil
+}
+
+func structConstraints(tableName string, tp reflect.Type, parent document.Path, info *TableInfo, indexes *[]IndexConfig) error {
+	for i := 0; i < tp.NumField(); i++ {
+		sf := tp.Field(i)
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if sf.Anonymous {
+			if ft.Kind() != reflect.Struct {
+				continue
+			}
+
+			err := structConstraints(tableName, ft, parent, info, indexes)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// unexported fields are ignored
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		name, options, ok := document.StructFieldTag(sf)
+		if !ok {
+			continue
+		}
+
+		fc := FieldConstraint{
+			Path: append(parent[:len(parent):len(parent)], document.PathFragment{FieldName: name}),
+			Type: valueTypeOf(ft),
+		}
+
+		var defaultValue string
+		var hasDefault, index, unique bool
+		for _, opt := range options {
+			switch {
+			case opt == "pk":
+				fc.IsPrimaryKey = true
+			case opt == "notnull":
+				fc.IsNotNull = true
+			case opt == "index":
+				index = true
+			case opt == "unique":
+				unique = true
+			case strings.HasPrefix(opt, "default="):
+				defaultValue = strings.TrimPrefix(opt, "default=")
+				hasDefault = true
+			default:
+				return fmt.Errorf("unknown option %q for field %q", opt, fc.Path)
+			}
+		}
+
+		if hasDefault {
+			var err error
+			fc.DefaultValue = document.NewTextValue(defaultValue)
+			if fc.Type != 0 {
+				fc.DefaultValue, err = fc.DefaultValue.CastAs(fc.Type)
+				if err != nil {
+					return fmt.Errorf("invalid default value for field %q: %w", fc.Path, err)
+				}
+			}
+		}
+
+		if fc.Type != 0 || fc.IsPrimaryKey || fc.IsNotNull || hasDefault {
+			if fc.IsPrimaryKey && info.GetPrimaryKey() != nil {
+				return fmt.Errorf("multiple primary keys are not allowed (%q is primary key)", info.GetPrimaryKey().Path)
+			}
+
+			info.FieldConstraints = append(info.FieldConstraints, fc)
+		}
+
+		if index || unique {
+			*indexes = append(*indexes, IndexConfig{
+				TableName: tableName,
+				IndexName: structIndexName(tableName, fc.Path),
+				Path:      fc.Path,
+				Unique:    unique,
+			})
+		}
+
+		// the fields of nested structs are mapped to nested paths
+		if ft.Kind() == reflect.Struct && ft != timeType {
+			err := structConstraints(tableName, ft, fc.Path, info, indexes)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// structIndexName returns the name of the index created on the given path.
+func structIndexName(tableName string, path document.Path) string {
+	var b strings.Builder
+	b.WriteString("idx_")
+	b.WriteString(tableName)
+	for _, f := range path {
+		b.WriteByte('_')
+		if f.FieldName != "" {
+			b.WriteString(f.FieldName)
+		} else {
+			fmt.Fprintf(&b, "%d", f.ArrayIndex)
+		}
+	}
+
+	return b.String()
+}
+
+// valueTypeOf returns the type of the values created from the Go type tp
+// by the document.NewValue function. It returns 0 if the type cannot be determined.
+func valueTypeOf(tp reflect.Type) document.ValueType {
+	switch {
+	case tp == timeType:
+		return document.TextValue
+	case tp.Implements(documentType):
+		return document.DocumentValue
+	case tp.Implements(arrayType):
+		return document.ArrayValue
+	}
+
+	switch tp.Kind() {
+	case reflect.Bool:
+		return document.BoolValue
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return document.IntegerValue
+	case reflect.Float32, reflect.Float64:
+		return document.DoubleValue
+	case reflect.String:
+		return document.TextValue
+	case reflect.Slice:
+		if tp.Elem().Kind() == reflect.Uint8 {
+			return document.BlobValue
+		}
+		return document.ArrayValue
+	case reflect.Array:
+		return document.ArrayValue
+	case reflect.Struct, reflect.Map:
+		return document.DocumentValue
+	}
+
+	return 0
+}
@@ -0,0 +1,91 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableInfoFromStruct(t *testing.T) {
+	type Address struct {
+		City    string `genji:"city,notnull"`
+		ZipCode string `genji:"zipcode,index"`
+	}
+
+	type Timestamps struct {
+		CreatedAt time.Time `genji:"created_at"`
+	}
+
+	type User struct {
+		ID       int64             `genji:"id,pk"`
+		Email    string            `genji:"email,unique,notnull"`
+		Age      int               `genji:"age,default=18"`
+		Score    *float64          `genji:"score"`
+		Active   bool              `genji:"active,default=true"`
+		Avatar   []byte            `genji:"avatar"`
+		Tags     []string          `genji:"tags"`
+		Address  Address           `genji:"address"`
+		Extra    map[string]string `genji:"extra"`
+		Any      interface{}       `genji:"any"`
+		Ignored  int               `genji:"-"`
+		Duration time.Duration
+		Timestamps
+
+		unexported int
+	}
+
+	path := func(p ...string) document.Path {
+		var path document.Path
+		for _, f := range p {
+			path = append(path, document.PathFragment{FieldName: f})
+		}
+		return path
+	}
+
+	info, indexes, err := database.TableInfoFromStruct("users", &User{})
+	require.NoError(t, err)
+	require.Equal(t, database.FieldConstraints{
+		{Path: path("id"), Type: document.IntegerValue, IsPrimaryKey: true},
+		{Path: path("email"), Type: document.TextValue, IsNotNull: true},
+		{Path: path("age"), Type: document.IntegerValue, DefaultValue: document.NewIntegerValue(18)},
+		{Path: path("score"), Type: document.DoubleValue},
+		{Path: path("active"), Type: document.BoolValue, DefaultValue: document.NewBoolValue(true)},
+		{Path: path("avatar"), Type: document.BlobValue},
+		{Path: path("tags"), Type: document.ArrayValue},
+		{Path: path("address"), Type: document.DocumentValue},
+		{Path: path("address", "city"), Type: document.TextValue, IsNotNull: true},
+		{Path: path("address", "zipcode"), Type: document.TextValue},
+		{Path: path("extra"), Type: document.DocumentValue},
+		{Path: path("duration"), Type: document.IntegerValue},
+		{Path: path("created_at"), Type: document.TextValue},
+	}, info.FieldConstraints)
+
+	require.Equal(t, []database.IndexConfig{
+		{TableName: "users", IndexName: "idx_users_email", Path: path("email"), Unique: true},
+		{TableName: "users", IndexName: "idx_users_address_zipcode", Path: path("address", "zipcode")},
+	}, indexes)
+
+	t.Run("errors", func(t *testing.T) {
+		_, _, err := database.TableInfoFromStruct("foo", 10)
+		require.Error(t, err)
+
+		_, _, err = database.TableInfoFromStruct("foo", struct {
+			A int `genji:"a,pk"`
+			B int `genji:"b,pk"`
+		}{})
+		require.Error(t, err)
+
+		_, _, err = database.TableInfoFromStruct("foo", struct {
+			A int `genji:"a,unknown"`
+		}{})
+		require.Error(t, err)
+
+		_, _, err = database.TableInfoFromStruct("foo", struct {
+			A int `genji:"a,default=foo"`
+		}{})
+		require.Error(t, err)
+	})
+}
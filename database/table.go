@@ -44,6 +44,7 @@ func (t *Table) Truncate() error {
 // If a primary key has been specified during the table creation, the field is expected to be present
 // in the given document.
 // If no primary key has been selected, a monotonic autoincremented integer key will be generated.
+// The INSERT triggers of the table are fired before and after the document is inserted.
 func (t *Table) Insert(d document.Document) ([]byte, error) {
 	info, err := t.Info()
 	if err != nil {
@@ -54,7 +55,17 @@ func (t *Table) Insert(d document.Document) ([]byte, error) {
 		return nil, errors.New("cannot write to read-only table")
 	}
 
-	fb, err := info.FieldConstraints.ValidateDocument(d)
+	triggers, err := t.triggers(TriggerInsert)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err = t.fireTriggers(triggers, true, d, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	fb, err := info.ValidateDocument(d)
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +77,12 @@ func (t *Table) Insert(d document.Document) ([]byte, error) {
 
 	_, err = t.Store.Get(key)
 	if err == nil {
-		return nil, ErrDuplicateDocument
+		// an expired document that has not been purged yet
+		// is deleted to make room for the new one.
+		err = t.deleteIfExpired(info, key)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	var buf bytes.Buffer
@@ -88,19 +104,33 @@ func (t *Table) Insert(d document.Document) ([]byte, error) {
 	}
 
 	for _, idx := range indexes {
-		v, err := idx.Opts.Path.GetValueFromDocument(fb)
-		if err != nil {
-			v = document.NewNullValue()
+		values, err := idx.values(fb)
+		if err == document.ErrFieldNotFound {
+			values = []document.Value{document.NewNullValue()}
+		} else if err != nil {
+			return nil, err
 		}
 
-		err = idx.Set(v, key)
+		entry, err := idx.entry(fb, key)
 		if err != nil {
-			if err == index.ErrDuplicate {
-				return nil, ErrDuplicateDocument
-			}
-
 			return nil, err
 		}
+
+		for _, v := range values {
+			err = idx.Set(v, entry)
+			if err != nil {
+				if err == index.ErrDuplicate {
+					return nil, ErrDuplicateDocument
+				}
+
+				return nil, err
+			}
+		}
+	}
+
+	_, err = t.fireTriggers(triggers, false, fb, nil)
+	if err != nil {
+		return nil, err
 	}
 
 	return key, nil
@@ -108,6 +138,7 @@ func (t *Table) Insert(d document.Document) ([]byte, error) {
 
 // Delete a document by key.
 // Indexes are automatically updated.
+// The DELETE triggers of the table are fired before and after the document is deleted.
 func (t *Table) Delete(key []byte) error {
 	info, err := t.Info()
 	if err != nil {
@@ -123,18 +154,34 @@ func (t *Table) Delete(key []byte) error {
 		return err
 	}
 
+	triggers, err := t.triggers(TriggerDelete)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.fireTriggers(triggers, true, nil, d)
+	if err != nil {
+		return err
+	}
+
+	err = t.delete(key, d)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.fireTriggers(triggers, false, nil, d)
+	return err
+}
+
+// delete the document d stored at key, without firing triggers.
+func (t *Table) delete(key []byte, d document.Document) error {
 	indexes, err := t.Indexes()
 	if err != nil {
 		return err
 	}
 
 	for _, idx := range indexes {
-		v, err := idx.Opts.Path.GetValueFromDocument(d)
-		if err != nil {
-			return err
-		}
-
-		err = idx.Delete(v, key)
+		err = idx.deleteDocument(d, key)
 		if err != nil {
 			return err
 		}
@@ -146,6 +193,7 @@ func (t *Table) Delete(key []byte) error {
 // Replace a document by key.
 // An error is returned if the key doesn't exist.
 // Indexes are automatically updated.
+// The UPDATE triggers of the table are fired before and after the document is replaced.
 func (t *Table) Replace(key []byte, d document.Document) error {
 	info, err := t.Info()
 	if err != nil {
@@ -156,17 +204,267 @@ func (t *Table) Replace(key []byte, d document.Document) error {
 		return errors.New("cannot write to read-only table")
 	}
 
-	d, err = info.FieldConstraints.ValidateDocument(d)
+	triggers, err := t.triggers(TriggerUpdate)
+	if err != nil {
+		return err
+	}
+
+	var old document.Document
+	if len(triggers) > 0 {
+		old, err = t.GetDocument(key)
+		if err != nil {
+			return err
+		}
+
+		d, err = t.fireTriggers(triggers, true, d, old)
+		if err != nil {
+			return err
+		}
+	}
+
+	d, err = t.validateAndReplace(info, key, d)
 	if err != nil {
 		return err
 	}
 
+	_, err = t.fireTriggers(triggers, false, d, old)
+	return err
+}
+
+// validateAndReplace validates d and replaces the document stored at key, without firing triggers.
+// It returns the validated document.
+func (t *Table) validateAndReplace(info *TableInfo, key []byte, d document.Document) (document.Document, error) {
+	d, err := t.removeUnchangedGenerated(info, key, d)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err = info.ValidateDocument(d)
+	if err != nil {
+		return nil, err
+	}
+
 	indexes, err := t.Indexes()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, t.replace(indexes, key, d)
+}
+
+// removeUnchangedGenerated removes from d the generated fields whose value is the same
+// as in the stored document, so that they can be computed again.
+func (t *Table) removeUnchangedGenerated(info *TableInfo, key []byte, d document.Document) (document.Document, error) {
+	var fb *document.FieldBuffer
+	var old document.Document
+
+	for _, fc := range info.FieldConstraints {
+		if !fc.IsGenerated() {
+			continue
+		}
+
+		if fb == nil {
+			var err error
+			old, err = t.GetDocument(key)
+			if err != nil {
+				return nil, err
+			}
+
+			fb = document.NewFieldBuffer()
+			err = fb.Copy(d)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		v, err := fc.Path.GetValueFromDocument(fb)
+		if err == document.ErrFieldNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ov, err := fc.Path.GetValueFromDocument(old)
+		if err == document.ErrFieldNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ok, err := v.IsEqual(ov)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			err = fb.Delete(fc.Path)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if fb == nil {
+		return d, nil
+	}
+
+	return fb, nil
+}
+
+// Rewrite replaces every document of the table by itself, after calling fn on it, if fn is not nil.
+// Each document is validated and converted using the current field constraints of the table,
+// which makes it possible to apply new constraints to existing documents.
+// Indexes are automatically updated. Triggers are not fired.
+func (t *Table) Rewrite(fn func(fb *document.FieldBuffer) error) error {
+	info, err := t.Info()
 	if err != nil {
 		return err
 	}
 
-	return t.replace(indexes, key, d)
+	if info.readOnly {
+		return errors.New("cannot write to read-only table")
+	}
+
+	// some engines don't support writing while iterating,
+	// all the keys are read before replacing the documents.
+	var keys [][]byte
+	it := t.Store.Iterator(engine.IteratorOptions{})
+	for it.Seek(nil); it.Valid(); it.Next() {
+		keys = append(keys, append([]byte{}, it.Item().Key()...))
+	}
+	err = it.Err()
+	it.Close()
+	if err != nil {
+		return err
+	}
+
+	fb := document.NewFieldBuffer()
+	for _, key := range keys {
+		d, err := t.GetDocument(key)
+		if err != nil {
+			return err
+		}
+
+		fb.Reset()
+		err = fb.Copy(d)
+		if err != nil {
+			return err
+		}
+
+		if fn != nil {
+			err = fn(fb)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = t.validateAndReplace(info, key, fb)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renameField renames the field found at path in every document of the table.
+// Indexes are not updated.
+func (t *Table) renameField(path document.Path, newName string) error {
+	var keys [][]byte
+	it := t.Store.Iterator(engine.IteratorOptions{})
+	for it.Seek(nil); it.Valid(); it.Next() {
+		keys = append(keys, append([]byte{}, it.Item().Key()...))
+	}
+	err := it.Err()
+	it.Close()
+	if err != nil {
+		return err
+	}
+
+	parent := path[:len(path)-1]
+	oldName := path[len(path)-1].FieldName
+
+	for _, key := range keys {
+		d, err := t.GetDocument(key)
+		if err != nil {
+			return err
+		}
+
+		fb := document.NewFieldBuffer()
+		err = fb.Copy(d)
+		if err != nil {
+			return err
+		}
+
+		var pd document.Document = fb
+		if len(parent) > 0 {
+			v, err := parent.GetValueFromDocument(fb)
+			if err == document.ErrFieldNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if v.Type != document.DocumentValue {
+				continue
+			}
+			pd = v.V.(document.Document)
+		}
+
+		renamed, ok, err := renameDocumentField(pd, oldName, newName)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		if len(parent) > 0 {
+			err = fb.Set(parent, document.NewDocumentValue(renamed))
+			if err != nil {
+				return err
+			}
+		} else {
+			fb = renamed
+		}
+
+		var buf bytes.Buffer
+		enc := t.tx.db.Codec.NewEncoder(&buf)
+		err = enc.EncodeDocument(fb)
+		enc.Close()
+		if err != nil {
+			return fmt.Errorf("failed to encode document: %w", err)
+		}
+
+		err = t.Store.Put(key, buf.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renameDocumentField returns a copy of d where the field oldName is renamed to newName,
+// preserving the order of the fields. It returns false if d doesn't contain oldName.
+func renameDocumentField(d document.Document, oldName, newName string) (*document.FieldBuffer, bool, error) {
+	var found bool
+	fb := document.NewFieldBuffer()
+	err := d.Iterate(func(field string, v document.Value) error {
+		switch field {
+		case newName:
+			return fmt.Errorf("field %q already exists", newName)
+		case oldName:
+			found = true
+			field = newName
+		}
+
+		fb.Add(field, v)
+		return nil
+	})
+
+	return fb, found, err
 }
 
 func (t *Table) replace(indexes map[string]Index, key []byte, d document.Document) error {
@@ -178,12 +476,7 @@ func (t *Table) replace(indexes map[string]Index, key []byte, d document.Documen
 
 	// remove key from indexes
 	for _, idx := range indexes {
-		v, err := idx.Opts.Path.GetValueFromDocument(old)
-		if err != nil {
-			return err
-		}
-
-		err = idx.Delete(v, key)
+		err = idx.deleteDocument(old, key)
 		if err != nil {
 			return err
 		}
@@ -206,21 +499,34 @@ func (t *Table) replace(indexes map[string]Index, key []byte, d document.Documen
 
 	// update indexes
 	for _, idx := range indexes {
-		v, err := idx.Opts.Path.GetValueFromDocument(d)
-		if err != nil {
+		values, err := idx.values(d)
+		if err == document.ErrFieldNotFound {
 			continue
 		}
+		if err != nil {
+			return err
+		}
 
-		err = idx.Set(v, key)
+		entry, err := idx.entry(d, key)
 		if err != nil {
 			return err
 		}
+
+		for _, v := range values {
+			err = idx.Set(v, entry)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return err
 }
 
-// Indexes returns a map of all the indexes of a table.
+// Indexes returns a map of all the indexes of a table, keyed by the path they index.
+// Indexes on array elements are keyed by their path followed by "[*]".
+// Full-text indexes are keyed by "FULLTEXT " followed by their path.
+// Partial indexes are keyed by their path followed by " WHERE " and their predicate.
 func (t *Table) Indexes() (map[string]Index, error) {
 	s, err := t.tx.tx.GetStore([]byte(indexStoreName))
 	if err != nil {
@@ -251,15 +557,19 @@ func (t *Table) Indexes() (map[string]Index, error) {
 				return err
 			}
 
-			idx := index.New(t.tx.tx, opts.IndexName, index.Options{
-				Unique: opts.Unique,
-				Type:   opts.Type,
-			})
+			idx, err := newIndex(t.tx, opts)
+			if err != nil {
+				return err
+			}
 
-			indexes[opts.Path.String()] = Index{
-				Index: idx,
-				Opts:  opts,
+			key := opts.Key()
+			if opts.FullText {
+				key = "FULLTEXT " + key
+			}
+			if opts.Predicate != "" {
+				key += " WHERE " + opts.Predicate
 			}
+			indexes[key] = *idx
 
 			return nil
 		})
@@ -413,12 +723,21 @@ func (t *Table) GetDocument(key []byte) (document.Document, error) {
 // if the table has a primary key, it extracts the field from
 // the document, converts it to the targeted type and returns
 // its encoded version.
+// if the primary key is auto-incremented and missing, it is
+// filled from the sequence of the table.
 // if there are no primary key in the table, a default
 // key is generated, called the docid.
 func (t *Table) generateKey(info *TableInfo, fb *document.FieldBuffer) ([]byte, error) {
 	if pk := info.GetPrimaryKey(); pk != nil {
 
 		v, err := pk.Path.GetValueFromDocument(fb)
+		switch {
+		case !pk.IsAutoIncrement:
+		case err == document.ErrFieldNotFound || (err == nil && v.Type == document.NullValue):
+			v, err = t.fillAutoIncrement(info, pk, fb)
+		case err == nil:
+			err = t.updateAutoIncrement(info, v)
+		}
 		if err == document.ErrFieldNotFound {
 			return nil, fmt.Errorf("missing primary key at path %q", pk.Path)
 		}
@@ -426,30 +745,73 @@ func (t *Table) generateKey(info *TableInfo, fb *document.FieldBuffer) ([]byte,
 			return nil, err
 		}
 
-		// if a primary key type is specified,
-		// encode the key using the optimized encoding solution
-		if pk.Type != 0 {
-			return v.MarshalBinary()
-		}
+		return encodePrimaryKey(pk, v)
+	}
 
-		// it no primary key type is specified,
-		// encode keys regardless of type.
-		var buf bytes.Buffer
-		err = document.NewValueEncoder(&buf).Encode(v)
+	docid, err := t.Store.NextSequence()
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeDocID(docid), nil
+}
+
+// EncodeKey returns the key of the document whose primary key is pk.
+// The value is converted the same way it would be when inserting a document,
+// so that the returned key can be passed to GetDocument.
+// If the table has no primary key, pk is expected to be the docid of the document.
+func (t *Table) EncodeKey(pk document.Value) ([]byte, error) {
+	info, err := t.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	if fc := info.GetPrimaryKey(); fc != nil {
+		switch {
+		case fc.Type != 0:
+			pk, err = pk.CastAs(fc.Type)
+		case pk.Type == document.IntegerValue:
+			pk, err = pk.CastAsDouble()
+		}
 		if err != nil {
 			return nil, err
 		}
-		return buf.Bytes(), nil
+
+		return encodePrimaryKey(fc, pk)
 	}
 
-	docid, err := t.Store.NextSequence()
+	v, err := pk.CastAsInteger()
 	if err != nil {
 		return nil, err
 	}
+	if v.V.(int64) < 0 {
+		return nil, fmt.Errorf("invalid docid %d", v.V)
+	}
+
+	return encodeDocID(uint64(v.V.(int64))), nil
+}
+
+func encodePrimaryKey(pk *FieldConstraint, v document.Value) ([]byte, error) {
+	// if a primary key type is specified,
+	// encode the key using the optimized encoding solution
+	if pk.Type != 0 {
+		return v.MarshalBinary()
+	}
+
+	// it no primary key type is specified,
+	// encode keys regardless of type.
+	var buf bytes.Buffer
+	err := document.NewValueEncoder(&buf).Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
+func encodeDocID(docid uint64) []byte {
 	buf := make([]byte, binary.MaxVarintLen64)
 	n := binary.PutUvarint(buf, docid)
-	return buf[:n], nil
+	return buf[:n]
 }
 
 // ReIndex all the indexes of the table.
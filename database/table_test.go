@@ -1,11 +1,13 @@
 package database_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/genjidb/genji/binarysort"
 	"github.com/genjidb/genji/database"
@@ -342,8 +344,8 @@ func TestTableInsert(t *testing.T) {
 
 		err := tx.CreateTable("test", &database.TableInfo{
 			FieldConstraints: []database.FieldConstraint{
-				{parsePath(t, "foo"), document.IntegerValue, false, false, document.Value{}},
-				{parsePath(t, "bar"), document.IntegerValue, false, false, document.Value{}},
+				{Path: parsePath(t, "foo"), Type: document.IntegerValue},
+				{Path: parsePath(t, "bar"), Type: document.IntegerValue},
 			},
 		})
 		require.NoError(t, err)
@@ -383,7 +385,7 @@ func TestTableInsert(t *testing.T) {
 
 		err := tx.CreateTable("test", &database.TableInfo{
 			FieldConstraints: []database.FieldConstraint{
-				{parsePath(t, "foo"), document.DoubleValue, false, false, document.Value{}},
+				{Path: parsePath(t, "foo"), Type: document.DoubleValue},
 			},
 		})
 		require.NoError(t, err)
@@ -405,7 +407,7 @@ func TestTableInsert(t *testing.T) {
 		// no enforced type, not null
 		err := tx.CreateTable("test1", &database.TableInfo{
 			FieldConstraints: []database.FieldConstraint{
-				{parsePath(t, "foo"), 0, false, true, document.Value{}},
+				{Path: parsePath(t, "foo"), IsNotNull: true},
 			},
 		})
 		require.NoError(t, err)
@@ -415,7 +417,7 @@ func TestTableInsert(t *testing.T) {
 		// enforced type, not null
 		err = tx.CreateTable("test2", &database.TableInfo{
 			FieldConstraints: []database.FieldConstraint{
-				{parsePath(t, "foo"), document.IntegerValue, false, true, document.Value{}},
+				{Path: parsePath(t, "foo"), Type: document.IntegerValue, IsNotNull: true},
 			},
 		})
 		require.NoError(t, err)
@@ -460,7 +462,7 @@ func TestTableInsert(t *testing.T) {
 		// no enforced type, not null
 		err := tx.CreateTable("test1", &database.TableInfo{
 			FieldConstraints: []database.FieldConstraint{
-				{parsePath(t, "foo"), 0, false, true, document.NewIntegerValue(42)},
+				{Path: parsePath(t, "foo"), IsNotNull: true, DefaultValue: document.NewIntegerValue(42)},
 			},
 		})
 		require.NoError(t, err)
@@ -470,7 +472,7 @@ func TestTableInsert(t *testing.T) {
 		// enforced type, not null
 		err = tx.CreateTable("test2", &database.TableInfo{
 			FieldConstraints: []database.FieldConstraint{
-				{parsePath(t, "foo"), document.IntegerValue, false, true, document.NewIntegerValue(42)},
+				{Path: parsePath(t, "foo"), Type: document.IntegerValue, IsNotNull: true, DefaultValue: document.NewIntegerValue(42)},
 			},
 		})
 		require.NoError(t, err)
@@ -526,7 +528,7 @@ func TestTableInsert(t *testing.T) {
 
 		err := tx.CreateTable("test1", &database.TableInfo{
 			FieldConstraints: []database.FieldConstraint{
-				{parsePath(t, "foo[1]"), 0, false, true, document.Value{}},
+				{Path: parsePath(t, "foo[1]"), IsNotNull: true},
 			},
 		})
 		require.NoError(t, err)
@@ -542,6 +544,40 @@ func TestTableInsert(t *testing.T) {
 				Append(document.NewIntegerValue(1)).Append(document.NewIntegerValue(2)))))
 		require.NoError(t, err)
 	})
+
+	t.Run("Should continue after the greatest key of an auto-incremented primary key", func(t *testing.T) {
+		tx, cleanup := newTestDB(t)
+		defer cleanup()
+
+		err := tx.CreateTable("test", &database.TableInfo{
+			FieldConstraints: []database.FieldConstraint{
+				{Path: parsePath(t, "id"), Type: document.IntegerValue, IsPrimaryKey: true, IsAutoIncrement: true},
+			},
+		})
+		require.NoError(t, err)
+		tb, err := tx.GetTable("test")
+		require.NoError(t, err)
+
+		// write a document without going through Insert, like when copying a database.
+		var buf bytes.Buffer
+		enc := msgpack.NewCodec().NewEncoder(&buf)
+		err = enc.EncodeDocument(document.NewFieldBuffer().Add("id", document.NewIntegerValue(7)))
+		require.NoError(t, err)
+		enc.Close()
+		key, err := document.NewIntegerValue(7).MarshalBinary()
+		require.NoError(t, err)
+		err = tb.Store.Put(key, buf.Bytes())
+		require.NoError(t, err)
+
+		key, err = tb.Insert(document.NewFieldBuffer().Add("a", document.NewIntegerValue(1)))
+		require.NoError(t, err)
+
+		d, err := tb.GetDocument(key)
+		require.NoError(t, err)
+		v, err := d.GetByField("id")
+		require.NoError(t, err)
+		require.Equal(t, document.NewIntegerValue(8), v)
+	})
 }
 
 // TestTableDelete verifies Delete behaviour.
@@ -812,6 +848,247 @@ func TestTableIndexes(t *testing.T) {
 	})
 }
 
+func TestTableArrayElementsIndex(t *testing.T) {
+	tx, cleanup := newTestDB(t)
+	defer cleanup()
+
+	err := tx.CreateTable("test", nil)
+	require.NoError(t, err)
+	tb, err := tx.GetTable("test")
+	require.NoError(t, err)
+
+	err = tx.CreateIndex(database.IndexConfig{
+		IndexName:     "idx",
+		TableName:     "test",
+		Path:          parsePath(t, "a"),
+		ArrayElements: true,
+	})
+	require.NoError(t, err)
+
+	m, err := tb.Indexes()
+	require.NoError(t, err)
+	_, ok := m["a[*]"]
+	require.True(t, ok)
+
+	idx, err := tx.GetIndex("idx")
+	require.NoError(t, err)
+	require.True(t, idx.Opts.ArrayElements)
+
+	// lookup returns the keys of the documents indexed with v
+	errStop := errors.New("stop")
+	lookup := func(v document.Value) []string {
+		var keys []string
+		err := idx.AscendGreaterOrEqual(v, func(val, k []byte, isEqual bool) error {
+			if !isEqual {
+				return errStop
+			}
+			keys = append(keys, string(k))
+			return nil
+		})
+		if err != errStop {
+			require.NoError(t, err)
+		}
+		return keys
+	}
+
+	count := func() int {
+		var i int
+		err := idx.AscendGreaterOrEqual(document.Value{}, func(val, k []byte, isEqual bool) error {
+			i++
+			return nil
+		})
+		require.NoError(t, err)
+		return i
+	}
+
+	newArrayDoc := func(values ...string) document.Document {
+		vb := document.NewValueBuffer()
+		for _, v := range values {
+			vb = vb.Append(document.NewTextValue(v))
+		}
+		return document.NewFieldBuffer().Add("a", document.NewArrayValue(vb))
+	}
+
+	// duplicated elements must be indexed once
+	k1, err := tb.Insert(newArrayDoc("foo", "bar", "foo"))
+	require.NoError(t, err)
+	k2, err := tb.Insert(newArrayDoc("bar", "baz"))
+	require.NoError(t, err)
+	// documents without an array are not indexed
+	_, err = tb.Insert(document.NewFieldBuffer().Add("a", document.NewTextValue("foo")))
+	require.NoError(t, err)
+	k4, err := tb.Insert(document.NewFieldBuffer().Add("b", document.NewTextValue("foo")))
+	require.NoError(t, err)
+
+	require.Equal(t, 4, count())
+	require.Equal(t, []string{string(k1)}, lookup(document.NewTextValue("foo")))
+	require.ElementsMatch(t, []string{string(k1), string(k2)}, lookup(document.NewTextValue("bar")))
+
+	err = tb.Replace(k1, newArrayDoc("baz"))
+	require.NoError(t, err)
+	require.Equal(t, 3, count())
+	require.Empty(t, lookup(document.NewTextValue("foo")))
+	require.ElementsMatch(t, []string{string(k1), string(k2)}, lookup(document.NewTextValue("baz")))
+
+	err = tb.Delete(k2)
+	require.NoError(t, err)
+	require.Equal(t, 1, count())
+	require.Equal(t, []string{string(k1)}, lookup(document.NewTextValue("baz")))
+
+	err = tb.Delete(k4)
+	require.NoError(t, err)
+
+	err = tx.ReIndex("idx")
+	require.NoError(t, err)
+	require.Equal(t, 1, count())
+}
+
+func TestTablePartialIndex(t *testing.T) {
+	tx, cleanup := newTestDB(t)
+	defer cleanup()
+
+	err := tx.CreateTable("test", nil)
+	require.NoError(t, err)
+	tb, err := tx.GetTable("test")
+	require.NoError(t, err)
+
+	err = tx.CreateIndex(database.IndexConfig{
+		IndexName: "idx",
+		TableName: "test",
+		Path:      parsePath(t, "a"),
+		Predicate: "invalid +",
+	})
+	require.Error(t, err)
+
+	err = tx.CreateIndex(database.IndexConfig{
+		IndexName: "idx",
+		TableName: "test",
+		Path:      parsePath(t, "a"),
+		Predicate: "status = 'open'",
+	})
+	require.NoError(t, err)
+
+	m, err := tb.Indexes()
+	require.NoError(t, err)
+	_, ok := m["a WHERE status = 'open'"]
+	require.True(t, ok)
+
+	idx, err := tx.GetIndex("idx")
+	require.NoError(t, err)
+	require.NotNil(t, idx.Predicate())
+
+	count := func() int {
+		var i int
+		err := idx.AscendGreaterOrEqual(document.Value{}, func(val, k []byte, isEqual bool) error {
+			i++
+			return nil
+		})
+		require.NoError(t, err)
+		return i
+	}
+
+	newDoc := func(a int64, status string) document.Document {
+		return document.NewFieldBuffer().
+			Add("a", document.NewIntegerValue(a)).
+			Add("status", document.NewTextValue(status))
+	}
+
+	k1, err := tb.Insert(newDoc(1, "open"))
+	require.NoError(t, err)
+	k2, err := tb.Insert(newDoc(2, "closed"))
+	require.NoError(t, err)
+	require.Equal(t, 1, count())
+
+	// documents that stop matching the predicate are removed from the index
+	err = tb.Replace(k1, newDoc(1, "closed"))
+	require.NoError(t, err)
+	require.Equal(t, 0, count())
+
+	// and added when they start matching it
+	err = tb.Replace(k2, newDoc(2, "open"))
+	require.NoError(t, err)
+	require.Equal(t, 1, count())
+
+	err = tb.Delete(k1)
+	require.NoError(t, err)
+	err = tb.Delete(k2)
+	require.NoError(t, err)
+	require.Equal(t, 0, count())
+
+	_, err = tb.Insert(newDoc(3, "open"))
+	require.NoError(t, err)
+	_, err = tb.Insert(newDoc(4, "closed"))
+	require.NoError(t, err)
+	err = tx.ReIndex("idx")
+	require.NoError(t, err)
+	require.Equal(t, 1, count())
+}
+
+func TestTableCoveringIndex(t *testing.T) {
+	tx, cleanup := newTestDB(t)
+	defer cleanup()
+
+	err := tx.CreateTable("test", nil)
+	require.NoError(t, err)
+	tb, err := tx.GetTable("test")
+	require.NoError(t, err)
+
+	err = tx.CreateIndex(database.IndexConfig{
+		IndexName: "idx",
+		TableName: "test",
+		Path:      parsePath(t, "a"),
+		Include:   []document.Path{parsePath(t, "b.c"), parsePath(t, "d")},
+	})
+	require.NoError(t, err)
+
+	idx, err := tx.GetIndex("idx")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "d"}, idx.StoredFields())
+
+	// read every document of the index, either from the index or from the table
+	read := func(indexOnly bool) []string {
+		idx.IndexOnly = indexOnly
+
+		var docs []string
+		err := idx.AscendGreaterOrEqual(document.Value{}, func(val, k []byte, isEqual bool) error {
+			d, err := idx.GetDocument(tb, k)
+			if err != nil {
+				return err
+			}
+
+			data, err := document.MarshalJSON(d)
+			if err != nil {
+				return err
+			}
+			docs = append(docs, string(data))
+			return nil
+		})
+		require.NoError(t, err)
+		return docs
+	}
+
+	doc := document.NewFromJSON([]byte(`{"a": 1, "b": {"c": 2}, "d": 3, "e": 4}`))
+	k, err := tb.Insert(doc)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{`{"a": 1, "b": {"c": 2}, "d": 3, "e": 4}`}, read(false))
+	require.Equal(t, []string{`{"a": 1, "b": {"c": 2}, "d": 3}`}, read(true))
+
+	// stored fields are updated with the document
+	doc = document.NewFromJSON([]byte(`{"a": 1, "d": 5}`))
+	err = tb.Replace(k, doc)
+	require.NoError(t, err)
+	require.Equal(t, []string{`{"a": 1, "d": 5}`}, read(true))
+
+	err = tx.ReIndex("idx")
+	require.NoError(t, err)
+	require.Equal(t, []string{`{"a": 1, "d": 5}`}, read(true))
+
+	err = tb.Delete(k)
+	require.NoError(t, err)
+	require.Empty(t, read(true))
+}
+
 // BenchmarkTableInsert benchmarks the Insert method with 1, 10, 1000 and 10000 successive insertions.
 func BenchmarkTableInsert(b *testing.B) {
 	for size := 1; size <= 10000; size *= 10 {
@@ -866,3 +1143,67 @@ func BenchmarkTableScan(b *testing.B) {
 		})
 	}
 }
+
+func TestTableDeleteExpired(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	docs := []*document.FieldBuffer{
+		document.NewFieldBuffer().Add("id", document.NewIntegerValue(1)).Add("exp", document.NewTextValue("2020-10-01T11:59:59Z")),
+		document.NewFieldBuffer().Add("id", document.NewIntegerValue(2)).Add("exp", document.NewTextValue("2020-10-01T14:00:00+03:00")),
+		document.NewFieldBuffer().Add("id", document.NewIntegerValue(3)).Add("exp", document.NewTextValue("2020-10-01T12:00:01Z")),
+		document.NewFieldBuffer().Add("id", document.NewIntegerValue(4)).Add("exp", document.NewIntegerValue(now.Unix()-1)),
+		document.NewFieldBuffer().Add("id", document.NewIntegerValue(5)).Add("exp", document.NewDoubleValue(float64(now.Unix())+0.5)),
+		document.NewFieldBuffer().Add("id", document.NewIntegerValue(6)).Add("exp", document.NewNullValue()),
+		document.NewFieldBuffer().Add("id", document.NewIntegerValue(7)),
+	}
+
+	for _, withIndex := range []bool{true, false} {
+		t.Run(fmt.Sprintf("With index: %v", withIndex), func(t *testing.T) {
+			tx, cleanup := newTestDB(t)
+			defer cleanup()
+
+			err := tx.CreateTable("test", &database.TableInfo{
+				FieldConstraints: []database.FieldConstraint{
+					{Path: parsePath(t, "id"), Type: document.IntegerValue, IsPrimaryKey: true},
+				},
+				TTL: parsePath(t, "exp"),
+			})
+			require.NoError(t, err)
+
+			tb, err := tx.GetTable("test")
+			require.NoError(t, err)
+
+			indexes, err := tb.Indexes()
+			require.NoError(t, err)
+			require.Len(t, indexes, 1)
+			if !withIndex {
+				for _, idx := range indexes {
+					require.NoError(t, tx.DropIndex(idx.Opts.IndexName))
+				}
+			}
+
+			for _, d := range docs {
+				_, err = tb.Insert(d)
+				require.NoError(t, err)
+			}
+
+			// only timestamps and numbers are valid expiration times
+			_, err = tb.Insert(document.NewFieldBuffer().Add("id", document.NewIntegerValue(8)).Add("exp", document.NewTextValue("tomorrow")))
+			require.Error(t, err)
+
+			n, err := tb.DeleteExpired(now)
+			require.NoError(t, err)
+			require.Equal(t, 3, n)
+
+			var ids []int64
+			err = tb.Iterate(func(d document.Document) error {
+				v, err := d.GetByField("id")
+				require.NoError(t, err)
+				ids = append(ids, v.V.(int64))
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, []int64{3, 5, 6, 7}, ids)
+		})
+	}
+}
@@ -8,12 +8,16 @@ import (
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/engine"
 	"github.com/genjidb/genji/index"
+	"github.com/genjidb/genji/index/fulltext"
 )
 
 var (
 	internalPrefix     = "__genji_"
 	tableInfoStoreName = internalPrefix + "tables"
 	indexStoreName     = internalPrefix + "indexes"
+	viewStoreName      = internalPrefix + "views"
+	triggerStoreName   = internalPrefix + "triggers"
+	sequenceStoreName  = internalPrefix + "sequences"
 )
 
 // Transaction represents a database transaction. It provides methods for managing the
@@ -29,6 +33,12 @@ type Transaction struct {
 
 	tableInfoStore *tableInfoStore
 	indexStore     *indexStore
+	viewStore      *viewStore
+	triggerStore   *triggerStore
+	sequenceStore  *sequenceStore
+
+	// number of triggers being fired
+	triggerDepth int
 }
 
 // DB returns the underlying database that created the transaction.
@@ -91,8 +101,31 @@ func (tx *Transaction) CreateTable(name string, info *TableInfo) error {
 		info = new(TableInfo)
 	}
 
+	err := tx.checkNotView(name)
+	if err != nil {
+		return err
+	}
+
+	// make sure the stored expressions can be parsed
+	err = info.FieldConstraints.parseGenerated(tx.db)
+	if err != nil {
+		return err
+	}
+
+	for i := range info.FieldConstraints {
+		err = info.checkTTLConstraint(&info.FieldConstraints[i])
+		if err != nil {
+			return err
+		}
+
+		err = checkAutoIncrement(&info.FieldConstraints[i])
+		if err != nil {
+			return err
+		}
+	}
+
 	info.tableName = name
-	err := tx.tableInfoStore.Insert(tx, name, info)
+	err = tx.tableInfoStore.Insert(tx, name, info)
 	if err != nil {
 		return err
 	}
@@ -102,7 +135,28 @@ func (tx *Transaction) CreateTable(name string, info *TableInfo) error {
 		return fmt.Errorf("failed to create table %q: %w", name, err)
 	}
 
-	return nil
+	if pk := info.GetPrimaryKey(); pk != nil && pk.IsAutoIncrement {
+		err = tx.sequenceStore.Insert(SequenceInfo{
+			SequenceName: autoIncrementSequenceName(info),
+			Start:        1,
+			Increment:    1,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if info.TTL == nil {
+		return nil
+	}
+
+	// index the TTL field to quickly find expired documents.
+	// the index is never typed, to allow documents without expiration time.
+	return tx.indexStore.Insert(IndexConfig{
+		TableName: name,
+		IndexName: ttlIndexName(info),
+		Path:      info.TTL,
+	})
 }
 
 // GetTable returns a table by name. The table instance is only valid for the lifetime of the transaction.
@@ -144,11 +198,158 @@ func (tx *Transaction) AddField(tableName string, fc FieldConstraint) error {
 		}
 	}
 
+	if fc.IsAutoIncrement {
+		return errors.New("cannot add an AUTOINCREMENT constraint")
+	}
+
+	err = info.checkTTLConstraint(&fc)
+	if err != nil {
+		return err
+	}
+
 	info.FieldConstraints = append(info.FieldConstraints, fc)
 
+	// make sure the stored expressions can be parsed
+	err = info.FieldConstraints.parseGenerated(tx.db)
+	if err != nil {
+		return err
+	}
+
+	return tx.tableInfoStore.Replace(tx, tableName, info)
+}
+
+// ReplaceFieldConstraint replaces the constraint of the field found at fc.Path.
+// If there is no constraint on that field, fc is added to the table.
+// The primary key of a table cannot be changed.
+func (tx *Transaction) ReplaceFieldConstraint(tableName string, fc FieldConstraint) error {
+	info, err := tx.tableInfoStore.Get(tx, tableName)
+	if err != nil {
+		return err
+	}
+
+	if fc.IsPrimaryKey {
+		return errors.New("cannot add a PRIMARY KEY constraint")
+	}
+
+	if fc.IsAutoIncrement {
+		return errors.New("cannot add an AUTOINCREMENT constraint")
+	}
+
+	err = info.checkTTLConstraint(&fc)
+	if err != nil {
+		return err
+	}
+
+	var found bool
+	for i, field := range info.FieldConstraints {
+		if !field.Path.IsEqual(fc.Path) {
+			continue
+		}
+
+		if field.IsPrimaryKey {
+			return fmt.Errorf("cannot alter primary key %q", field.Path.String())
+		}
+
+		info.FieldConstraints[i] = fc
+		found = true
+		break
+	}
+
+	if !found {
+		info.FieldConstraints = append(info.FieldConstraints, fc)
+	}
+
 	return tx.tableInfoStore.Replace(tx, tableName, info)
 }
 
+// DropField removes the constraint of the field found at the given path,
+// as well as the indexes of the table that reference the field, either by indexing it,
+// including it or using it in their expression or predicate.
+// It doesn't modify the documents of the table.
+// The primary key of a table cannot be dropped.
+func (tx *Transaction) DropField(tableName string, path document.Path) error {
+	info, err := tx.tableInfoStore.Get(tx, tableName)
+	if err != nil {
+		return err
+	}
+
+	for i, field := range info.FieldConstraints {
+		if !field.Path.IsEqual(path) {
+			continue
+		}
+
+		if field.IsPrimaryKey {
+			return fmt.Errorf("cannot drop primary key %q", field.Path.String())
+		}
+
+		info.FieldConstraints = append(info.FieldConstraints[:i], info.FieldConstraints[i+1:]...)
+		err = tx.tableInfoStore.Replace(tx, tableName, info)
+		if err != nil {
+			return err
+		}
+
+		return tx.dropFieldIndexes(tableName, path)
+	}
+
+	return fmt.Errorf("field %q not found", path.String())
+}
+
+// dropFieldIndexes drops the indexes of the table that reference the field found at the given path.
+func (tx *Transaction) dropFieldIndexes(tableName string, path document.Path) error {
+	idxs, err := tx.ListIndexes()
+	if err != nil {
+		return err
+	}
+
+	for _, idx := range idxs {
+		if idx.TableName != tableName {
+			continue
+		}
+
+		used, err := tx.indexUsesPath(idx, path)
+		if err != nil {
+			return err
+		}
+		if !used {
+			continue
+		}
+
+		err = tx.DropIndex(idx.IndexName)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indexUsesPath returns true if the index reads the value found at p, or a value
+// containing it or contained in it.
+func (tx *Transaction) indexUsesPath(idx *IndexConfig, p document.Path) (bool, error) {
+	if idx.Expr == "" && (pathHasPrefix(idx.Path, p) || pathHasPrefix(p, idx.Path)) {
+		return true, nil
+	}
+
+	for _, ip := range idx.Include {
+		if pathHasPrefix(ip, p) || pathHasPrefix(p, ip) {
+			return true, nil
+		}
+	}
+
+	for _, e := range []string{idx.Predicate, idx.Expr} {
+		if e == "" {
+			continue
+		}
+
+		used, err := tx.exprUsesPath(e, p)
+		if err != nil || used {
+			return used, err
+		}
+	}
+
+	return false, nil
+}
+
 // RenameTable renames a table.
 // If it doesn't exist, it returns ErrTableNotFound.
 func (tx *Transaction) RenameTable(oldName, newName string) error {
@@ -161,6 +362,11 @@ func (tx *Transaction) RenameTable(oldName, newName string) error {
 		return errors.New("cannot write to read-only table")
 	}
 
+	err = tx.checkNotView(newName)
+	if err != nil {
+		return err
+	}
+
 	ti.tableName = newName
 	// Insert the TableInfo keyed by the newName name.
 	err = tx.tableInfoStore.Insert(tx, newName, ti)
@@ -183,11 +389,154 @@ func (tx *Transaction) RenameTable(oldName, newName string) error {
 		}
 	}
 
+	// Update the triggers.
+	triggers, err := tx.tableTriggers(oldName)
+	if err != nil {
+		return err
+	}
+	for _, tr := range triggers {
+		tr.TableName = newName
+		err = tx.triggerStore.Replace(*tr)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Delete the old reference from the tableInfoStore.
 	return tx.tableInfoStore.Delete(tx, oldName)
 }
 
-// DropTable deletes a table from the database.
+// RenameField renames the field found at oldPath. Both paths must only differ by their last field name.
+// Every document of the table, the field constraints and the indexes are updated accordingly.
+// Indexes on the field are not rebuilt since the indexed values are unchanged, except covering
+// indexes storing the field. Renaming a field used by a partial or an expression index is not supported.
+func (tx *Transaction) RenameField(tableName string, oldPath, newPath document.Path) error {
+	info, err := tx.tableInfoStore.Get(tx, tableName)
+	if err != nil {
+		return err
+	}
+
+	if info.readOnly {
+		return errors.New("cannot write to read-only table")
+	}
+
+	n := len(oldPath)
+	if n == 0 || len(newPath) != n || !oldPath[:n-1].IsEqual(newPath[:n-1]) ||
+		oldPath[n-1].FieldName == "" || newPath[n-1].FieldName == "" {
+		return fmt.Errorf("cannot rename %q to %q: only the last field name of a path can be renamed", oldPath, newPath)
+	}
+	if oldPath.IsEqual(newPath) {
+		return fmt.Errorf("field %q already exists", newPath)
+	}
+
+	for i, fc := range info.FieldConstraints {
+		if pathHasPrefix(fc.Path, newPath) {
+			return fmt.Errorf("field %q already exists", newPath)
+		}
+
+		// stored expressions reference fields by name and are not rewritten
+		if fc.IsGenerated() {
+			used, err := tx.exprUsesPath(fc.Generated, oldPath)
+			if err != nil {
+				return err
+			}
+			if used {
+				return fmt.Errorf("cannot rename field %q: it is used by the generated field %q", oldPath, fc.Path)
+			}
+		}
+
+		if pathHasPrefix(fc.Path, oldPath) {
+			info.FieldConstraints[i].Path = replacePathPrefix(fc.Path, oldPath, newPath)
+		}
+	}
+	if info.TTL != nil && pathHasPrefix(info.TTL, oldPath) {
+		info.TTL = replacePathPrefix(info.TTL, oldPath, newPath)
+	}
+
+	idxs, err := tx.ListIndexes()
+	if err != nil {
+		return err
+	}
+
+	var rebuild []string
+	for _, idx := range idxs {
+		if idx.TableName != tableName {
+			continue
+		}
+
+		for _, e := range []string{idx.Predicate, idx.Expr} {
+			if e == "" {
+				continue
+			}
+
+			used, err := tx.exprUsesPath(e, oldPath)
+			if err != nil {
+				return err
+			}
+			if used {
+				return fmt.Errorf("cannot rename field %q: it is used by the predicate or the expression of index %q", oldPath, idx.IndexName)
+			}
+		}
+
+		var changed bool
+		if idx.Expr == "" && pathHasPrefix(idx.Path, oldPath) {
+			idx.Path = replacePathPrefix(idx.Path, oldPath, newPath)
+			changed = true
+		}
+		for i, p := range idx.Include {
+			if pathHasPrefix(p, oldPath) {
+				idx.Include[i] = replacePathPrefix(p, oldPath, newPath)
+				changed = true
+			}
+		}
+
+		// covering indexes store the top-level fields of the documents,
+		// which contain the renamed field.
+		cfg := Index{Opts: *idx}
+		for _, f := range cfg.StoredFields() {
+			if f == oldPath[0].FieldName || f == newPath[0].FieldName {
+				rebuild = append(rebuild, idx.IndexName)
+				break
+			}
+		}
+
+		if changed {
+			err = tx.indexStore.Replace(idx.IndexName, *idx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// documents are read using the current table info,
+	// which must be replaced after the documents are renamed.
+	t, err := tx.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	err = t.renameField(oldPath, newPath[n-1].FieldName)
+	if err != nil {
+		return err
+	}
+
+	err = tx.tableInfoStore.Replace(tx, tableName, info)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range rebuild {
+		err = tx.ReIndex(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DropTable deletes a table from the database, along with its indexes, triggers
+// and the sequence of its AUTOINCREMENT field.
 func (tx *Transaction) DropTable(name string) error {
 	ti, err := tx.tableInfoStore.Get(tx, name)
 	if err != nil {
@@ -229,6 +578,24 @@ func (tx *Transaction) DropTable(name string) error {
 		return err
 	}
 
+	triggers, err := tx.tableTriggers(name)
+	if err != nil {
+		return err
+	}
+	for _, tr := range triggers {
+		err = tx.triggerStore.Delete(tr.TriggerName)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pk := ti.GetPrimaryKey(); pk != nil && pk.IsAutoIncrement {
+		err = tx.sequenceStore.Delete(autoIncrementSequenceName(ti))
+		if err != nil {
+			return err
+		}
+	}
+
 	err = tx.tableInfoStore.Delete(tx, name)
 	if err != nil {
 		return err
@@ -250,15 +617,42 @@ func (tx *Transaction) CreateIndex(opts IndexConfig) error {
 		return err
 	}
 
+	if opts.FullText && (opts.Unique || opts.ArrayElements || opts.Expr != "" || len(opts.Include) > 0) {
+		return errors.New("full-text indexes can only be created on a path")
+	}
+
 	// if the index is created on a field on which we know the type,
 	// create a typed index.
-	for _, fc := range info.FieldConstraints {
-		if fc.Path.IsEqual(opts.Path) {
-			if fc.Type != 0 {
-				opts.Type = fc.Type
+	// indexes on array elements or expressions are never typed since
+	// elements and results can be of any type, and full-text indexes
+	// only index text.
+	if !opts.ArrayElements && opts.Expr == "" && !opts.FullText {
+		for _, fc := range info.FieldConstraints {
+			if fc.Path.IsEqual(opts.Path) {
+				if fc.Type != 0 {
+					opts.Type = fc.Type
+				}
+
+				break
 			}
+		}
+	}
+
+	// make sure the stored expressions can be parsed
+	for _, e := range []string{opts.Predicate, opts.Expr} {
+		if e == "" {
+			continue
+		}
+
+		pe, err := tx.db.parseExpr(e)
+		if err != nil {
+			return err
+		}
 
-			break
+		// the documents are indexed once, when they are written,
+		// so the result must not change between two evaluations.
+		if !pe.IsDeterministic() {
+			return fmt.Errorf("cannot create index %q: expression %s is not deterministic", opts.IndexName, pe)
 		}
 	}
 
@@ -272,15 +666,7 @@ func (tx *Transaction) GetIndex(name string) (*Index, error) {
 		return nil, err
 	}
 
-	idx := index.New(tx.tx, opts.IndexName, index.Options{
-		Unique: opts.Unique,
-		Type:   opts.Type,
-	})
-
-	return &Index{
-		Index: idx,
-		Opts:  *opts,
-	}, nil
+	return newIndex(tx, *opts)
 }
 
 // DropIndex deletes an index from the database.
@@ -294,6 +680,10 @@ func (tx *Transaction) DropIndex(name string) error {
 		return err
 	}
 
+	if opts.FullText {
+		return fulltext.New(tx.tx, opts.IndexName, fulltext.Options{}).Truncate()
+	}
+
 	idx := index.New(tx.tx, opts.IndexName, index.Options{
 		Unique: opts.Unique,
 		Type:   opts.Type,
@@ -325,7 +715,7 @@ func (tx *Transaction) ReIndex(indexName string) error {
 	}
 
 	return tb.Iterate(func(d document.Document) error {
-		v, err := idx.Opts.Path.GetValueFromDocument(d)
+		values, err := idx.values(d)
 		if err == document.ErrFieldNotFound {
 			return nil
 		}
@@ -333,7 +723,19 @@ func (tx *Transaction) ReIndex(indexName string) error {
 			return err
 		}
 
-		return idx.Set(v, d.(document.Keyer).RawKey())
+		entry, err := idx.entry(d, d.(document.Keyer).RawKey())
+		if err != nil {
+			return err
+		}
+
+		for _, v := range values {
+			err = idx.Set(v, entry)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
 	})
 }
 
@@ -370,6 +772,68 @@ func (tx *Transaction) ReIndexAll() error {
 	return nil
 }
 
+// exprUsesPath returns whether the expression s, stored in the catalog, reads p,
+// one of its subfields or one of its parents.
+// If the paths read by the expression cannot be determined, it is assumed to read p.
+func (tx *Transaction) exprUsesPath(s string, p document.Path) (bool, error) {
+	e, err := tx.db.parseExpr(s)
+	if err != nil {
+		return false, err
+	}
+
+	paths, ok := e.Paths()
+	if !ok {
+		return true, nil
+	}
+
+	for _, ep := range paths {
+		if pathHasPrefix(ep, p) || pathHasPrefix(p, ep) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// initStores loads the internal stores used by the transaction.
+// If missingOK is true, the catalog stores that don't exist are left nil,
+// which only happens while migrating a database created before they were introduced.
+func (tx *Transaction) initStores(missingOK bool) error {
+	var err error
+
+	tx.tableInfoStore, err = tx.getTableInfoStore()
+	if err != nil {
+		return err
+	}
+
+	tx.indexStore, err = tx.getIndexStore()
+	if err != nil {
+		return err
+	}
+
+	tx.viewStore, err = tx.getViewStore()
+	if err == engine.ErrStoreNotFound && missingOK {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	tx.triggerStore, err = tx.getTriggerStore()
+	if err == engine.ErrStoreNotFound && missingOK {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	tx.sequenceStore, err = tx.getSequenceStore()
+	if err == engine.ErrStoreNotFound && missingOK {
+		err = nil
+	}
+	return err
+}
+
 func (tx *Transaction) getTableInfoStore() (*tableInfoStore, error) {
 	st, err := tx.tx.GetStore([]byte(tableInfoStoreName))
 	if err != nil {
@@ -10,12 +10,24 @@ import (
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/document/encoding/msgpack"
 	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/genjidb/genji/sql/parser"
+	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/stretchr/testify/require"
 )
 
+func parseExpr(s string) (database.Expr, error) {
+	e, err := parser.ParseExpr(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return expr.DocumentExpr{E: e}, nil
+}
+
 func newTestDB(t testing.TB) (*database.Transaction, func()) {
 	db, err := database.New(context.Background(), memoryengine.NewEngine(), database.Options{
-		Codec: msgpack.NewCodec(),
+		Codec:     msgpack.NewCodec(),
+		ParseExpr: parseExpr,
 	})
 	require.NoError(t, err)
 
@@ -257,6 +269,64 @@ func TestTxCreateIndex(t *testing.T) {
 			require.Equal(t, err, database.ErrTableNotFound)
 		}
 	})
+
+	t.Run("Should create a full-text index", func(t *testing.T) {
+		tx, cleanup := newTestDB(t)
+		defer cleanup()
+
+		err := tx.CreateTable("test", &database.TableInfo{
+			FieldConstraints: []database.FieldConstraint{
+				{Path: parsePath(t, "foo"), Type: document.TextValue},
+			},
+		})
+		require.NoError(t, err)
+
+		err = tx.CreateIndex(database.IndexConfig{
+			IndexName: "idxFoo", TableName: "test", Path: parsePath(t, "foo"), FullText: true, Stem: true,
+		})
+		require.NoError(t, err)
+		idx, err := tx.GetIndex("idxFoo")
+		require.NoError(t, err)
+		require.NotNil(t, idx.FullText())
+		require.True(t, idx.FullText().Stem)
+		require.Zero(t, idx.Opts.Type)
+
+		err = tx.CreateIndex(database.IndexConfig{
+			IndexName: "idxBar", TableName: "test", Path: parsePath(t, "foo"), FullText: true, Unique: true,
+		})
+		require.Error(t, err)
+
+		err = tx.DropIndex("idxFoo")
+		require.NoError(t, err)
+	})
+
+	t.Run("Should fail if the expression is not deterministic", func(t *testing.T) {
+		tx, cleanup := newTestDB(t)
+		defer cleanup()
+
+		err := tx.CreateTable("test", nil)
+		require.NoError(t, err)
+
+		tests := []database.IndexConfig{
+			{IndexName: "idxNext", TableName: "test", Expr: "foo + nextval('seq')"},
+			{IndexName: "idxCurr", TableName: "test", Expr: "lower(currval('seq'))"},
+			{IndexName: "idxRank", TableName: "test", Expr: "rank()"},
+			{IndexName: "idxWhere", TableName: "test", Path: parsePath(t, "foo"), Predicate: "foo > currval('seq')"},
+		}
+
+		for _, opts := range tests {
+			err = tx.CreateIndex(opts)
+			require.Error(t, err, opts.IndexName)
+
+			_, err = tx.GetIndex(opts.IndexName)
+			require.Equal(t, database.ErrIndexNotFound, err)
+		}
+
+		err = tx.CreateIndex(database.IndexConfig{
+			IndexName: "idxLower", TableName: "test", Expr: "(foo + 1) * 2 = pk()",
+		})
+		require.NoError(t, err)
+	})
 }
 
 func TestTxDropIndex(t *testing.T) {
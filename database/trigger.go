@@ -0,0 +1,336 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine"
+)
+
+// A TriggerEvent is the kind of write firing a trigger.
+type TriggerEvent string
+
+// List of events firing triggers.
+const (
+	TriggerInsert TriggerEvent = "INSERT"
+	TriggerUpdate TriggerEvent = "UPDATE"
+	TriggerDelete TriggerEvent = "DELETE"
+)
+
+// maxTriggerDepth is the maximum number of nested triggers,
+// which prevents triggers from firing each other indefinitely.
+const maxTriggerDepth = 32
+
+// TriggerInfo holds the definition of a trigger.
+type TriggerInfo struct {
+	TriggerName string
+	TableName   string
+
+	// Before is true if the trigger fires before the document is written,
+	// false if it fires after.
+	Before bool
+	Event  TriggerEvent
+
+	// When is the text of an optional condition, the trigger only fires
+	// if it evaluates to true.
+	When string
+
+	// Statement is the text of the statement executed every time the trigger fires.
+	Statement string
+}
+
+// ToDocument creates a document from a TriggerInfo.
+func (t *TriggerInfo) ToDocument() document.Document {
+	buf := document.NewFieldBuffer()
+
+	buf.Add("trigger_name", document.NewTextValue(t.TriggerName))
+	buf.Add("table_name", document.NewTextValue(t.TableName))
+	if t.Before {
+		buf.Add("timing", document.NewTextValue("BEFORE"))
+	} else {
+		buf.Add("timing", document.NewTextValue("AFTER"))
+	}
+	buf.Add("event", document.NewTextValue(string(t.Event)))
+	if t.When != "" {
+		buf.Add("when", document.NewTextValue(t.When))
+	}
+	buf.Add("sql", document.NewTextValue(t.Statement))
+	return buf
+}
+
+// ScanDocument implements the document.Scanner interface.
+func (t *TriggerInfo) ScanDocument(d document.Document) error {
+	f, err := d.GetByField("trigger_name")
+	if err != nil {
+		return err
+	}
+	t.TriggerName = f.V.(string)
+
+	f, err = d.GetByField("table_name")
+	if err != nil {
+		return err
+	}
+	t.TableName = f.V.(string)
+
+	f, err = d.GetByField("timing")
+	if err != nil {
+		return err
+	}
+	t.Before = f.V.(string) == "BEFORE"
+
+	f, err = d.GetByField("event")
+	if err != nil {
+		return err
+	}
+	t.Event = TriggerEvent(f.V.(string))
+
+	f, err = d.GetByField("when")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		t.When = f.V.(string)
+	}
+
+	f, err = d.GetByField("sql")
+	if err != nil {
+		return err
+	}
+	t.Statement = f.V.(string)
+
+	return nil
+}
+
+// triggerStore manages the definitions of the triggers.
+type triggerStore struct {
+	db *Database
+	st engine.Store
+}
+
+func (t *triggerStore) Insert(info TriggerInfo) error {
+	key := []byte(info.TriggerName)
+	_, err := t.st.Get(key)
+	if err == nil {
+		return ErrTriggerAlreadyExists
+	}
+	if err != engine.ErrKeyNotFound {
+		return err
+	}
+
+	return t.put(info)
+}
+
+func (t *triggerStore) Replace(info TriggerInfo) error {
+	return t.put(info)
+}
+
+func (t *triggerStore) put(info TriggerInfo) error {
+	var buf bytes.Buffer
+	enc := t.db.Codec.NewEncoder(&buf)
+	defer enc.Close()
+	err := enc.EncodeDocument(info.ToDocument())
+	if err != nil {
+		return err
+	}
+
+	return t.st.Put([]byte(info.TriggerName), buf.Bytes())
+}
+
+func (t *triggerStore) Get(triggerName string) (*TriggerInfo, error) {
+	v, err := t.st.Get([]byte(triggerName))
+	if err == engine.ErrKeyNotFound {
+		return nil, fmt.Errorf("%w: %q", ErrTriggerNotFound, triggerName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var info TriggerInfo
+	err = info.ScanDocument(t.db.Codec.NewDocument(v))
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+func (t *triggerStore) Delete(triggerName string) error {
+	err := t.st.Delete([]byte(triggerName))
+	if err == engine.ErrKeyNotFound {
+		return fmt.Errorf("%w: %q", ErrTriggerNotFound, triggerName)
+	}
+	return err
+}
+
+func (t *triggerStore) ListAll() ([]*TriggerInfo, error) {
+	it := t.st.Iterator(engine.IteratorOptions{})
+	defer it.Close()
+
+	var list []*TriggerInfo
+	var buf []byte
+	var err error
+	for it.Seek(nil); it.Valid(); it.Next() {
+		buf, err = it.Item().ValueCopy(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		var info TriggerInfo
+		err = info.ScanDocument(t.db.Codec.NewDocument(buf))
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, &info)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (tx *Transaction) getTriggerStore() (*triggerStore, error) {
+	st, err := tx.tx.GetStore([]byte(triggerStoreName))
+	if err != nil {
+		return nil, err
+	}
+	return &triggerStore{
+		st: st,
+		db: tx.db,
+	}, nil
+}
+
+// CreateTrigger creates a trigger on an existing table.
+// The condition and the statement are parsed using the ParseTrigger function of the database.
+// If a trigger with the same name already exists, returns ErrTriggerAlreadyExists.
+func (tx *Transaction) CreateTrigger(info *TriggerInfo) error {
+	if strings.HasPrefix(info.TriggerName, internalPrefix) {
+		return fmt.Errorf("trigger name must not start with %s", internalPrefix)
+	}
+
+	switch info.Event {
+	case TriggerInsert, TriggerUpdate, TriggerDelete:
+	default:
+		return fmt.Errorf("unknown trigger event %q", info.Event)
+	}
+
+	ti, err := tx.tableInfoStore.Get(tx, info.TableName)
+	if err != nil {
+		return err
+	}
+	if ti.readOnly {
+		return errors.New("cannot create a trigger on a read-only table")
+	}
+
+	_, err = tx.db.parseTrigger(info.When, info.Statement)
+	if err != nil {
+		return err
+	}
+
+	return tx.triggerStore.Insert(*info)
+}
+
+// GetTrigger returns the definition of a trigger.
+// If it doesn't exist, it returns ErrTriggerNotFound.
+func (tx *Transaction) GetTrigger(name string) (*TriggerInfo, error) {
+	return tx.triggerStore.Get(name)
+}
+
+// DropTrigger deletes a trigger.
+// If it doesn't exist, it returns ErrTriggerNotFound.
+func (tx *Transaction) DropTrigger(name string) error {
+	return tx.triggerStore.Delete(name)
+}
+
+// ListTriggers lists all the triggers of the database, sorted by name.
+func (tx *Transaction) ListTriggers() ([]*TriggerInfo, error) {
+	return tx.triggerStore.ListAll()
+}
+
+// tableTriggers returns the triggers of the given table, sorted by name.
+func (tx *Transaction) tableTriggers(tableName string) ([]*TriggerInfo, error) {
+	list, err := tx.triggerStore.ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var triggers []*TriggerInfo
+	for _, info := range list {
+		if info.TableName == tableName {
+			triggers = append(triggers, info)
+		}
+	}
+
+	return triggers, nil
+}
+
+// triggers returns the triggers of the table fired by the given event.
+func (t *Table) triggers(event TriggerEvent) ([]*TriggerInfo, error) {
+	list, err := t.tx.tableTriggers(t.name)
+	if err != nil {
+		return nil, err
+	}
+
+	var triggers []*TriggerInfo
+	for _, info := range list {
+		if info.Event == event {
+			triggers = append(triggers, info)
+		}
+	}
+
+	return triggers, nil
+}
+
+// fireTriggers fires, in order, the triggers of the list that fire before the write if before is true,
+// or after otherwise. newDoc is the document being written and oldDoc the document it replaces or deletes,
+// any of them can be nil.
+// The fields of the document returned by a trigger fired before an insert or an update are set on the new
+// document, which is returned.
+func (t *Table) fireTriggers(triggers []*TriggerInfo, before bool, newDoc, oldDoc document.Document) (document.Document, error) {
+	for _, info := range triggers {
+		if info.Before != before {
+			continue
+		}
+
+		if t.tx.triggerDepth >= maxTriggerDepth {
+			return nil, fmt.Errorf("trigger %q: too many nested triggers", info.TriggerName)
+		}
+
+		tr, err := t.tx.db.parseTrigger(info.When, info.Statement)
+		if err != nil {
+			return nil, fmt.Errorf("trigger %q: %w", info.TriggerName, err)
+		}
+
+		t.tx.triggerDepth++
+		d, err := tr.Fire(t.tx, newDoc, oldDoc)
+		t.tx.triggerDepth--
+		if err != nil {
+			return nil, fmt.Errorf("trigger %q: %w", info.TriggerName, err)
+		}
+
+		if !before || d == nil || newDoc == nil {
+			continue
+		}
+
+		fb := document.NewFieldBuffer()
+		err = fb.Copy(newDoc)
+		if err != nil {
+			return nil, err
+		}
+
+		err = d.Iterate(func(field string, v document.Value) error {
+			return fb.Set(document.Path{document.PathFragment{FieldName: field}}, v)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		newDoc = fb
+	}
+
+	return newDoc, nil
+}
@@ -0,0 +1,336 @@
+package database
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine"
+)
+
+// DefaultTTLSweepInterval is the interval at which expired documents are purged
+// if no other interval is provided.
+const DefaultTTLSweepInterval = time.Minute
+
+var errStopSweep = errors.New("stop")
+
+// expirationTime returns the time at which a document expires, given the value
+// of its TTL field. Texts must be RFC 3339 timestamps and numbers are Unix times, in seconds.
+// If v is null, the document never expires and ok is false.
+func expirationTime(v document.Value) (t time.Time, ok bool, err error) {
+	switch v.Type {
+	case document.NullValue:
+		return t, false, nil
+	case document.TextValue:
+		t, err = time.Parse(time.RFC3339Nano, v.V.(string))
+		if err != nil {
+			return t, false, fmt.Errorf("invalid expiration time %q: %w", v.V.(string), err)
+		}
+		return t, true, nil
+	case document.IntegerValue:
+		return time.Unix(v.V.(int64), 0), true, nil
+	case document.DoubleValue:
+		sec, frac := math.Modf(v.V.(float64))
+		return time.Unix(int64(sec), int64(frac*1e9)), true, nil
+	}
+
+	return t, false, fmt.Errorf("expiration time must be a timestamp or a number of seconds, got %s", v.Type)
+}
+
+// checkTTL ensures the TTL field of d, if any, contains a valid expiration time.
+func (ti *TableInfo) checkTTL(d document.Document) error {
+	v, err := ti.TTL.GetValueFromDocument(d)
+	if err == document.ErrFieldNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, _, err = expirationTime(v)
+	return err
+}
+
+// checkTTLConstraint ensures fc doesn't prevent the TTL field from containing expiration times.
+func (ti *TableInfo) checkTTLConstraint(fc *FieldConstraint) error {
+	if ti.TTL == nil || !fc.Path.IsEqual(ti.TTL) {
+		return nil
+	}
+
+	switch fc.Type {
+	case 0, document.IntegerValue, document.DoubleValue, document.TextValue:
+		return nil
+	}
+
+	return fmt.Errorf("TTL field %q must be of type integer, double or text", fc.Path)
+}
+
+// IsExpired returns true if the table has a TTL field and the expiration time
+// stored in d is before now. Documents without an expiration time never expire.
+func (ti *TableInfo) IsExpired(d document.Document, now time.Time) (bool, error) {
+	if ti.TTL == nil {
+		return false, nil
+	}
+
+	v, err := ti.TTL.GetValueFromDocument(d)
+	if err == document.ErrFieldNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	t, ok, err := expirationTime(v)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return !t.After(now), nil
+}
+
+// ttlIndexName returns the name of the index created on the TTL field of a table.
+// It is derived from the name of the store of the table, which doesn't change
+// when the table is renamed.
+func ttlIndexName(info *TableInfo) string {
+	return internalPrefix + "ttl_" + hex.EncodeToString(info.storeName)
+}
+
+// ttlIndex returns an index that can be used to read the values of the TTL field
+// in order, or nil if there is none.
+func (t *Table) ttlIndex(info *TableInfo) (*Index, error) {
+	indexes, err := t.Indexes()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, idx := range indexes {
+		opts := idx.Opts
+		if !opts.Path.IsEqual(info.TTL) || opts.ArrayElements || opts.FullText ||
+			opts.Expr != "" || opts.Predicate != "" || len(opts.Include) > 0 {
+			continue
+		}
+
+		idx := idx
+		return &idx, nil
+	}
+
+	return nil, nil
+}
+
+// deleteIfExpired deletes the document stored at key if it has expired.
+// Otherwise, it returns ErrDuplicateDocument.
+func (t *Table) deleteIfExpired(info *TableInfo, key []byte) error {
+	d, err := t.GetDocument(key)
+	if err != nil {
+		return err
+	}
+
+	ok, err := info.IsExpired(d, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrDuplicateDocument
+	}
+
+	return t.delete(key, d)
+}
+
+// DeleteExpired deletes every document of the table whose expiration time is before now.
+// If there is an index on the TTL field, only the documents expiring before now are read,
+// otherwise the whole table is scanned. Triggers are not fired.
+// It returns the number of deleted documents.
+func (t *Table) DeleteExpired(now time.Time) (int, error) {
+	info, err := t.Info()
+	if err != nil {
+		return 0, err
+	}
+
+	if info.TTL == nil {
+		return 0, nil
+	}
+
+	idx, err := t.ttlIndex(info)
+	if err != nil {
+		return 0, err
+	}
+
+	// some engines don't support writing while iterating,
+	// all the keys are read before deleting the documents.
+	var keys [][]byte
+	collect := func(d document.Document) error {
+		ok, err := info.IsExpired(d, now)
+		if err != nil || !ok {
+			return err
+		}
+
+		keys = append(keys, append([]byte{}, d.(document.Keyer).RawKey()...))
+		return nil
+	}
+
+	if idx == nil {
+		err = t.Iterate(collect)
+	} else {
+		err = t.expiredFromIndex(idx, info, now, collect)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		d, err := t.GetDocument(key)
+		if err != nil {
+			return 0, err
+		}
+
+		err = t.delete(key, d)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(keys), nil
+}
+
+// expiredFromIndex calls fn for the documents of the table whose expiration time may be before now,
+// using an index on the TTL field.
+// Numbers are ordered, the iteration stops at the first one after now.
+// Timestamps are compared to now using their date, which is enough to skip any timestamp
+// that cannot be before now, whatever its time zone.
+func (t *Table) expiredFromIndex(idx *Index, info *TableInfo, now time.Time, fn func(d document.Document) error) error {
+	maxDate := now.UTC().AddDate(0, 0, 2).Format("2006-01-02")
+
+	// untyped indexes are read one type at a time,
+	// typed indexes only contain values of their type.
+	pivots := []document.Value{{Type: document.IntegerValue}, {Type: document.DoubleValue}, {Type: document.TextValue}}
+	if idx.Opts.Type != 0 {
+		pivots = []document.Value{{}}
+	}
+
+	for _, pivot := range pivots {
+		err := idx.AscendGreaterOrEqual(pivot, func(val, key []byte, isEqual bool) error {
+			d, err := t.GetDocument(key)
+			if err != nil {
+				return err
+			}
+
+			v, err := info.TTL.GetValueFromDocument(d)
+			if err != nil {
+				return err
+			}
+
+			if v.Type == document.TextValue {
+				if s := v.V.(string); len(s) >= len(maxDate) && s[:len(maxDate)] >= maxDate {
+					return errStopSweep
+				}
+			} else if exp, ok, err := expirationTime(v); err != nil || (ok && exp.After(now)) {
+				if err != nil {
+					return err
+				}
+				return errStopSweep
+			}
+
+			return fn(d)
+		})
+		if err != nil && err != errStopSweep {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PurgeExpired deletes the expired documents of every table with a TTL field.
+// It returns the number of deleted documents.
+func (db *Database) PurgeExpired(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	// look for tables with a TTL field first, to avoid opening
+	// a write transaction if there is nothing to purge.
+	tables, err := db.ttlTables(ctx)
+	if err != nil || len(tables) == 0 {
+		return 0, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var total int
+	for _, name := range tables {
+		t, err := tx.GetTable(name)
+		if errors.Is(err, ErrTableNotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		n, err := t.DeleteExpired(now)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+
+	if total == 0 {
+		return 0, nil
+	}
+
+	return total, tx.Commit()
+}
+
+// ttlTables returns the names of the tables with a TTL field.
+func (db *Database) ttlTables(ctx context.Context) ([]string, error) {
+	tx, err := db.BeginTx(ctx, &TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var names []string
+	it := tx.tableInfoStore.st.Iterator(engine.IteratorOptions{})
+	defer it.Close()
+
+	var buf []byte
+	for it.Seek(nil); it.Valid(); it.Next() {
+		buf, err = it.Item().ValueCopy(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		var ti TableInfo
+		err = ti.ScanDocument(db.Codec.NewDocument(buf))
+		if err != nil {
+			return nil, err
+		}
+
+		if ti.TTL != nil {
+			names = append(names, ti.tableName)
+		}
+	}
+
+	return names, it.Err()
+}
+
+// sweep purges the expired documents at the given interval until stop is closed.
+// Errors are ignored, a failed purge is retried at the next tick.
+func (db *Database) sweep(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			_, _ = db.PurgeExpired(context.Background())
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine"
+)
+
+// ViewInfo holds the definition of a view.
+type ViewInfo struct {
+	ViewName string
+
+	// Query is the text of the SELECT statement returning the documents of the view.
+	Query string
+}
+
+// ToDocument creates a document from a ViewInfo.
+func (v *ViewInfo) ToDocument() document.Document {
+	buf := document.NewFieldBuffer()
+
+	buf.Add("view_name", document.NewTextValue(v.ViewName))
+	buf.Add("sql", document.NewTextValue(v.Query))
+	return buf
+}
+
+// ScanDocument implements the document.Scanner interface.
+func (v *ViewInfo) ScanDocument(d document.Document) error {
+	f, err := d.GetByField("view_name")
+	if err != nil {
+		return err
+	}
+	v.ViewName = f.V.(string)
+
+	f, err = d.GetByField("sql")
+	if err != nil {
+		return err
+	}
+	v.Query = f.V.(string)
+
+	return nil
+}
+
+// viewStore manages the definitions of the views.
+type viewStore struct {
+	db *Database
+	st engine.Store
+}
+
+func (t *viewStore) Insert(info ViewInfo) error {
+	key := []byte(info.ViewName)
+	_, err := t.st.Get(key)
+	if err == nil {
+		return ErrViewAlreadyExists
+	}
+	if err != engine.ErrKeyNotFound {
+		return err
+	}
+
+	var buf bytes.Buffer
+	enc := t.db.Codec.NewEncoder(&buf)
+	defer enc.Close()
+	err = enc.EncodeDocument(info.ToDocument())
+	if err != nil {
+		return err
+	}
+
+	return t.st.Put(key, buf.Bytes())
+}
+
+func (t *viewStore) Get(viewName string) (*ViewInfo, error) {
+	v, err := t.st.Get([]byte(viewName))
+	if err == engine.ErrKeyNotFound {
+		return nil, fmt.Errorf("%w: %q", ErrViewNotFound, viewName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var info ViewInfo
+	err = info.ScanDocument(t.db.Codec.NewDocument(v))
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+func (t *viewStore) Delete(viewName string) error {
+	err := t.st.Delete([]byte(viewName))
+	if err == engine.ErrKeyNotFound {
+		return fmt.Errorf("%w: %q", ErrViewNotFound, viewName)
+	}
+	return err
+}
+
+func (t *viewStore) ListAll() ([]*ViewInfo, error) {
+	it := t.st.Iterator(engine.IteratorOptions{})
+	defer it.Close()
+
+	var list []*ViewInfo
+	var buf []byte
+	var err error
+	for it.Seek(nil); it.Valid(); it.Next() {
+		buf, err = it.Item().ValueCopy(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		var info ViewInfo
+		err = info.ScanDocument(t.db.Codec.NewDocument(buf))
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, &info)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (tx *Transaction) getViewStore() (*viewStore, error) {
+	st, err := tx.tx.GetStore([]byte(viewStoreName))
+	if err != nil {
+		return nil, err
+	}
+	return &viewStore{
+		st: st,
+		db: tx.db,
+	}, nil
+}
+
+// CreateView creates a view with the given name, returning the documents selected by query.
+// The query is parsed using the ParseQuery function of the database and must be read-only.
+// If a view or a table with the same name already exists, returns ErrViewAlreadyExists.
+func (tx *Transaction) CreateView(name, query string) error {
+	if strings.HasPrefix(name, internalPrefix) {
+		return fmt.Errorf("view name must not start with %s", internalPrefix)
+	}
+
+	_, err := tx.tableInfoStore.Get(tx, name)
+	if err == nil {
+		return fmt.Errorf("%w: a table named %q exists", ErrViewAlreadyExists, name)
+	}
+	if !errors.Is(err, ErrTableNotFound) {
+		return err
+	}
+
+	q, err := tx.db.parseQuery(query)
+	if err != nil {
+		return err
+	}
+	if !q.IsReadOnly() {
+		return errors.New("the query of a view must be read-only")
+	}
+
+	return tx.viewStore.Insert(ViewInfo{ViewName: name, Query: query})
+}
+
+// GetView returns the definition of a view.
+// If it doesn't exist, it returns ErrViewNotFound.
+func (tx *Transaction) GetView(name string) (*ViewInfo, error) {
+	return tx.viewStore.Get(name)
+}
+
+// ParseView returns the parsed query of a view.
+// If it doesn't exist, it returns ErrViewNotFound.
+func (tx *Transaction) ParseView(name string) (Query, error) {
+	info, err := tx.viewStore.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.db.parseQuery(info.Query)
+}
+
+// DropView deletes a view.
+// If it doesn't exist, it returns ErrViewNotFound.
+func (tx *Transaction) DropView(name string) error {
+	return tx.viewStore.Delete(name)
+}
+
+// ListViews lists all the views of the database, sorted by name.
+func (tx *Transaction) ListViews() ([]*ViewInfo, error) {
+	return tx.viewStore.ListAll()
+}
+
+// checkNotView returns ErrTableAlreadyExists if a view with the given name exists.
+func (tx *Transaction) checkNotView(name string) error {
+	_, err := tx.viewStore.Get(name)
+	if err == nil {
+		return fmt.Errorf("%w: a view named %q exists", ErrTableAlreadyExists, name)
+	}
+	if errors.Is(err, ErrViewNotFound) {
+		return nil
+	}
+	return err
+}
@@ -6,7 +6,9 @@ import (
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/parser"
+	"github.com/genjidb/genji/sql/planner"
 	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/query/expr"
 )
 
 // DB represents a collection of tables stored in the underlying engine.
@@ -158,6 +160,20 @@ func (tx *Tx) QueryDocument(q string, args ...interface{}) (document.Document, e
 	return r, nil
 }
 
+// Iterate calls fn for every document of the table.
+func (tx *Tx) Iterate(tableName string, fn func(d document.Document) error) error {
+	return tx.iterate(planner.NewTree(planner.NewTableInputNode(tableName)), nil, fn)
+}
+
+func (tx *Tx) iterate(t *planner.Tree, params []expr.Param, fn func(d document.Document) error) error {
+	res, err := t.Run(tx.Transaction, params)
+	if err != nil {
+		return err
+	}
+
+	return res.Iterate(fn)
+}
+
 // Exec a query against the database within tx and without returning the result.
 func (tx *Tx) Exec(q string, args ...interface{}) error {
 	res, err := tx.Query(q, args...)
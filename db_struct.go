@@ -0,0 +1,216 @@
+// +build !wasm
+
+package genji
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/parser"
+	"github.com/genjidb/genji/sql/planner"
+	"github.com/genjidb/genji/sql/query"
+)
+
+// CreateTableFor creates a table whose field constraints and indexes are derived from
+// the fields of the struct v. See database.TableInfoFromStruct for the list of supported tags.
+func (db *DB) CreateTableFor(name string, v interface{}) error {
+	return db.Update(func(tx *Tx) error {
+		return tx.CreateTableFor(name, v)
+	})
+}
+
+// AutoMigrate creates the table for the struct v if it doesn't exist. Otherwise, it adds
+// the field constraints and the indexes derived from v that are missing from the table.
+// Existing fields are never modified or removed.
+func (db *DB) AutoMigrate(name string, v interface{}) error {
+	return db.Update(func(tx *Tx) error {
+		return tx.AutoMigrate(name, v)
+	})
+}
+
+// CreateTableFor creates a table whose field constraints and indexes are derived from
+// the fields of the struct v. See database.TableInfoFromStruct for the list of supported tags.
+func (tx *Tx) CreateTableFor(name string, v interface{}) error {
+	info, indexes, err := database.TableInfoFromStruct(name, v)
+	if err != nil {
+		return err
+	}
+
+	_, err = query.CreateTableStmt{TableName: name, Info: *info}.Run(tx.Transaction, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, idx := range indexes {
+		err = tx.CreateIndex(idx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AutoMigrate creates the table for the struct v if it doesn't exist. Otherwise, it adds
+// the field constraints and the indexes derived from v that are missing from the table.
+// Existing fields are never modified or removed.
+func (tx *Tx) AutoMigrate(name string, v interface{}) error {
+	t, err := tx.GetTable(name)
+	if errors.Is(err, database.ErrTableNotFound) {
+		return tx.CreateTableFor(name, v)
+	}
+	if err != nil {
+		return err
+	}
+
+	current, err := t.Info()
+	if err != nil {
+		return err
+	}
+
+	info, indexes, err := database.TableInfoFromStruct(name, v)
+	if err != nil {
+		return err
+	}
+
+	for _, fc := range info.FieldConstraints {
+		var found bool
+		for _, cur := range current.FieldConstraints {
+			if cur.Path.IsEqual(fc.Path) {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		err = tx.AddField(name, fc)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, idx := range indexes {
+		_, err = tx.GetIndex(idx.IndexName)
+		if err == nil {
+			continue
+		}
+		if err != database.ErrIndexNotFound {
+			return err
+		}
+
+		err = tx.CreateIndex(idx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// InsertStruct converts the struct v to a document and inserts it into the given table.
+// See document.NewFromStruct for the conversion rules.
+func (tx *Tx) InsertStruct(tableName string, v interface{}) error {
+	t, err := tx.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	d, err := document.NewFromStruct(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.Insert(d)
+	return err
+}
+
+// GetByPK fetches the document whose primary key is pk and scans it into the struct pointed by v.
+// If the table has no primary key, pk is the docid of the document.
+// If the document doesn't exist, GetByPK returns database.ErrDocumentNotFound.
+func (tx *Tx) GetByPK(tableName string, pk interface{}, v interface{}) error {
+	t, err := tx.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	pv, err := document.NewValue(pk)
+	if err != nil {
+		return err
+	}
+
+	key, err := t.EncodeKey(pv)
+	if err != nil {
+		return err
+	}
+
+	d, err := t.GetDocument(key)
+	if err != nil {
+		return err
+	}
+
+	info, err := t.Info()
+	if err != nil {
+		return err
+	}
+
+	expired, err := info.IsExpired(d, time.Now())
+	if err != nil {
+		return err
+	}
+	if expired {
+		return database.ErrDocumentNotFound
+	}
+
+	return document.StructScan(d, v)
+}
+
+// Find selects the documents of the table that satisfy the where condition and scans them
+// into the slice pointed by dst. The elements of the slice must be structs or pointers to structs.
+// The where condition is an SQL expression which may contain parameters, replaced by args.
+// If where is empty, all the documents of the table are selected.
+func (tx *Tx) Find(tableName string, dst interface{}, where string, args ...interface{}) error {
+	ref := reflect.ValueOf(dst)
+	if ref.Kind() != reflect.Ptr || ref.Elem().Kind() != reflect.Slice {
+		return errors.New("dst must be a pointer to a slice")
+	}
+
+	sl := ref.Elem()
+	tp := sl.Type().Elem()
+	isPtr := tp.Kind() == reflect.Ptr
+	if isPtr {
+		tp = tp.Elem()
+	}
+	if tp.Kind() != reflect.Struct {
+		return errors.New("dst must be a pointer to a slice of structs")
+	}
+
+	var n planner.Node = planner.NewTableInputNode(tableName)
+	if where != "" {
+		cond, err := parser.ParseExpr(where)
+		if err != nil {
+			return err
+		}
+
+		n = planner.NewSelectionNode(n, cond)
+	}
+
+	sl.SetLen(0)
+	return tx.iterate(planner.NewTree(n), argsToParams(args), func(d document.Document) error {
+		elem := reflect.New(tp)
+		err := document.StructScan(d, elem.Interface())
+		if err != nil {
+			return err
+		}
+
+		if !isPtr {
+			elem = elem.Elem()
+		}
+		sl.Set(reflect.Append(sl, elem))
+		return nil
+	})
+}
@@ -1,13 +1,16 @@
 package genji_test
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"testing"
+	"time"
 
 	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine/memoryengine"
 	"github.com/stretchr/testify/require"
 )
 
@@ -117,3 +120,240 @@ func TestQueryDocument(t *testing.T) {
 		require.Nil(t, r)
 	})
 }
+
+func TestCreateTableFor(t *testing.T) {
+	type User struct {
+		ID    int64  `genji:"id,pk"`
+		Email string `genji:"email,unique,notnull"`
+		Age   int    `genji:"age,default=18"`
+	}
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.CreateTableFor("users", User{})
+	require.NoError(t, err)
+
+	// the table already exists
+	err = db.CreateTableFor("users", User{})
+	require.Equal(t, database.ErrTableAlreadyExists, err)
+
+	err = db.Exec("INSERT INTO users (id, email) VALUES (1, 'a@example.com')")
+	require.NoError(t, err)
+
+	var u User
+	d, err := db.QueryDocument("SELECT * FROM users")
+	require.NoError(t, err)
+	require.NoError(t, document.StructScan(d, &u))
+	require.Equal(t, User{ID: 1, Email: "a@example.com", Age: 18}, u)
+
+	// email is unique and required
+	err = db.Exec("INSERT INTO users (id, email) VALUES (2, 'a@example.com')")
+	require.Error(t, err)
+	err = db.Exec("INSERT INTO users (id) VALUES (3)")
+	require.Error(t, err)
+
+	t.Run("AutoMigrate", func(t *testing.T) {
+		type UserV2 struct {
+			ID    int64  `genji:"id,pk"`
+			Email string `genji:"email,unique,notnull"`
+			Age   int    `genji:"age,default=18"`
+			Name  string `genji:"name,index,default=unknown"`
+		}
+
+		err = db.AutoMigrate("users", UserV2{})
+		require.NoError(t, err)
+
+		// migrating again is a no-op
+		err = db.AutoMigrate("users", UserV2{})
+		require.NoError(t, err)
+
+		err = db.Exec("INSERT INTO users (id, email, age) VALUES (4, 'b@example.com', '20')")
+		require.NoError(t, err)
+
+		var u UserV2
+		d, err := db.QueryDocument("SELECT * FROM users WHERE id = 4")
+		require.NoError(t, err)
+		require.NoError(t, document.StructScan(d, &u))
+		require.Equal(t, UserV2{ID: 4, Email: "b@example.com", Age: 20, Name: "unknown"}, u)
+
+		err = db.View(func(tx *genji.Tx) error {
+			_, err := tx.GetIndex("idx_users_name")
+			return err
+		})
+		require.NoError(t, err)
+
+		// tables that don't exist are created
+		err = db.AutoMigrate("users_v2", UserV2{})
+		require.NoError(t, err)
+		err = db.Exec("INSERT INTO users_v2 (id, email) VALUES (1, 'a@example.com')")
+		require.NoError(t, err)
+	})
+}
+
+func TestStructHelpers(t *testing.T) {
+	type User struct {
+		ID   int64  `genji:"id,pk"`
+		Name string `genji:"name"`
+		Age  int    `genji:"age"`
+	}
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.CreateTableFor("users", User{})
+	require.NoError(t, err)
+	err = db.Exec("CREATE TABLE docs")
+	require.NoError(t, err)
+
+	err = db.Update(func(tx *genji.Tx) error {
+		for i := 1; i <= 5; i++ {
+			err := tx.InsertStruct("users", &User{ID: int64(i), Name: fmt.Sprintf("user%d", i), Age: 20 + i})
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.InsertStruct("docs", User{ID: 10, Name: "foo"})
+	})
+	require.NoError(t, err)
+
+	err = db.View(func(tx *genji.Tx) error {
+		t.Run("GetByPK", func(t *testing.T) {
+			var u User
+			err := tx.GetByPK("users", 3, &u)
+			require.NoError(t, err)
+			require.Equal(t, User{ID: 3, Name: "user3", Age: 23}, u)
+
+			err = tx.GetByPK("users", 10, &u)
+			require.Equal(t, database.ErrDocumentNotFound, err)
+
+			// tables without primary key are queried by docid
+			err = tx.GetByPK("docs", 1, &u)
+			require.NoError(t, err)
+			require.Equal(t, User{ID: 10, Name: "foo"}, u)
+		})
+
+		t.Run("Find", func(t *testing.T) {
+			var users []User
+			err := tx.Find("users", &users, "age > ? AND name != 'user5'", 22)
+			require.NoError(t, err)
+			require.Equal(t, []User{{3, "user3", 23}, {4, "user4", 24}}, users)
+
+			var all []*User
+			err = tx.Find("users", &all, "")
+			require.NoError(t, err)
+			require.Len(t, all, 5)
+			require.Equal(t, &User{1, "user1", 21}, all[0])
+
+			// the condition must be a single expression
+			for _, where := range []string{"age > 1; DROP TABLE users", "age > 1 ORDER BY age", "age > 1 LIMIT 1"} {
+				err = tx.Find("users", &all, where)
+				require.Error(t, err, where)
+			}
+
+			err = tx.Find("users", users, "")
+			require.Error(t, err)
+		})
+
+		t.Run("Iterate", func(t *testing.T) {
+			var count int
+			err := tx.Iterate("users", func(d document.Document) error {
+				count++
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, 5, count)
+		})
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestTTLSweeper(t *testing.T) {
+	db, err := genji.New(context.Background(), memoryengine.NewEngine(), genji.WithTTLSweepInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE sessions(id INTEGER PRIMARY KEY, expires_at INTEGER) WITH TTL expires_at")
+	require.NoError(t, err)
+
+	now := time.Now().Unix()
+	err = db.Exec("INSERT INTO sessions (id, expires_at) VALUES (1, ?), (2, ?), (3, NULL)", now-10, now+3600)
+	require.NoError(t, err)
+
+	count := func() int {
+		var n int
+		err := db.View(func(tx *genji.Tx) error {
+			t, err := tx.GetTable("sessions")
+			if err != nil {
+				return err
+			}
+
+			return t.Iterate(func(d document.Document) error {
+				n++
+				return nil
+			})
+		})
+		require.NoError(t, err)
+		return n
+	}
+
+	require.Eventually(t, func() bool { return count() == 2 }, time.Second, 10*time.Millisecond)
+}
+
+func TestStructHelpersEmbedded(t *testing.T) {
+	type Timestamps struct {
+		Created time.Time `genji:"created,notnull"`
+	}
+
+	type Owner struct {
+		OwnerID int64 `genji:"owner_id,index"`
+	}
+
+	type Post struct {
+		Timestamps
+		*Owner
+		ID    int64  `genji:"id,pk"`
+		Title string `genji:"title"`
+	}
+
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.CreateTableFor("posts", Post{})
+	require.NoError(t, err)
+
+	created := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	p := Post{Timestamps: Timestamps{Created: created}, Owner: &Owner{OwnerID: 7}, ID: 1, Title: "foo"}
+
+	err = db.Update(func(tx *genji.Tx) error {
+		return tx.InsertStruct("posts", &p)
+	})
+	require.NoError(t, err)
+
+	// the fields of embedded structs are stored at the top level
+	d, err := db.QueryDocument("SELECT created, owner_id FROM posts WHERE owner_id = 7")
+	require.NoError(t, err)
+	data, err := document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"created": "2021-02-03T04:05:06Z", "owner_id": 7}`, string(data))
+
+	err = db.View(func(tx *genji.Tx) error {
+		var got Post
+		err := tx.GetByPK("posts", 1, &got)
+		require.NoError(t, err)
+		require.Equal(t, p, got)
+
+		var all []Post
+		err = tx.Find("posts", &all, "owner_id = 7")
+		require.NoError(t, err)
+		require.Equal(t, []Post{p}, all)
+		return nil
+	})
+	require.NoError(t, err)
+}
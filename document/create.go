@@ -149,12 +149,9 @@ func newFromStruct(ref reflect.Value) (Document, error) {
 			return nil, err
 		}
 
-		field := strings.ToLower(sf.Name)
-		if gtag, ok := sf.Tag.Lookup("genji"); ok {
-			if gtag == "-" {
-				continue
-			}
-			field = gtag
+		field, _, ok := StructFieldTag(sf)
+		if !ok {
+			continue
 		}
 
 		fb.Add(field, v)
@@ -163,6 +160,29 @@ func newFromStruct(ref reflect.Value) (Document, error) {
 	return &fb, nil
 }
 
+// StructFieldTag returns the name of the field associated with the struct field sf and the
+// list of options that follow it in the "genji" tag, in the form `genji:"name,option,option"`.
+// If the tag or the name are omitted, the name is the lowercased name of the struct field.
+// It returns false if the struct field must be ignored, using `genji:"-"`.
+func StructFieldTag(sf reflect.StructField) (name string, options []string, ok bool) {
+	gtag, found := sf.Tag.Lookup("genji")
+	if !found {
+		return strings.ToLower(sf.Name), nil, true
+	}
+
+	if gtag == "-" {
+		return "", nil, false
+	}
+
+	parts := strings.Split(gtag, ",")
+	name = parts[0]
+	if name == "" {
+		name = strings.ToLower(sf.Name)
+	}
+
+	return name, parts[1:], true
+}
+
 // NewValue creates a value whose type is infered from x.
 func NewValue(x interface{}) (Value, error) {
 	// Attempt exact matches first:
@@ -3,6 +3,7 @@ package document_test
 import (
 	"encoding/json"
 	"errors"
+	"reflect"
 	"testing"
 	"time"
 
@@ -528,6 +529,49 @@ type foo struct {
 	D float64
 }
 
+func TestStructFieldTag(t *testing.T) {
+	type user struct {
+		A int
+		B int `genji:"b-field"`
+		C int `genji:"c,pk,default=10"`
+		D int `genji:",notnull"`
+		E int `genji:"-"`
+	}
+
+	tests := []struct {
+		field   string
+		name    string
+		options []string
+		ok      bool
+	}{
+		{"A", "a", nil, true},
+		{"B", "b-field", []string{}, true},
+		{"C", "c", []string{"pk", "default=10"}, true},
+		{"D", "d", []string{"notnull"}, true},
+		{"E", "", nil, false},
+	}
+
+	tp := reflect.TypeOf(user{})
+	for _, test := range tests {
+		t.Run(test.field, func(t *testing.T) {
+			sf, _ := tp.FieldByName(test.field)
+			name, options, ok := document.StructFieldTag(sf)
+			require.Equal(t, test.name, name)
+			require.Equal(t, test.options, options)
+			require.Equal(t, test.ok, ok)
+		})
+	}
+
+	// options are not part of the field name
+	d, err := document.NewFromStruct(user{A: 1, B: 2, C: 3, D: 4, E: 5})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a": 1, "b-field": 2, "c": 3, "d": 4}`, document.NewDocumentValue(d).String())
+
+	var u user
+	err = document.StructScan(d, &u)
+	require.NoError(t, err)
+	require.Equal(t, user{A: 1, B: 2, C: 3, D: 4}, u)
+}
 func (f *foo) Iterate(fn func(field string, value document.Value) error) error {
 	var err error
 
@@ -17,6 +17,20 @@ type Codec interface {
 	// The returned document should ideally support random-access, i.e. decoding one path
 	// without decoding the entire document. If not, the document must be lazily decoded.
 	NewDocument([]byte) document.Document
+	// Name returns a unique identifier of the encoding format.
+	// It is persisted by the database to ensure documents are always
+	// decoded with the codec that encoded them.
+	Name() string
+}
+
+// A Wrapper is a codec that wraps another codec.
+// Wrappers must be able to decode documents encoded by the codec they wrap,
+// which allows a database to switch from the wrapped codec to the wrapper.
+type Wrapper interface {
+	Codec
+
+	// Unwrap returns the wrapped codec.
+	Unwrap() Codec
 }
 
 // An Encoder encodes one document to the underlying writer.
@@ -0,0 +1,145 @@
+// Package compression provides a codec that compresses the documents encoded by another codec.
+//
+// Compressed documents are prefixed with a header byte. Documents that are not prefixed with
+// that byte are considered uncompressed and passed as is to the wrapped codec, which allows
+// compressed and uncompressed documents to coexist in the same database and keeps databases
+// created without compression readable.
+package compression
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/encoding"
+	"github.com/golang/snappy"
+)
+
+// SnappyHeader is the first byte of every document compressed with Snappy.
+// It was chosen because neither the MessagePack codec nor the custom codec
+// can produce a document starting with a zero byte.
+const SnappyHeader byte = 0x00
+
+// DefaultThreshold is the default minimum size of an encoded document
+// for it to be compressed.
+const DefaultThreshold = 128
+
+// Options of the codec.
+type Options struct {
+	// Documents whose encoded size is lower than Threshold are stored uncompressed,
+	// since compressing small documents generally makes them bigger.
+	// If zero, DefaultThreshold is used. To compress every document, set it to a negative value.
+	Threshold int
+}
+
+// A Codec wraps another codec and compresses the documents it encodes using Snappy.
+// The wrapped codec must never encode documents starting with SnappyHeader.
+type Codec struct {
+	codec     encoding.Codec
+	threshold int
+}
+
+// NewCodec creates a codec that compresses documents encoded with codec.
+func NewCodec(codec encoding.Codec, opts *Options) Codec {
+	c := Codec{
+		codec:     codec,
+		threshold: DefaultThreshold,
+	}
+
+	if opts != nil && opts.Threshold != 0 {
+		c.threshold = opts.Threshold
+	}
+
+	return c
+}
+
+// NewEncoder implements the encoding.Codec interface.
+func (c Codec) NewEncoder(w io.Writer) encoding.Encoder {
+	e := Encoder{
+		w:         w,
+		threshold: c.threshold,
+	}
+	e.enc = c.codec.NewEncoder(&e.buf)
+	return &e
+}
+
+// NewDocument implements the encoding.Codec interface.
+// If data is compressed, it is decompressed before being passed to the wrapped codec,
+// otherwise it is passed as is.
+func (c Codec) NewDocument(data []byte) document.Document {
+	if len(data) == 0 || data[0] != SnappyHeader {
+		return c.codec.NewDocument(data)
+	}
+
+	dec, err := snappy.Decode(nil, data[1:])
+	if err != nil {
+		return errDocument{err}
+	}
+
+	return c.codec.NewDocument(dec)
+}
+
+// Name implements the encoding.Codec interface.
+func (c Codec) Name() string {
+	return "snappy+" + c.codec.Name()
+}
+
+// Unwrap implements the encoding.Wrapper interface.
+func (c Codec) Unwrap() encoding.Codec {
+	return c.codec
+}
+
+// Encoder encodes documents using the wrapped codec and compresses them.
+type Encoder struct {
+	w         io.Writer
+	enc       encoding.Encoder
+	buf       bytes.Buffer
+	cbuf      []byte
+	threshold int
+}
+
+// EncodeDocument encodes d and writes it to the underlying writer,
+// compressed if its size is greater than the threshold.
+func (e *Encoder) EncodeDocument(d document.Document) error {
+	e.buf.Reset()
+
+	err := e.enc.EncodeDocument(d)
+	if err != nil {
+		return err
+	}
+
+	if e.buf.Len() < e.threshold {
+		_, err = e.w.Write(e.buf.Bytes())
+		return err
+	}
+
+	n := snappy.MaxEncodedLen(e.buf.Len()) + 1
+	if cap(e.cbuf) < n {
+		e.cbuf = make([]byte, n)
+	}
+	e.cbuf = e.cbuf[:n]
+	e.cbuf[0] = SnappyHeader
+
+	enc := snappy.Encode(e.cbuf[1:], e.buf.Bytes())
+	_, err = e.w.Write(e.cbuf[:len(enc)+1])
+	return err
+}
+
+// Close the wrapped encoder.
+func (e *Encoder) Close() {
+	e.enc.Close()
+}
+
+// errDocument is returned when a document cannot be decompressed.
+// It returns the decompression error on every call.
+type errDocument struct {
+	err error
+}
+
+func (d errDocument) GetByField(field string) (document.Value, error) {
+	return document.Value{}, d.err
+}
+
+func (d errDocument) Iterate(fn func(field string, value document.Value) error) error {
+	return d.err
+}
@@ -0,0 +1,88 @@
+package compression
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/encoding"
+	"github.com/genjidb/genji/document/encoding/custom"
+	"github.com/genjidb/genji/document/encoding/encodingtest"
+	"github.com/genjidb/genji/document/encoding/msgpack"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodec(t *testing.T) {
+	t.Run("MessagePack", func(t *testing.T) {
+		encodingtest.TestCodec(t, func() encoding.Codec {
+			return NewCodec(msgpack.NewCodec(), &Options{Threshold: -1})
+		})
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		encodingtest.TestCodec(t, func() encoding.Codec {
+			return NewCodec(custom.NewCodec(), &Options{Threshold: -1})
+		})
+	})
+}
+
+func encode(t *testing.T, codec encoding.Codec, d document.Document) []byte {
+	var buf bytes.Buffer
+	enc := codec.NewEncoder(&buf)
+	defer enc.Close()
+
+	require.NoError(t, enc.EncodeDocument(d))
+	return buf.Bytes()
+}
+
+func TestCompression(t *testing.T) {
+	small := document.NewFieldBuffer().Add("a", document.NewTextValue("foo"))
+	big := document.NewFieldBuffer().Add("a", document.NewTextValue(strings.Repeat("foo", 1000)))
+
+	codecs := []struct {
+		name  string
+		codec encoding.Codec
+	}{
+		{"MessagePack", msgpack.NewCodec()},
+		{"Custom", custom.NewCodec()},
+	}
+
+	for _, c := range codecs {
+		t.Run(c.name, func(t *testing.T) {
+			codec := NewCodec(c.codec, nil)
+
+			t.Run("Small documents are not compressed", func(t *testing.T) {
+				data := encode(t, codec, small)
+				require.Equal(t, encode(t, c.codec, small), data)
+			})
+
+			t.Run("Big documents are compressed", func(t *testing.T) {
+				data := encode(t, codec, big)
+				raw := encode(t, c.codec, big)
+				require.Equal(t, SnappyHeader, data[0])
+				require.Less(t, len(data), len(raw))
+
+				v, err := codec.NewDocument(data).GetByField("a")
+				require.NoError(t, err)
+				require.Equal(t, strings.Repeat("foo", 1000), v.V)
+			})
+
+			t.Run("Uncompressed documents are readable", func(t *testing.T) {
+				data := encode(t, c.codec, big)
+
+				v, err := codec.NewDocument(data).GetByField("a")
+				require.NoError(t, err)
+				require.Equal(t, strings.Repeat("foo", 1000), v.V)
+			})
+
+			t.Run("Corrupted documents return an error", func(t *testing.T) {
+				d := codec.NewDocument([]byte{SnappyHeader, 0xff, 0xff})
+
+				_, err := d.GetByField("a")
+				require.Error(t, err)
+				require.Error(t, d.Iterate(func(string, document.Value) error { return nil }))
+			})
+		})
+	}
+}
@@ -29,6 +29,11 @@ func (c Codec) NewDocument(data []byte) document.Document {
 	return EncodedDocument(data)
 }
 
+// Name implements the encoding.Codec interface.
+func (c Codec) Name() string {
+	return "custom"
+}
+
 // Encoder encodes Genji documents and values
 // in MessagePack.
 type Encoder struct {
@@ -164,8 +169,14 @@ func (e EncodedDocument) Iterate(fn func(field string, value document.Value) err
 		return err
 	}
 
-	for _, fh := range format.Header.FieldHeaders {
-		v, err := DecodeValue(document.ValueType(fh.Type), format.Body[fh.Offset:fh.Offset+fh.Size])
+	for i := range format.Header.FieldHeaders {
+		fh := &format.Header.FieldHeaders[i]
+		data, err := format.fieldData(fh)
+		if err != nil {
+			return err
+		}
+
+		v, err := DecodeValue(document.ValueType(fh.Type), data)
 		if err != nil {
 			return err
 		}
@@ -198,8 +209,14 @@ func (e EncodedArray) Iterate(fn func(i int, value document.Value) error) error
 		return err
 	}
 
-	for _, fh := range format.Header.FieldHeaders {
-		v, err := DecodeValue(document.ValueType(fh.Type), format.Body[fh.Offset:fh.Offset+fh.Size])
+	for i := range format.Header.FieldHeaders {
+		fh := &format.Header.FieldHeaders[i]
+		data, err := format.fieldData(fh)
+		if err != nil {
+			return err
+		}
+
+		v, err := DecodeValue(document.ValueType(fh.Type), data)
 		if err != nil {
 			return err
 		}
@@ -215,8 +232,11 @@ func (e EncodedArray) Iterate(fn func(i int, value document.Value) error) error
 }
 
 // GetByIndex returns a value by index of the array.
+// Values are stored in order, so the value at index i is described by the i-th field header.
 func (e EncodedArray) GetByIndex(i int) (document.Value, error) {
-	v, err := decodeValueFromDocument(e, string(encodeInt64(int64(i))))
+	v, err := lookupField(e, func(j int, _ []byte) bool {
+		return i == j
+	})
 	if err == document.ErrFieldNotFound {
 		return v, document.ErrValueNotFound
 	}
@@ -229,10 +249,21 @@ func (e EncodedArray) MarshalJSON() ([]byte, error) {
 	return document.MarshalJSONArray(e)
 }
 
+// decodeValueFromDocument decodes the value of the given field without decoding the rest of the document.
 func decodeValueFromDocument(data []byte, field string) (document.Value, error) {
+	return lookupField(data, func(_ int, name []byte) bool {
+		return string(name) == field
+	})
+}
+
+// lookupField walks through the field headers of data and decodes the value of the first
+// field for which match returns true.
+// Only the name of each field header is read before calling match, the rest
+// of the header is skipped unless the field matches.
+func lookupField(data []byte, match func(i int, name []byte) bool) (document.Value, error) {
 	hsize, n := binary.Uvarint(data)
-	if n <= 0 {
-		return document.Value{}, errors.New("cannot decode data")
+	if n <= 0 || hsize > uint64(len(data)-n) {
+		return document.Value{}, errCannotDecode
 	}
 
 	hdata := data[n : n+int(hsize)]
@@ -241,26 +272,59 @@ func decodeValueFromDocument(data []byte, field string) (document.Value, error)
 	// skip number of fields
 	_, n = binary.Uvarint(hdata)
 	if n <= 0 {
-		return document.Value{}, errors.New("cannot decode data")
+		return document.Value{}, errCannotDecode
 	}
 	hdata = hdata[n:]
 
 	var fh FieldHeader
-	for len(hdata) > 0 {
-		n, err := fh.Decode(hdata)
+	for i := 0; len(hdata) > 0; i++ {
+		nameSize, n := binary.Uvarint(hdata)
+		if n <= 0 || nameSize > uint64(len(hdata)-n) {
+			return document.Value{}, errCannotDecode
+		}
+
+		if !match(i, hdata[n:n+int(nameSize)]) {
+			// skip name, type, size and offset
+			var err error
+			hdata, err = skipUvarints(hdata[n+int(nameSize):], 3)
+			if err != nil {
+				return document.Value{}, err
+			}
+			continue
+		}
+
+		_, err := fh.Decode(hdata)
 		if err != nil {
 			return document.Value{}, err
 		}
-		hdata = hdata[n:]
 
-		if field == string(fh.Name) {
-			return DecodeValue(document.ValueType(fh.Type), body[fh.Offset:fh.Offset+fh.Size])
+		d, err := fieldData(body, &fh)
+		if err != nil {
+			return document.Value{}, err
 		}
+
+		return DecodeValue(document.ValueType(fh.Type), d)
 	}
 
 	return document.Value{}, document.ErrFieldNotFound
 }
 
+// skipUvarints skips count uvarints from data without decoding them.
+func skipUvarints(data []byte, count int) ([]byte, error) {
+	for ; count > 0; count-- {
+		i := 0
+		for i < len(data) && i < binary.MaxVarintLen64 && data[i] >= 0x80 {
+			i++
+		}
+		if i == len(data) || i == binary.MaxVarintLen64 {
+			return nil, errCannotDecode
+		}
+		data = data[i+1:]
+	}
+
+	return data, nil
+}
+
 // EncodeArray encodes a into its binary representation.
 func EncodeArray(a document.Array) ([]byte, error) {
 	var format Format
@@ -334,7 +398,10 @@ func DecodeValue(t document.ValueType, data []byte) (document.Value, error) {
 		}
 		return document.NewBoolValue(x), nil
 	case document.IntegerValue:
-		x, _ := binary.Varint(data)
+		x, n := binary.Varint(data)
+		if n <= 0 {
+			return document.Value{}, errCannotDecode
+		}
 		return document.NewIntegerValue(x), nil
 	case document.DoubleValue:
 		x, err := binarysort.DecodeFloat64(data)
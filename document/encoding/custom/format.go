@@ -7,6 +7,8 @@ import (
 	"io"
 )
 
+var errCannotDecode = errors.New("cannot decode data")
+
 // Format is an encoding format used to encode and decode documents.
 // It is composed of a header and a body.
 // The header defines a list of fields, offsets and relevant metadata.
@@ -42,8 +44,8 @@ func (h *Header) Decode(data []byte) (int, error) {
 	var n int
 
 	h.Size, n = binary.Uvarint(data)
-	if n <= 0 {
-		return 0, errors.New("cannot decode data")
+	if n <= 0 || h.Size > uint64(len(data)-n) {
+		return 0, errCannotDecode
 	}
 
 	hdata := data[n : n+int(h.Size)]
@@ -51,7 +53,7 @@ func (h *Header) Decode(data []byte) (int, error) {
 
 	h.FieldsCount, n = binary.Uvarint(hdata)
 	if n <= 0 {
-		return 0, errors.New("cannot decode data")
+		return 0, errCannotDecode
 	}
 	hdata = hdata[n:]
 
@@ -70,6 +72,19 @@ func (h *Header) Decode(data []byte) (int, error) {
 	return read, nil
 }
 
+// fieldData returns the data of the field described by fh.
+func (f *Format) fieldData(fh *FieldHeader) ([]byte, error) {
+	return fieldData(f.Body, fh)
+}
+
+func fieldData(body []byte, fh *FieldHeader) ([]byte, error) {
+	if fh.Offset > uint64(len(body)) || fh.Size > uint64(len(body))-fh.Offset {
+		return nil, errCannotDecode
+	}
+
+	return body[fh.Offset : fh.Offset+fh.Size], nil
+}
+
 // BodySize returns the size of the body.
 func (h *Header) BodySize() int {
 	var size uint64
@@ -136,8 +151,8 @@ func (f *FieldHeader) Decode(data []byte) (int, error) {
 
 	// name size
 	f.NameSize, n = binary.Uvarint(data)
-	if n <= 0 {
-		return 0, errors.New("cannot decode data")
+	if n <= 0 || f.NameSize > uint64(len(data)-n) {
+		return 0, errCannotDecode
 	}
 	data = data[n:]
 	read += n
@@ -150,7 +165,7 @@ func (f *FieldHeader) Decode(data []byte) (int, error) {
 	// type
 	f.Type, n = binary.Uvarint(data)
 	if n <= 0 {
-		return 0, errors.New("cannot decode data")
+		return 0, errCannotDecode
 	}
 	data = data[n:]
 	read += n
@@ -158,7 +173,7 @@ func (f *FieldHeader) Decode(data []byte) (int, error) {
 	// size
 	f.Size, n = binary.Uvarint(data)
 	if n <= 0 {
-		return 0, errors.New("cannot decode data")
+		return 0, errCannotDecode
 	}
 	data = data[n:]
 	read += n
@@ -166,7 +181,7 @@ func (f *FieldHeader) Decode(data []byte) (int, error) {
 	// offset
 	f.Offset, n = binary.Uvarint(data)
 	if n <= 0 {
-		return 0, errors.New("cannot decode data")
+		return 0, errCannotDecode
 	}
 	data = data[n:]
 	read += n
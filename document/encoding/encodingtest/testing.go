@@ -23,6 +23,8 @@ func TestCodec(t *testing.T, codecBuilder func() encoding.Codec) {
 		{"Document/JSON", testDocumentJSON},
 		{"Array/GetByIndex", testArrayGetByIndex},
 		{"Array/JSON", testArrayJSON},
+		{"Nested/GetByField", testNestedGetByField},
+		{"Malformed", testMalformed},
 	}
 
 	for _, test := range tests {
@@ -261,3 +263,88 @@ func testDecodeDocument(t *testing.T, codecBuilder func() encoding.Codec) {
 	require.NoError(t, err)
 	require.Equal(t, 3, i)
 }
+
+func testNestedGetByField(t *testing.T, codecBuilder func() encoding.Codec) {
+	codec := codecBuilder()
+
+	inner := document.NewFieldBuffer().
+		Add("b", document.NewTextValue("foo")).
+		Add("c", document.NewArrayValue(document.NewValueBuffer().
+			Append(document.NewIntegerValue(1)).
+			Append(document.NewArrayValue(document.NewValueBuffer().Append(document.NewDoubleValue(2.5))))))
+
+	doc := document.NewFieldBuffer().
+		Add("a", document.NewDocumentValue(inner)).
+		Add("d", document.NewBoolValue(true))
+
+	var buf bytes.Buffer
+
+	enc := codec.NewEncoder(&buf)
+	defer enc.Close()
+
+	err := enc.EncodeDocument(doc)
+	require.NoError(t, err)
+
+	d := codec.NewDocument(buf.Bytes())
+
+	field := func(name string) document.PathFragment {
+		return document.PathFragment{FieldName: name}
+	}
+	index := func(i int) document.PathFragment {
+		return document.PathFragment{ArrayIndex: i}
+	}
+
+	tests := []struct {
+		path     document.Path
+		expected document.Value
+	}{
+		{document.Path{field("a"), field("b")}, document.NewTextValue("foo")},
+		{document.Path{field("a"), field("c"), index(0)}, document.NewIntegerValue(1)},
+		{document.Path{field("a"), field("c"), index(1), index(0)}, document.NewDoubleValue(2.5)},
+		{document.Path{field("d")}, document.NewBoolValue(true)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path.String(), func(t *testing.T) {
+			v, err := test.path.GetValueFromDocument(d)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, v)
+		})
+	}
+
+	_, err = document.NewPath("a", "e").GetValueFromDocument(d)
+	require.Equal(t, document.ErrFieldNotFound, err)
+
+	_, err = document.Path{field("a"), field("c"), index(2)}.GetValueFromDocument(d)
+	require.Equal(t, document.ErrFieldNotFound, err)
+}
+
+func testMalformed(t *testing.T, codecBuilder func() encoding.Codec) {
+	codec := codecBuilder()
+
+	doc := document.NewFieldBuffer().
+		Add("a", document.NewTextValue("foo")).
+		Add("b", document.NewArrayValue(document.NewValueBuffer().Append(document.NewIntegerValue(1))))
+
+	var buf bytes.Buffer
+
+	enc := codec.NewEncoder(&buf)
+	defer enc.Close()
+
+	err := enc.EncodeDocument(doc)
+	require.NoError(t, err)
+
+	data := buf.Bytes()
+
+	// truncated documents must return an error instead of panicking
+	for i := 1; i < len(data); i++ {
+		d := codec.NewDocument(data[:i])
+
+		err := d.Iterate(func(string, document.Value) error { return nil })
+		require.Error(t, err, "iterating over the first %d bytes", i)
+
+		require.NotPanics(t, func() {
+			_, _ = d.GetByField("b")
+		})
+	}
+}
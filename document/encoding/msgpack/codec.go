@@ -28,6 +28,11 @@ func (c Codec) NewDocument(data []byte) document.Document {
 	return EncodedDocument(data)
 }
 
+// Name implements the encoding.Codec interface.
+func (c Codec) Name() string {
+	return "msgpack"
+}
+
 // Encoder encodes Genji documents and values
 // in MessagePack.
 type Encoder struct {
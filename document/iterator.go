@@ -218,17 +218,20 @@ func (s Stream) Offset(n int) Stream {
 }
 
 // Append adds the given iterator to the stream.
+// The operations of the stream are only applied to the documents
+// of the stream, not to the ones of the appended iterator.
 func (s Stream) Append(it Iterator) Stream {
-	if mr, ok := s.it.(multiIterator); ok {
+	if mr, ok := s.it.(multiIterator); ok && s.op == nil {
 		mr.iterators = append(mr.iterators, it)
 		s.it = mr
-	} else {
-		s.it = multiIterator{
-			iterators: []Iterator{s, it},
-		}
+		return s
 	}
 
-	return s
+	return Stream{
+		it: multiIterator{
+			iterators: []Iterator{s, it},
+		},
+	}
 }
 
 // Count counts all the documents from the stream.
@@ -165,3 +165,25 @@ func TestIteratorToJSONArray(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, `[{"a": 0}, {"a": 1}, {"a": 2}]`, buf.String())
 }
+
+func TestStreamAppend(t *testing.T) {
+	newIterator := func(from, to int) document.Iterator {
+		var docs []document.Document
+		for i := from; i < to; i++ {
+			docs = append(docs, document.NewFieldBuffer().Add("a", document.NewIntegerValue(int64(i))))
+		}
+		return document.NewIterator(docs...)
+	}
+
+	st := document.NewStream(newIterator(0, 3)).Filter(func(d document.Document) (bool, error) {
+		v, err := d.GetByField("a")
+		return v.V.(int64) > 0, err
+	})
+
+	st = st.Append(newIterator(0, 2)).Append(newIterator(5, 6))
+
+	var buf bytes.Buffer
+	err := document.IteratorToJSONArray(&buf, st)
+	require.NoError(t, err)
+	require.Equal(t, `[{"a": 1}, {"a": 2}, {"a": 0}, {"a": 1}, {"a": 5}]`, buf.String())
+}
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-	"strings"
 	"time"
 )
 
@@ -43,8 +42,8 @@ func Scan(d Document, targets ...interface{}) error {
 // field type when possible, otherwise an error is returned.
 // The decoding of each struct field can be customized by the format string stored
 // under the "genji" key stored in the struct field's tag.
-// The content of the format string is used instead of the struct field name and passed
-// to the GetByField method.
+// The name found before the first comma of the format string is used instead of the
+// struct field name and passed to the GetByField method. See StructFieldTag.
 func StructScan(d Document, t interface{}) error {
 	ref := reflect.ValueOf(t)
 
@@ -70,15 +69,31 @@ func structScan(d Document, ref reflect.Value) error {
 	for i := 0; i < l; i++ {
 		f := sref.Field(i)
 		sf := stp.Field(i)
-		var name string
-		if gtag, ok := sf.Tag.Lookup("genji"); ok {
-			if gtag == "-" {
+
+		// the fields of embedded structs are stored in the document itself,
+		// see NewFromStruct.
+		if sf.Anonymous {
+			if f.Kind() == reflect.Ptr && f.Type().Elem().Kind() == reflect.Struct {
+				if f.IsNil() {
+					if !f.CanSet() {
+						continue
+					}
+					f.Set(reflect.New(f.Type().Elem()))
+				}
+				f = f.Elem()
+			}
+
+			if f.Kind() == reflect.Struct {
+				if err := structScan(d, f.Addr()); err != nil {
+					return err
+				}
 				continue
 			}
+		}
 
-			name = gtag
-		} else {
-			name = strings.ToLower(sf.Name)
+		name, _, ok := StructFieldTag(sf)
+		if !ok {
+			continue
 		}
 		v, err := d.GetByField(name)
 		if err == ErrFieldNotFound {
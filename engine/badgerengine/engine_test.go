@@ -1,6 +1,7 @@
 package badgerengine_test
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 	"github.com/dgraph-io/badger/v2"
 	"github.com/genjidb/genji/engine"
 	"github.com/genjidb/genji/engine/badgerengine"
+	"github.com/genjidb/genji/engine/encryptedengine"
 	"github.com/genjidb/genji/engine/enginetest"
 	"github.com/stretchr/testify/require"
 )
@@ -29,6 +31,19 @@ func TestBadgerEngine(t *testing.T) {
 	enginetest.TestSuite(t, builder(t))
 }
 
+func TestEncryptedBadgerEngine(t *testing.T) {
+	b := builder(t)
+	enginetest.TestSuite(t, func() (engine.Engine, func()) {
+		ng, cleanup := b()
+		eng, err := encryptedengine.NewEngine(ng, encryptedengine.Options{
+			Keys:      map[uint32][]byte{1: bytes.Repeat([]byte{1}, 32)},
+			ActiveKey: 1,
+		})
+		require.NoError(t, err)
+		return eng, cleanup
+	})
+}
+
 func BenchmarkBadgerEngineStorePut(b *testing.B) {
 	enginetest.BenchmarkStorePut(b, builder(b))
 }
@@ -0,0 +1,251 @@
+// Package encryptedengine implements an engine that encrypts the data of any other engine.
+//
+// Values are encrypted with AES-GCM using a random nonce. Every value is prefixed with the id
+// of the key that was used to encrypt it, which allows adding new keys over time without having
+// to rewrite existing data: new values are always encrypted with the active key, while older
+// values remain readable as long as their key is still provided.
+//
+// Keys can optionally be encrypted as well. Since stores must be able to look up a key
+// directly and iterate over keys in order, keys are encrypted deterministically with an
+// order-preserving scheme: encrypted keys are sorted like their plaintext, which lets the
+// underlying engine seek and iterate without loading anything in memory. In exchange, the
+// encrypted keys reveal the ordering of the keys, which keys are equal, the length of their
+// common prefixes and their length. Values are authenticated together with their plaintext key,
+// which prevents moving a value from one key to another. By default, keys are stored in plaintext
+// and only values are encrypted.
+package encryptedengine
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/engine"
+)
+
+// storeListName is the name of the store used to keep track of
+// the stores created through the engine.
+// It is used to find which stores must be reencrypted during a rotation.
+var storeListName = []byte("__genji_encrypted_stores")
+
+// Options configures the encrypted engine.
+type Options struct {
+	// Keys used to encrypt and decrypt values, indexed by id.
+	// Each key must be either 16, 24 or 32 bytes long, to select
+	// AES-128, AES-192 or AES-256 respectively.
+	// Keys that were used to encrypt existing values must be kept
+	// until Rotate has been called.
+	Keys map[uint32][]byte
+
+	// ActiveKey is the id of the key used to encrypt new values.
+	ActiveKey uint32
+
+	// KeyEncryptionKey, if set, enables encryption of the keys of every store.
+	// It must be either 16, 24 or 32 bytes long.
+	// Unlike value keys, it can't be changed once data has been written.
+	// Encrypted keys are twice as long as their plaintext.
+	KeyEncryptionKey []byte
+}
+
+// Engine wraps an engine and encrypts all the data written to it.
+type Engine struct {
+	ng engine.Engine
+
+	aeads  map[uint32]cipher.AEAD
+	active uint32
+
+	keys *keyCipher
+}
+
+// NewEngine creates an engine that encrypts the data stored in ng.
+func NewEngine(ng engine.Engine, opts Options) (*Engine, error) {
+	if len(opts.Keys) == 0 {
+		return nil, errors.New("at least one key must be provided")
+	}
+
+	if _, ok := opts.Keys[opts.ActiveKey]; !ok {
+		return nil, fmt.Errorf("active key %d not found", opts.ActiveKey)
+	}
+
+	e := Engine{
+		ng:     ng,
+		aeads:  make(map[uint32]cipher.AEAD, len(opts.Keys)),
+		active: opts.ActiveKey,
+	}
+
+	for id, k := range opts.Keys {
+		aead, err := newAEAD(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %d: %w", id, err)
+		}
+
+		e.aeads[id] = aead
+	}
+
+	if opts.KeyEncryptionKey != nil {
+		kc, err := newKeyCipher(opts.KeyEncryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key encryption key: %w", err)
+		}
+
+		e.keys = kc
+	}
+
+	return &e, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Begin creates a transaction on the underlying engine.
+func (e *Engine) Begin(ctx context.Context, opts engine.TxOptions) (engine.Transaction, error) {
+	tx, err := e.ng.Begin(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transaction{
+		tx: tx,
+		ng: e,
+	}, nil
+}
+
+// Close the underlying engine.
+func (e *Engine) Close() error {
+	return e.ng.Close()
+}
+
+// Rotate reencrypts all the values that were not encrypted with the active key.
+// Once it returns, keys other than the active one are no longer needed and can
+// be removed from the options.
+func (e *Engine) Rotate(ctx context.Context) error {
+	tx, err := e.Begin(ctx, engine.TxOptions{Writable: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	list, err := tx.(*Transaction).tx.GetStore(storeListName)
+	if err == engine.ErrStoreNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var names [][]byte
+	it := list.Iterator(engine.IteratorOptions{})
+	for it.Seek(nil); it.Valid(); it.Next() {
+		names = append(names, append([]byte{}, it.Item().Key()...))
+	}
+	err = it.Err()
+	if err != nil {
+		it.Close()
+		return err
+	}
+	err = it.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		st, err := tx.GetStore(name)
+		if err != nil {
+			return err
+		}
+
+		err = st.(*Store).rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// A Transaction wraps a transaction of the underlying engine.
+type Transaction struct {
+	tx engine.Transaction
+	ng *Engine
+}
+
+// Rollback the transaction.
+func (t *Transaction) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// Commit the transaction.
+func (t *Transaction) Commit() error {
+	return t.tx.Commit()
+}
+
+// GetStore returns a store that encrypts and decrypts data on the fly.
+func (t *Transaction) GetStore(name []byte) (engine.Store, error) {
+	st, err := t.tx.GetStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		st: st,
+		ng: t.ng,
+	}, nil
+}
+
+// CreateStore creates a store and registers it in the list of encrypted stores.
+func (t *Transaction) CreateStore(name []byte) error {
+	err := t.tx.CreateStore(name)
+	if err != nil {
+		return err
+	}
+
+	list, err := t.storeList()
+	if err != nil {
+		return err
+	}
+
+	return list.Put(name, nil)
+}
+
+// DropStore drops a store and removes it from the list of encrypted stores.
+func (t *Transaction) DropStore(name []byte) error {
+	err := t.tx.DropStore(name)
+	if err != nil {
+		return err
+	}
+
+	list, err := t.storeList()
+	if err != nil {
+		return err
+	}
+
+	err = list.Delete(name)
+	if err == engine.ErrKeyNotFound {
+		return nil
+	}
+	return err
+}
+
+// storeList returns the store holding the names of the encrypted stores,
+// and creates it if it doesn't exist.
+func (t *Transaction) storeList() (engine.Store, error) {
+	st, err := t.tx.GetStore(storeListName)
+	if err != engine.ErrStoreNotFound {
+		return st, err
+	}
+
+	err = t.tx.CreateStore(storeListName)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.tx.GetStore(storeListName)
+}
@@ -0,0 +1,296 @@
+package encryptedengine_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/boltengine"
+	"github.com/genjidb/genji/engine/encryptedengine"
+	"github.com/genjidb/genji/engine/enginetest"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	key1 = bytes.Repeat([]byte{1}, 32)
+	key2 = bytes.Repeat([]byte{2}, 16)
+	kek  = bytes.Repeat([]byte{3}, 32)
+)
+
+// builder wraps the engines created by b in an encrypted engine.
+func builder(t testing.TB, b enginetest.Builder, opts encryptedengine.Options) enginetest.Builder {
+	return func() (engine.Engine, func()) {
+		ng, cleanup := b()
+		eng, err := encryptedengine.NewEngine(ng, opts)
+		require.NoError(t, err)
+		return eng, cleanup
+	}
+}
+
+func memoryBuilder() (engine.Engine, func()) {
+	return memoryengine.NewEngine(), func() {}
+}
+
+func boltBuilder(t testing.TB) enginetest.Builder {
+	return func() (engine.Engine, func()) {
+		dir, err := ioutil.TempDir("", "genji")
+		require.NoError(t, err)
+
+		ng, err := boltengine.NewEngine(filepath.Join(dir, "test.db"), 0o600, nil)
+		require.NoError(t, err)
+		return ng, func() {
+			os.RemoveAll(dir)
+		}
+	}
+}
+
+func TestEncryptedEngine(t *testing.T) {
+	opts := encryptedengine.Options{Keys: map[uint32][]byte{1: key1}, ActiveKey: 1}
+
+	t.Run("Memory", func(t *testing.T) {
+		enginetest.TestSuite(t, builder(t, memoryBuilder, opts))
+	})
+
+	t.Run("Bolt", func(t *testing.T) {
+		enginetest.TestSuite(t, builder(t, boltBuilder(t), opts))
+	})
+
+	opts.KeyEncryptionKey = kek
+
+	t.Run("Memory/Encrypted keys", func(t *testing.T) {
+		enginetest.TestSuite(t, builder(t, memoryBuilder, opts))
+	})
+
+	t.Run("Bolt/Encrypted keys", func(t *testing.T) {
+		enginetest.TestSuite(t, builder(t, boltBuilder(t), opts))
+	})
+}
+
+func TestNewEngine(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  encryptedengine.Options
+		fails bool
+	}{
+		{"No keys", encryptedengine.Options{}, true},
+		{"Unknown active key", encryptedengine.Options{Keys: map[uint32][]byte{1: key1}, ActiveKey: 2}, true},
+		{"Invalid key size", encryptedengine.Options{Keys: map[uint32][]byte{1: []byte("foo")}, ActiveKey: 1}, true},
+		{"Invalid key encryption key size", encryptedengine.Options{Keys: map[uint32][]byte{1: key1}, ActiveKey: 1, KeyEncryptionKey: []byte("foo")}, true},
+		{"Ok", encryptedengine.Options{Keys: map[uint32][]byte{1: key1, 2: key2}, ActiveKey: 2, KeyEncryptionKey: kek}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := encryptedengine.NewEngine(memoryengine.NewEngine(), test.opts)
+			if test.fails {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+// put stores k and v in the "test" store, creating it if necessary.
+func put(t *testing.T, ng engine.Engine, k, v []byte) {
+	tx, err := ng.Begin(context.Background(), engine.TxOptions{Writable: true})
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	st, err := tx.GetStore([]byte("test"))
+	if err == engine.ErrStoreNotFound {
+		require.NoError(t, tx.CreateStore([]byte("test")))
+		st, err = tx.GetStore([]byte("test"))
+	}
+	require.NoError(t, err)
+	require.NoError(t, st.Put(k, v))
+	require.NoError(t, tx.Commit())
+}
+
+// scan returns all the raw keys and values of the "test" store.
+func scan(t *testing.T, ng engine.Engine) (keys, values [][]byte) {
+	tx, err := ng.Begin(context.Background(), engine.TxOptions{})
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	st, err := tx.GetStore([]byte("test"))
+	require.NoError(t, err)
+
+	it := st.Iterator(engine.IteratorOptions{})
+	defer it.Close()
+
+	for it.Seek(nil); it.Valid(); it.Next() {
+		v, err := it.Item().ValueCopy(nil)
+		require.NoError(t, err)
+		keys = append(keys, append([]byte{}, it.Item().Key()...))
+		values = append(values, v)
+	}
+	require.NoError(t, it.Err())
+	return
+}
+
+func TestEncryption(t *testing.T) {
+	t.Run("Values are not stored in plaintext", func(t *testing.T) {
+		mem := memoryengine.NewEngine()
+		ng, err := encryptedengine.NewEngine(mem, encryptedengine.Options{Keys: map[uint32][]byte{1: key1}, ActiveKey: 1})
+		require.NoError(t, err)
+
+		put(t, ng, []byte("foo"), []byte("secret"))
+
+		keys, values := scan(t, mem)
+		require.Equal(t, [][]byte{[]byte("foo")}, keys)
+		require.NotContains(t, string(values[0]), "secret")
+
+		keys, values = scan(t, ng)
+		require.Equal(t, [][]byte{[]byte("foo")}, keys)
+		require.Equal(t, [][]byte{[]byte("secret")}, values)
+	})
+
+	t.Run("Keys are not stored in plaintext", func(t *testing.T) {
+		mem := memoryengine.NewEngine()
+		ng, err := encryptedengine.NewEngine(mem, encryptedengine.Options{Keys: map[uint32][]byte{1: key1}, ActiveKey: 1, KeyEncryptionKey: kek})
+		require.NoError(t, err)
+
+		put(t, ng, []byte("foo"), []byte("secret"))
+
+		keys, _ := scan(t, mem)
+		require.Len(t, keys, 1)
+		require.NotContains(t, string(keys[0]), "foo")
+
+		keys, values := scan(t, ng)
+		require.Equal(t, [][]byte{[]byte("foo")}, keys)
+		require.Equal(t, [][]byte{[]byte("secret")}, values)
+	})
+
+	t.Run("Encrypted keys are ordered", func(t *testing.T) {
+		mem := memoryengine.NewEngine()
+		ng, err := encryptedengine.NewEngine(mem, encryptedengine.Options{Keys: map[uint32][]byte{1: key1}, ActiveKey: 1, KeyEncryptionKey: kek})
+		require.NoError(t, err)
+
+		// many keys of various lengths, sharing prefixes
+		rnd := rand.New(rand.NewSource(1))
+		uniq := make(map[string]bool)
+		for len(uniq) < 20000 {
+			k := make([]byte, 1+rnd.Intn(8))
+			for i := range k {
+				k[i] = byte(rnd.Intn(4)) * 85
+			}
+			uniq[string(k)] = true
+		}
+		var want [][]byte
+		for k := range uniq {
+			want = append(want, []byte(k))
+		}
+		sort.Slice(want, func(i, j int) bool {
+			return bytes.Compare(want[i], want[j]) < 0
+		})
+
+		tx, err := ng.Begin(context.Background(), engine.TxOptions{Writable: true})
+		require.NoError(t, err)
+		require.NoError(t, tx.CreateStore([]byte("test")))
+		st, err := tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+		for k := range uniq {
+			require.NoError(t, st.Put([]byte(k), []byte(k)))
+		}
+		require.NoError(t, tx.Commit())
+
+		// the stored keys are sorted like their plaintext
+		raw, _ := scan(t, mem)
+		require.Len(t, raw, len(want))
+		keys, values := scan(t, ng)
+		require.Equal(t, want, keys)
+		require.Equal(t, want, values)
+
+		tx, err = ng.Begin(context.Background(), engine.TxOptions{})
+		require.NoError(t, err)
+		defer tx.Rollback()
+		st, err = tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+
+		// seeking moves to the first key greater or equal to the pivot,
+		// or lower or equal in reverse.
+		for _, pivot := range [][]byte{{0}, {1}, {85, 170}, {170, 255, 255, 255, 255, 255, 255, 255, 255}, {255, 0}} {
+			i := sort.Search(len(want), func(i int) bool {
+				return bytes.Compare(want[i], pivot) >= 0
+			})
+
+			it := st.Iterator(engine.IteratorOptions{})
+			it.Seek(pivot)
+			if i < len(want) {
+				require.True(t, it.Valid())
+				require.Equal(t, want[i], it.Item().Key())
+			} else {
+				require.False(t, it.Valid())
+			}
+			require.NoError(t, it.Close())
+
+			if i == len(want) || !bytes.Equal(want[i], pivot) {
+				i--
+			}
+			it = st.Iterator(engine.IteratorOptions{Reverse: true})
+			it.Seek(pivot)
+			if i >= 0 {
+				require.True(t, it.Valid())
+				require.Equal(t, want[i], it.Item().Key())
+			} else {
+				require.False(t, it.Valid())
+			}
+			require.NoError(t, it.Close())
+		}
+	})
+
+	t.Run("Wrong key", func(t *testing.T) {
+		mem := memoryengine.NewEngine()
+		ng, err := encryptedengine.NewEngine(mem, encryptedengine.Options{Keys: map[uint32][]byte{1: key1}, ActiveKey: 1})
+		require.NoError(t, err)
+
+		put(t, ng, []byte("foo"), []byte("secret"))
+
+		ng, err = encryptedengine.NewEngine(mem, encryptedengine.Options{Keys: map[uint32][]byte{1: key2}, ActiveKey: 1})
+		require.NoError(t, err)
+
+		tx, err := ng.Begin(context.Background(), engine.TxOptions{})
+		require.NoError(t, err)
+		defer tx.Rollback()
+		st, err := tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+		_, err = st.Get([]byte("foo"))
+		require.Error(t, err)
+	})
+
+	t.Run("Rotation", func(t *testing.T) {
+		mem := memoryengine.NewEngine()
+		ng, err := encryptedengine.NewEngine(mem, encryptedengine.Options{Keys: map[uint32][]byte{1: key1}, ActiveKey: 1, KeyEncryptionKey: kek})
+		require.NoError(t, err)
+
+		put(t, ng, []byte("a"), []byte("A"))
+		put(t, ng, []byte("b"), []byte("B"))
+
+		// add a new key, old values must still be readable
+		ng, err = encryptedengine.NewEngine(mem, encryptedengine.Options{Keys: map[uint32][]byte{1: key1, 2: key2}, ActiveKey: 2, KeyEncryptionKey: kek})
+		require.NoError(t, err)
+		put(t, ng, []byte("c"), []byte("C"))
+
+		keys, values := scan(t, ng)
+		require.Equal(t, [][]byte{[]byte("a"), []byte("b"), []byte("c")}, keys)
+		require.Equal(t, [][]byte{[]byte("A"), []byte("B"), []byte("C")}, values)
+
+		require.NoError(t, ng.Rotate(context.Background()))
+
+		// after rotation, the old key is no longer needed
+		ng, err = encryptedengine.NewEngine(mem, encryptedengine.Options{Keys: map[uint32][]byte{2: key2}, ActiveKey: 2, KeyEncryptionKey: kek})
+		require.NoError(t, err)
+
+		keys, values = scan(t, ng)
+		require.Equal(t, [][]byte{[]byte("a"), []byte("b"), []byte("c")}, keys)
+		require.Equal(t, [][]byte{[]byte("A"), []byte("B"), []byte("C")}, values)
+	})
+}
@@ -0,0 +1,114 @@
+package encryptedengine
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"sort"
+)
+
+var errInvalidEncryptedKey = errors.New("invalid encrypted key")
+
+// keyCipher encrypts keys deterministically while preserving their ordering.
+//
+// Each byte of a key is replaced by two bytes, using a secret strictly increasing mapping
+// from the 256 possible values of a byte to 16-bit integers. The mapping used for a byte
+// depends on all the bytes that precede it in the key: two keys sharing a prefix are encrypted
+// with the same mappings up to the first byte that differs, and the mapping of that byte
+// preserves the order between them. As a result, encrypted keys are sorted exactly like their
+// plaintext, and the underlying engine can seek and iterate over them directly.
+type keyCipher struct {
+	// block generates the mappings from the state of each position.
+	block cipher.Block
+	// macKey derives the state of each position from the state
+	// of the previous position and the byte found there.
+	macKey []byte
+}
+
+func newKeyCipher(key []byte) (*keyCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// derive a different key for generating states
+	// to avoid using the same key for two different purposes.
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("genji key order"))
+
+	return &keyCipher{
+		block:  block,
+		macKey: mac.Sum(nil),
+	}, nil
+}
+
+// encrypt returns the encrypted form of k, which is twice as long.
+func (c *keyCipher) encrypt(k []byte) []byte {
+	mac := hmac.New(sha256.New, c.macKey)
+	state := mac.Sum(nil)
+
+	var m [256]uint16
+	out := make([]byte, 2*len(k))
+	for i, b := range k {
+		c.mapping(state, &m)
+		binary.BigEndian.PutUint16(out[2*i:], m[b])
+		state = c.next(mac, state, b)
+	}
+
+	return out
+}
+
+// decrypt returns the plaintext of a key encrypted by encrypt.
+func (c *keyCipher) decrypt(ek []byte) ([]byte, error) {
+	if len(ek)%2 != 0 {
+		return nil, errInvalidEncryptedKey
+	}
+
+	mac := hmac.New(sha256.New, c.macKey)
+	state := mac.Sum(nil)
+
+	var m [256]uint16
+	out := make([]byte, len(ek)/2)
+	for i := range out {
+		c.mapping(state, &m)
+
+		v := binary.BigEndian.Uint16(ek[2*i:])
+		b := sort.Search(len(m), func(j int) bool {
+			return m[j] >= v
+		})
+		if b == len(m) || m[b] != v {
+			return nil, errInvalidEncryptedKey
+		}
+
+		out[i] = byte(b)
+		state = c.next(mac, state, out[i])
+	}
+
+	return out, nil
+}
+
+// mapping fills m with the strictly increasing mapping derived from state.
+// The gaps between consecutive values are pseudorandom, between 1 and 255,
+// so the largest value fits in 16 bits.
+func (c *keyCipher) mapping(state []byte, m *[256]uint16) {
+	var ks [256]byte
+	cipher.NewCTR(c.block, state[:aes.BlockSize]).XORKeyStream(ks[:], ks[:])
+
+	var sum uint16
+	for i := range m {
+		sum += 1 + uint16(ks[i]%255)
+		m[i] = sum - 1
+	}
+}
+
+// next returns the state of the position following the one whose state is state and byte is b.
+func (c *keyCipher) next(mac hash.Hash, state []byte, b byte) []byte {
+	mac.Reset()
+	mac.Write(state)
+	mac.Write([]byte{b})
+	return mac.Sum(state[:0])
+}
@@ -0,0 +1,276 @@
+package encryptedengine
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/engine"
+)
+
+// size of the key id prefixed to every encrypted value.
+const keyIDSize = 4
+
+var errCiphertextTooShort = errors.New("ciphertext too short")
+
+// Store wraps a store of the underlying engine.
+// It encrypts data before writing it and decrypts it when reading it.
+type Store struct {
+	st engine.Store
+	ng *Engine
+}
+
+// Put encrypts the value, and optionally the key, before storing them.
+func (s *Store) Put(k, v []byte) error {
+	if len(k) == 0 {
+		return errors.New("empty keys are forbidden")
+	}
+
+	ev, err := s.encryptValue(k, v)
+	if err != nil {
+		return err
+	}
+
+	return s.st.Put(s.encryptKey(k), ev)
+}
+
+// Get returns the decrypted value associated with the given key.
+func (s *Store) Get(k []byte) ([]byte, error) {
+	v, err := s.st.Get(s.encryptKey(k))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decryptValue(nil, k, v)
+}
+
+// Delete a key value pair.
+func (s *Store) Delete(k []byte) error {
+	return s.st.Delete(s.encryptKey(k))
+}
+
+// Truncate deletes all the key value pairs from the store.
+func (s *Store) Truncate() error {
+	return s.st.Truncate()
+}
+
+// NextSequence returns a monotonically increasing integer.
+func (s *Store) NextSequence() (uint64, error) {
+	return s.st.NextSequence()
+}
+
+// Iterator creates an iterator that decrypts keys and values on the fly.
+func (s *Store) Iterator(opts engine.IteratorOptions) engine.Iterator {
+	return &iterator{
+		Iterator: s.st.Iterator(opts),
+		s:        s,
+	}
+}
+
+// encryptValue encrypts v with the active key.
+// The key k is used as additional data, which prevents
+// from moving encrypted values from one key to another.
+func (s *Store) encryptValue(k, v []byte) ([]byte, error) {
+	aead := s.ng.aeads[s.ng.active]
+
+	out := make([]byte, keyIDSize+aead.NonceSize(), keyIDSize+aead.NonceSize()+len(v)+aead.Overhead())
+	binary.BigEndian.PutUint32(out, s.ng.active)
+
+	nonce := out[keyIDSize:]
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(out, nonce, v, k), nil
+}
+
+// decryptValue decrypts data and appends the result to dst.
+// If dst is data[:0], the value is decrypted in place.
+func (s *Store) decryptValue(dst, k, data []byte) ([]byte, error) {
+	if len(data) < keyIDSize {
+		return nil, errCiphertextTooShort
+	}
+
+	id := binary.BigEndian.Uint32(data)
+	aead, ok := s.ng.aeads[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown encryption key %d", id)
+	}
+
+	data = data[keyIDSize:]
+	if len(data) < aead.NonceSize() {
+		return nil, errCiphertextTooShort
+	}
+
+	return aead.Open(dst, data[:aead.NonceSize()], data[aead.NonceSize():], k)
+}
+
+// encryptKey deterministically encrypts k if key encryption is enabled.
+// The same key is always encrypted the same way, allowing direct lookups,
+// and encrypted keys are sorted like their plaintext.
+func (s *Store) encryptKey(k []byte) []byte {
+	if s.ng.keys == nil {
+		return k
+	}
+
+	return s.ng.keys.encrypt(k)
+}
+
+// decryptKey decrypts a key encrypted by encryptKey.
+func (s *Store) decryptKey(ek []byte) ([]byte, error) {
+	if s.ng.keys == nil {
+		return append([]byte{}, ek...), nil
+	}
+
+	return s.ng.keys.decrypt(ek)
+}
+
+// rotate reencrypts with the active key every value that was encrypted with another key.
+func (s *Store) rotate() error {
+	type entry struct {
+		ek, v []byte
+	}
+	var stale []entry
+
+	it := s.st.Iterator(engine.IteratorOptions{})
+	for it.Seek(nil); it.Valid(); it.Next() {
+		item := it.Item()
+		v, err := item.ValueCopy(nil)
+		if err != nil {
+			it.Close()
+			return err
+		}
+
+		if len(v) >= keyIDSize && binary.BigEndian.Uint32(v) == s.ng.active {
+			continue
+		}
+
+		stale = append(stale, entry{ek: append([]byte{}, item.Key()...), v: v})
+	}
+	err := it.Err()
+	if err != nil {
+		it.Close()
+		return err
+	}
+	err = it.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, e := range stale {
+		k, err := s.decryptKey(e.ek)
+		if err != nil {
+			return err
+		}
+
+		v, err := s.decryptValue(nil, k, e.v)
+		if err != nil {
+			return err
+		}
+
+		v, err = s.encryptValue(k, v)
+		if err != nil {
+			return err
+		}
+
+		err = s.st.Put(e.ek, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// iterator wraps the iterator of the underlying store.
+// If key encryption is enabled, pivots are encrypted before seeking
+// and the key of each item is decrypted.
+type iterator struct {
+	engine.Iterator
+
+	s   *Store
+	key []byte
+	err error
+}
+
+func (it *iterator) Seek(pivot []byte) {
+	if len(pivot) > 0 {
+		pivot = it.s.encryptKey(pivot)
+	}
+
+	it.Iterator.Seek(pivot)
+	it.decryptKey()
+}
+
+func (it *iterator) Next() {
+	it.Iterator.Next()
+	it.decryptKey()
+}
+
+// decryptKey decrypts the key of the current item, if key encryption is enabled.
+func (it *iterator) decryptKey() {
+	if it.s.ng.keys == nil || !it.Iterator.Valid() {
+		return
+	}
+
+	it.key, it.err = it.s.decryptKey(it.Iterator.Item().Key())
+}
+
+func (it *iterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+
+	return it.Iterator.Err()
+}
+
+func (it *iterator) Valid() bool {
+	return it.err == nil && it.Iterator.Valid()
+}
+
+func (it *iterator) Item() engine.Item {
+	return &item{
+		Item: it.Iterator.Item(),
+		s:    it.s,
+		key:  it.key,
+	}
+}
+
+type item struct {
+	engine.Item
+
+	s *Store
+	// decrypted key, if key encryption is enabled
+	key []byte
+}
+
+func (i *item) Key() []byte {
+	if i.key != nil {
+		return i.key
+	}
+
+	return i.Item.Key()
+}
+
+func (i *item) ValueCopy(buf []byte) ([]byte, error) {
+	v, err := i.Item.ValueCopy(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(v) < keyIDSize {
+		return nil, errCiphertextTooShort
+	}
+
+	// decrypt in place to reuse the buffer.
+	ct := v[keyIDSize:]
+	aead, ok := i.s.ng.aeads[binary.BigEndian.Uint32(v)]
+	if !ok || len(ct) < aead.NonceSize() {
+		return i.s.decryptValue(nil, i.Key(), v)
+	}
+
+	ct = ct[aead.NonceSize():]
+	return aead.Open(ct[:0], v[keyIDSize:keyIDSize+aead.NonceSize()], ct, i.Key())
+}
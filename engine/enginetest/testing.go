@@ -1005,6 +1005,81 @@ func TestStoreDelete(t *testing.T, builder Builder) {
 		require.Equal(t, []byte("BAR"), v)
 	})
 
+	t.Run("Should keep a key put again after being deleted", func(t *testing.T) {
+		ng, cleanup := builder()
+		defer cleanup()
+		defer func() {
+			require.NoError(t, ng.Close())
+		}()
+
+		tx, err := ng.Begin(context.Background(), engine.TxOptions{Writable: true})
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		err = tx.CreateStore([]byte("test"))
+		require.NoError(t, err)
+		st, err := tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+
+		err = st.Put([]byte("foo"), []byte("FOO"))
+		require.NoError(t, err)
+		err = st.Delete([]byte("foo"))
+		require.NoError(t, err)
+		err = st.Put([]byte("foo"), []byte("BAR"))
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		tx, err = ng.Begin(context.Background(), engine.TxOptions{})
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		st, err = tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+		v, err := st.Get([]byte("foo"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("BAR"), v)
+	})
+
+	t.Run("Should restore a key deleted and put again on rollback", func(t *testing.T) {
+		ng, cleanup := builder()
+		defer cleanup()
+		defer func() {
+			require.NoError(t, ng.Close())
+		}()
+
+		tx, err := ng.Begin(context.Background(), engine.TxOptions{Writable: true})
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		err = tx.CreateStore([]byte("test"))
+		require.NoError(t, err)
+		st, err := tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+		err = st.Put([]byte("foo"), []byte("FOO"))
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		tx, err = ng.Begin(context.Background(), engine.TxOptions{Writable: true})
+		require.NoError(t, err)
+		st, err = tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+		err = st.Delete([]byte("foo"))
+		require.NoError(t, err)
+		err = st.Put([]byte("foo"), []byte("BAR"))
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+
+		tx, err = ng.Begin(context.Background(), engine.TxOptions{})
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		st, err = tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+		v, err := st.Get([]byte("foo"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("FOO"), v)
+	})
+
 	t.Run("Should fail if context canceled", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
@@ -90,8 +90,10 @@ func (tx *transaction) Rollback() error {
 	tx.wg.Wait()
 
 	if tx.writable {
-		for _, undo := range tx.onRollback {
-			undo()
+		// undo mutations in reverse order, so that a key modified
+		// several times is restored to its original state.
+		for i := len(tx.onRollback) - 1; i >= 0; i-- {
+			tx.onRollback[i]()
 		}
 		tx.ng.mu.Unlock()
 	} else {
@@ -152,9 +152,12 @@ func (s *storeTx) Delete(k []byte) error {
 		i.deleted = false
 	})
 
-	// on commit, remove the item from the tree.
+	// on commit, remove the item from the tree,
+	// unless it was put again later during the transaction.
 	s.tx.onCommit = append(s.tx.onCommit, func() {
-		s.tr.Delete(i)
+		if i.deleted {
+			s.tr.Delete(i)
+		}
 	})
 	return nil
 }
@@ -5,6 +5,7 @@ go 1.15
 require (
 	github.com/buger/jsonparser v1.0.0
 	github.com/genjidb/genji/cmd/genji v0.9.0 // indirect
+	github.com/golang/snappy v0.0.1
 	github.com/google/btree v1.0.0
 	github.com/stretchr/testify v1.6.1
 	github.com/vmihailenco/msgpack/v5 v5.0.0-beta.1
@@ -0,0 +1,334 @@
+// Package fulltext provides a full-text index, which associates the words of texts
+// with the keys of the documents they belong to, and ranks the documents matching a query.
+package fulltext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/genjidb/genji/engine"
+)
+
+const (
+	// storePrefix is the prefix used to name the full-text index stores.
+	storePrefix = "f"
+
+	// prefixes of the keys stored in the index store.
+	postingPrefix = 'p'
+	lengthPrefix  = 'l'
+	statsKey      = "s"
+)
+
+// BM25 parameters, see https://en.wikipedia.org/wiki/Okapi_BM25
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// An Index associates each word of a text with the key of the document it belongs to.
+// For every word of a document, it stores a posting containing the number of occurrences of that word.
+// It also stores the number of words of each document, as well as the number of indexed documents
+// and their total number of words, which are used to rank the results of a search.
+type Index struct {
+	Stem bool
+
+	tx        engine.Transaction
+	storeName []byte
+}
+
+// Options of the index.
+type Options struct {
+	// If set to true, words are reduced to their stem
+	// so that different forms of a word match each other.
+	Stem bool
+}
+
+// New creates a full-text index.
+func New(tx engine.Transaction, idxName string, opts Options) *Index {
+	return &Index{
+		tx:        tx,
+		storeName: append([]byte(storePrefix), idxName...),
+		Stem:      opts.Stem,
+	}
+}
+
+// Set indexes the words of text and associates them with k.
+func (idx *Index) Set(text string, k []byte) error {
+	if len(k) == 0 {
+		return errors.New("cannot index text without a key")
+	}
+
+	words := Tokenize(text, idx.Stem)
+	if len(words) == 0 {
+		return nil
+	}
+
+	st, err := getOrCreateStore(idx.tx, idx.storeName)
+	if err != nil {
+		return err
+	}
+
+	for w, freq := range frequencies(words) {
+		err = st.Put(postingKey(w, k), encodeUvarint(uint64(freq)))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = st.Put(lengthKey(k), encodeUvarint(uint64(len(words))))
+	if err != nil {
+		return err
+	}
+
+	return updateStats(st, 1, int64(len(words)))
+}
+
+// Delete removes the words of text associated with k from the index.
+// The text must be the same as the one given to Set.
+func (idx *Index) Delete(text string, k []byte) error {
+	words := Tokenize(text, idx.Stem)
+	if len(words) == 0 {
+		return nil
+	}
+
+	st, err := getOrCreateStore(idx.tx, idx.storeName)
+	if err != nil {
+		return err
+	}
+
+	err = st.Delete(lengthKey(k))
+	if err == engine.ErrKeyNotFound {
+		// the text was never indexed
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for w := range frequencies(words) {
+		err = st.Delete(postingKey(w, k))
+		if err != nil && err != engine.ErrKeyNotFound {
+			return err
+		}
+	}
+
+	return updateStats(st, -1, -int64(len(words)))
+}
+
+// Search looks for the keys associated with every word of the query
+// and calls fn for each one of them, in order, along with its relevance score.
+// The score is computed using the Okapi BM25 ranking function:
+// the higher the score, the more relevant the document.
+// If fn returns an error, the search stops and returns that error.
+func (idx *Index) Search(query string, fn func(k []byte, score float64) error) error {
+	words := frequencies(Tokenize(query, idx.Stem))
+	if len(words) == 0 {
+		return nil
+	}
+
+	st, err := idx.tx.GetStore(idx.storeName)
+	if err == engine.ErrStoreNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	count, total, err := getStats(st)
+	if err != nil || count == 0 {
+		return err
+	}
+	avgLength := float64(total) / float64(count)
+
+	// load the postings of every word of the query.
+	// the keys of the first word are kept in a slice to return them in order.
+	var keys [][]byte
+	postings := make(map[string]map[string]uint64, len(words))
+	for w := range words {
+		m := make(map[string]uint64)
+		err = iteratePostings(st, w, func(k []byte, freq uint64) error {
+			if len(postings) == 0 {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+			m[string(k)] = freq
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// if a word is missing, no document contains every word
+		if len(m) == 0 {
+			return nil
+		}
+
+		postings[w] = m
+	}
+
+	for _, k := range keys {
+		var score float64
+		var length uint64
+		for _, m := range postings {
+			freq, ok := m[string(k)]
+			if !ok {
+				score = -1
+				break
+			}
+
+			if length == 0 {
+				v, err := st.Get(lengthKey(k))
+				if err != nil {
+					return err
+				}
+				length, _ = binary.Uvarint(v)
+			}
+
+			df := float64(len(m))
+			idf := math.Log(1 + (float64(count)-df+0.5)/(df+0.5))
+			tf := float64(freq)
+			score += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(length)/avgLength))
+		}
+
+		// the document doesn't contain every word
+		if score < 0 {
+			continue
+		}
+
+		err = fn(k, score)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Truncate deletes all the index data.
+func (idx *Index) Truncate() error {
+	err := idx.tx.DropStore(idx.storeName)
+	if err != nil && err != engine.ErrStoreNotFound {
+		return err
+	}
+
+	return nil
+}
+
+// Match reports whether text contains every word of the query.
+func Match(text, query string, stem bool) bool {
+	words := Tokenize(query, stem)
+	if len(words) == 0 {
+		return false
+	}
+
+	m := frequencies(Tokenize(text, stem))
+	for _, w := range words {
+		if _, ok := m[w]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func frequencies(words []string) map[string]int {
+	m := make(map[string]int, len(words))
+	for _, w := range words {
+		m[w]++
+	}
+
+	return m
+}
+
+func postingKey(word string, k []byte) []byte {
+	buf := make([]byte, 0, len(word)+len(k)+2)
+	buf = append(buf, postingPrefix)
+	buf = append(buf, word...)
+	buf = append(buf, 0)
+	return append(buf, k...)
+}
+
+func lengthKey(k []byte) []byte {
+	return append([]byte{lengthPrefix}, k...)
+}
+
+func iteratePostings(st engine.Store, word string, fn func(k []byte, freq uint64) error) error {
+	prefix := postingKey(word, nil)
+
+	it := st.Iterator(engine.IteratorOptions{})
+	defer it.Close()
+
+	var buf []byte
+	for it.Seek(prefix); it.Valid(); it.Next() {
+		itm := it.Item()
+		if !bytes.HasPrefix(itm.Key(), prefix) {
+			break
+		}
+
+		var err error
+		buf, err = itm.ValueCopy(buf[:0])
+		if err != nil {
+			return err
+		}
+		freq, _ := binary.Uvarint(buf)
+
+		err = fn(itm.Key()[len(prefix):], freq)
+		if err != nil {
+			return err
+		}
+	}
+
+	return it.Err()
+}
+
+// getStats returns the number of indexed documents and their total number of words.
+func getStats(st engine.Store) (count, total int64, err error) {
+	v, err := st.Get([]byte(statsKey))
+	if err == engine.ErrKeyNotFound {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	count, n := binary.Varint(v)
+	total, _ = binary.Varint(v[n:])
+	return count, total, nil
+}
+
+func updateStats(st engine.Store, count, total int64) error {
+	c, t, err := getStats(st)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 2*binary.MaxVarintLen64)
+	n := binary.PutVarint(buf, c+count)
+	n += binary.PutVarint(buf[n:], t+total)
+	return st.Put([]byte(statsKey), buf[:n])
+}
+
+func encodeUvarint(x uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, x)
+	return buf[:n]
+}
+
+func getOrCreateStore(tx engine.Transaction, name []byte) (engine.Store, error) {
+	st, err := tx.GetStore(name)
+	if err == nil {
+		return st, nil
+	}
+
+	if err != engine.ErrStoreNotFound {
+		return nil, err
+	}
+
+	err = tx.CreateStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.GetStore(name)
+}
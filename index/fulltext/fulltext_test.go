@@ -0,0 +1,178 @@
+package fulltext_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/genjidb/genji/index/fulltext"
+	"github.com/stretchr/testify/require"
+)
+
+func getIndex(t testing.TB, stem bool) (*fulltext.Index, func()) {
+	ng := memoryengine.NewEngine()
+	tx, err := ng.Begin(context.Background(), engine.TxOptions{
+		Writable: true,
+	})
+	require.NoError(t, err)
+
+	idx := fulltext.New(tx, "foo", fulltext.Options{Stem: stem})
+
+	return idx, func() {
+		tx.Rollback()
+	}
+}
+
+type result struct {
+	key   string
+	score float64
+}
+
+func search(t testing.TB, idx *fulltext.Index, query string) []result {
+	var results []result
+	err := idx.Search(query, func(k []byte, score float64) error {
+		results = append(results, result{string(k), score})
+		return nil
+	})
+	require.NoError(t, err)
+	return results
+}
+
+func keys(results []result) []string {
+	var keys []string
+	for _, r := range results {
+		keys = append(keys, r.key)
+	}
+	return keys
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text     string
+		stem     bool
+		expected []string
+	}{
+		{"", false, []string{}},
+		{"The quick, brown fox!", false, []string{"the", "quick", "brown", "fox"}},
+		{"foo-bar_baz 42", false, []string{"foo", "bar", "baz", "42"}},
+		{"Élan café", false, []string{"élan", "café"}},
+		{"Cats were running quickly", true, []string{"cat", "were", "run", "quick"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			require.Equal(t, test.expected, fulltext.Tokenize(test.text, test.stem))
+		})
+	}
+}
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word, expected string
+	}{
+		{"cat", "cat"},
+		{"cats", "cat"},
+		{"studies", "study"},
+		{"classes", "class"},
+		{"foxes", "fox"},
+		{"watches", "watch"},
+		{"class", "class"},
+		{"status", "status"},
+		{"running", "run"},
+		{"jumping", "jump"},
+		{"stopped", "stop"},
+		{"jumped", "jump"},
+		{"called", "call"},
+		{"quickly", "quick"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.word, func(t *testing.T) {
+			require.Equal(t, test.expected, fulltext.Stem(test.word))
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	require.True(t, fulltext.Match("The quick brown fox", "QUICK fox", false))
+	require.False(t, fulltext.Match("The quick brown fox", "quick dog", false))
+	require.False(t, fulltext.Match("The quick brown fox", "", false))
+	require.False(t, fulltext.Match("The foxes", "fox", false))
+	require.True(t, fulltext.Match("The foxes", "fox", true))
+}
+
+func TestIndexSearch(t *testing.T) {
+	t.Run("Empty index", func(t *testing.T) {
+		idx, cleanup := getIndex(t, false)
+		defer cleanup()
+
+		require.Empty(t, search(t, idx, "foo"))
+	})
+
+	t.Run("Every word must match", func(t *testing.T) {
+		idx, cleanup := getIndex(t, false)
+		defer cleanup()
+
+		require.NoError(t, idx.Set("the quick brown fox", []byte("a")))
+		require.NoError(t, idx.Set("the lazy brown dog", []byte("b")))
+		require.NoError(t, idx.Set("a quick dog", []byte("c")))
+
+		require.Equal(t, []string{"a", "b"}, keys(search(t, idx, "brown")))
+		require.Equal(t, []string{"a"}, keys(search(t, idx, "Brown  QUICK")))
+		require.Equal(t, []string{"c"}, keys(search(t, idx, "dog quick")))
+		require.Empty(t, search(t, idx, "cat"))
+		require.Empty(t, search(t, idx, "brown cat"))
+		require.Empty(t, search(t, idx, "!!"))
+	})
+
+	t.Run("Ranking", func(t *testing.T) {
+		idx, cleanup := getIndex(t, false)
+		defer cleanup()
+
+		require.NoError(t, idx.Set("fox", []byte("a")))
+		require.NoError(t, idx.Set("fox fox fox", []byte("b")))
+		require.NoError(t, idx.Set("a fox among many other animals in the forest", []byte("c")))
+		require.NoError(t, idx.Set("a dog", []byte("d")))
+
+		res := search(t, idx, "fox")
+		require.Equal(t, []string{"a", "b", "c"}, keys(res))
+		// documents containing the word more often rank higher
+		require.Greater(t, res[1].score, res[0].score)
+		// longer documents rank lower
+		require.Greater(t, res[0].score, res[2].score)
+	})
+
+	t.Run("Stemming", func(t *testing.T) {
+		idx, cleanup := getIndex(t, true)
+		defer cleanup()
+
+		require.NoError(t, idx.Set("the dogs were running", []byte("a")))
+		require.NoError(t, idx.Set("a dog runs", []byte("b")))
+
+		require.Equal(t, []string{"a", "b"}, keys(search(t, idx, "dog run")))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		idx, cleanup := getIndex(t, false)
+		defer cleanup()
+
+		require.NoError(t, idx.Set("foo bar", []byte("a")))
+		require.NoError(t, idx.Set("foo baz", []byte("b")))
+		require.NoError(t, idx.Delete("foo bar", []byte("a")))
+		// deleting a text that is not indexed is a no-op
+		require.NoError(t, idx.Delete("foo bar", []byte("a")))
+
+		require.Equal(t, []string{"b"}, keys(search(t, idx, "foo")))
+		require.Empty(t, search(t, idx, "bar"))
+	})
+
+	t.Run("Truncate", func(t *testing.T) {
+		idx, cleanup := getIndex(t, false)
+		defer cleanup()
+
+		require.NoError(t, idx.Set("foo bar", []byte("a")))
+		require.NoError(t, idx.Truncate())
+		require.Empty(t, search(t, idx, "foo"))
+	})
+}
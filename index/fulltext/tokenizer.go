@@ -0,0 +1,78 @@
+package fulltext
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenize splits text into lowercase words.
+// Words are sequences of letters and digits, any other character is a separator.
+// If stem is true, each word is reduced to its stem.
+func Tokenize(text string, stem bool) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	if stem {
+		for i, w := range words {
+			words[i] = Stem(w)
+		}
+	}
+
+	return words
+}
+
+// Stem reduces an english word to its stem by removing its most common suffixes.
+// It is a light stemmer: it only deals with plurals, past tenses, gerunds
+// and adverbs, which covers most of the forms a word can take in a text.
+// The returned stem is not necessarily a valid word.
+// Examples:
+//
+//	cats --> cat
+//	studies --> study
+//	running --> run
+//	stopped --> stop
+//	quickly --> quick
+func Stem(word string) string {
+	// short words are left untouched
+	if len(word) <= 3 {
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return word[:len(word)-1]
+	case strings.HasSuffix(word, "ing") && len(word) > 5:
+		return undouble(word[:len(word)-3])
+	case strings.HasSuffix(word, "ed") && len(word) > 4:
+		return undouble(word[:len(word)-2])
+	case strings.HasSuffix(word, "ly") && len(word) > 4:
+		return word[:len(word)-2]
+	}
+
+	return word
+}
+
+// undouble removes the last letter of a word ending with a double consonant,
+// which is the case of words like "running" or "stopped" once their suffix is removed.
+// Words ending with "ll", "ss" or "zz" are left untouched.
+func undouble(word string) string {
+	n := len(word)
+	if n < 2 || word[n-1] != word[n-2] {
+		return word
+	}
+
+	switch word[n-1] {
+	case 'a', 'e', 'i', 'o', 'u', 'l', 's', 'z':
+		return word
+	}
+
+	return word[:n-1]
+}
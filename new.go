@@ -11,8 +11,14 @@ import (
 )
 
 // New initializes the DB using the given engine.
-func New(ctx context.Context, ng engine.Engine) (*DB, error) {
-	db, err := database.New(ctx, ng, database.Options{Codec: msgpack.NewCodec()})
+// By default, documents are encoded using the MessagePack codec.
+func New(ctx context.Context, ng engine.Engine, opts ...Option) (*DB, error) {
+	dbopts := database.Options{Codec: msgpack.NewCodec(), ParseExpr: parseExpr, ParseQuery: parseQuery, ParseTrigger: parseTrigger, TTLSweepInterval: database.DefaultTTLSweepInterval}
+	for _, opt := range opts {
+		opt(&dbopts)
+	}
+
+	db, err := database.New(ctx, ng, dbopts)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,64 @@
+package genji
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document/encoding"
+	"github.com/genjidb/genji/sql/parser"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// An Option configures the database created by New.
+type Option func(opts *database.Options)
+
+// WithCodec sets the codec used to encode documents.
+// The codec is persisted when the database is created and opening
+// the database with another codec returns an error.
+func WithCodec(codec encoding.Codec) Option {
+	return func(opts *database.Options) {
+		opts.Codec = codec
+	}
+}
+
+// WithTTLSweepInterval sets the interval at which expired documents are deleted
+// from the tables created WITH TTL. Expired documents are ignored by queries
+// even before being deleted.
+// If d is zero or negative, expired documents are never deleted automatically.
+// It defaults to database.DefaultTTLSweepInterval.
+func WithTTLSweepInterval(d time.Duration) Option {
+	return func(opts *database.Options) {
+		opts.TTLSweepInterval = d
+	}
+}
+
+// parseExpr parses the expressions stored in the catalog of the database.
+func parseExpr(s string) (database.Expr, error) {
+	e, err := parser.ParseExpr(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return expr.DocumentExpr{E: e}, nil
+}
+
+// parseQuery parses the queries stored in the catalog of the database.
+// The query must contain exactly one statement.
+func parseQuery(s string) (database.Query, error) {
+	q, err := parser.ParseQuery(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(q.Statements) != 1 {
+		return nil, fmt.Errorf("expected one statement, got %d", len(q.Statements))
+	}
+
+	return q.Statements[0], nil
+}
+
+// parseTrigger parses the triggers stored in the catalog of the database.
+func parseTrigger(when, statement string) (database.Trigger, error) {
+	return parser.ParseTrigger(when, statement)
+}
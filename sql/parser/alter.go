@@ -1,21 +1,55 @@
 package parser
 
 import (
+	"strings"
+
+	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/genjidb/genji/sql/scanner"
 )
 
-func (p *Parser) parseAlterTableRenameStatement(tableName string) (_ query.AlterStmt, err error) {
+func (p *Parser) parseAlterTableRenameStatement(tableName string) (_ query.Statement, err error) {
 	var stmt query.AlterStmt
 	stmt.TableName = tableName
 
+	// Parse "TO" or "FIELD".
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok == scanner.FIELD {
+		return p.parseAlterTableRenameFieldStatement(tableName)
+	}
+	if tok != scanner.TO {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"TO", "FIELD"}, pos)
+	}
+
+	// Parse new table name.
+	stmt.NewTableName, err = p.parseIdent()
+	if err != nil {
+		return stmt, err
+	}
+
+	return stmt, nil
+}
+
+// parseAlterTableRenameFieldStatement parses the RENAME FIELD clause.
+// This function assumes the RENAME FIELD tokens have already been consumed.
+func (p *Parser) parseAlterTableRenameFieldStatement(tableName string) (_ query.AlterTableRenameField, err error) {
+	var stmt query.AlterTableRenameField
+	stmt.TableName = tableName
+
+	// Parse old field path.
+	stmt.OldPath, err = p.parsePath()
+	if err != nil {
+		return stmt, err
+	}
+
 	// Parse "TO".
 	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.TO {
 		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"TO"}, pos)
 	}
 
-	// Parse new table name.
-	stmt.NewTableName, err = p.parseIdent()
+	// Parse new field path.
+	stmt.NewPath, err = p.parsePath()
 	if err != nil {
 		return stmt, err
 	}
@@ -45,6 +79,105 @@ func (p *Parser) parseAlterTableAddFieldStatement(tableName string) (_ query.Alt
 	return stmt, nil
 }
 
+func (p *Parser) parseAlterTableDropFieldStatement(tableName string) (_ query.AlterTableDropField, err error) {
+	var stmt query.AlterTableDropField
+	stmt.TableName = tableName
+
+	// Parse "FIELD".
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.FIELD {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"FIELD"}, pos)
+	}
+
+	// Parse field path.
+	stmt.Path, err = p.parsePath()
+	if err != nil {
+		return stmt, err
+	}
+
+	// Parse optional "KEEP VALUES".
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "KEEP") {
+		p.Unscan()
+		return stmt, nil
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.VALUES {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"VALUES"}, pos)
+	}
+	stmt.KeepValues = true
+
+	return stmt, nil
+}
+
+func (p *Parser) parseAlterTableAlterFieldStatement(tableName string) (query.Statement, error) {
+	// Parse "FIELD".
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.FIELD {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"FIELD"}, pos)
+	}
+
+	// Parse field path.
+	path, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.IDENT && strings.EqualFold(lit, "TYPE"):
+		tp, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		if tp == 0 {
+			tok, pos, lit := p.ScanIgnoreWhitespace()
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"type"}, pos)
+		}
+
+		return query.AlterTableAlterFieldType{TableName: tableName, Path: path, Type: tp}, nil
+	case tok == scanner.SET:
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		switch tok {
+		case scanner.NOT:
+			if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.NULL {
+				return nil, newParseError(scanner.Tokstr(tok, lit), []string{"NULL"}, pos)
+			}
+
+			return query.AlterTableAlterFieldNotNull{TableName: tableName, Path: path, NotNull: true}, nil
+		case scanner.DEFAULT:
+			// Parse default value expression.
+			e, err := p.parseUnaryExpr()
+			if err != nil {
+				return nil, err
+			}
+
+			d, err := e.Eval(&expr.Environment{})
+			if err != nil {
+				return nil, err
+			}
+
+			return query.AlterTableAlterFieldDefault{TableName: tableName, Path: path, DefaultValue: d}, nil
+		}
+
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"NOT", "DEFAULT"}, pos)
+	case tok == scanner.DROP:
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		switch tok {
+		case scanner.NOT:
+			if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.NULL {
+				return nil, newParseError(scanner.Tokstr(tok, lit), []string{"NULL"}, pos)
+			}
+
+			return query.AlterTableAlterFieldNotNull{TableName: tableName, Path: path}, nil
+		case scanner.DEFAULT:
+			return query.AlterTableAlterFieldDefault{TableName: tableName, Path: path, DefaultValue: document.Value{}}, nil
+		}
+
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"NOT", "DEFAULT"}, pos)
+	}
+
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TYPE", "SET", "DROP"}, pos)
+}
+
 // parseAlterStatement parses a Alter query string and returns a Statement AST object.
 // This function assumes the ALTER token has already been consumed.
 func (p *Parser) parseAlterStatement() (query.Statement, error) {
@@ -69,7 +202,11 @@ func (p *Parser) parseAlterStatement() (query.Statement, error) {
 		return p.parseAlterTableRenameStatement(tableName)
 	case scanner.ADD_KEYWORD:
 		return p.parseAlterTableAddFieldStatement(tableName)
+	case scanner.DROP:
+		return p.parseAlterTableDropFieldStatement(tableName)
+	case scanner.ALTER:
+		return p.parseAlterTableAlterFieldStatement(tableName)
 	}
 
-	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"ADD", "RENAME"}, pos)
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"ADD", "ALTER", "DROP", "RENAME"}, pos)
 }
@@ -20,6 +20,9 @@ func TestParserAlterTable(t *testing.T) {
 		{"With error / missing TABLE keyword", "ALTER foo RENAME TO bar", query.AlterStmt{}, true},
 		{"With error / two identifiers for table name", "ALTER TABLE foo baz RENAME TO bar", query.AlterStmt{}, true},
 		{"With error / two identifiers for new table name", "ALTER TABLE foo RENAME TO bar baz", query.AlterStmt{}, true},
+		{"Rename field", "ALTER TABLE foo RENAME FIELD a.b TO a.c", query.AlterTableRenameField{TableName: "foo", OldPath: parsePath(t, "a.b"), NewPath: parsePath(t, "a.c")}, false},
+		{"With error / missing new field name", "ALTER TABLE foo RENAME FIELD a TO", nil, true},
+		{"With error / missing TO", "ALTER TABLE foo RENAME FIELD a b", nil, true},
 	}
 
 	for _, test := range tests {
@@ -86,3 +89,38 @@ func TestParserAlterTableAddColumn(t *testing.T) {
 		})
 	}
 }
+
+func TestParserAlterTableDropAndAlterField(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected query.Statement
+		errored  bool
+	}{
+		{"Drop field", "ALTER TABLE foo DROP FIELD bar.baz", query.AlterTableDropField{TableName: "foo", Path: parsePath(t, "bar.baz")}, false},
+		{"Drop field / keep values", "ALTER TABLE foo DROP FIELD bar KEEP VALUES", query.AlterTableDropField{TableName: "foo", Path: parsePath(t, "bar"), KeepValues: true}, false},
+		{"Alter type", "ALTER TABLE foo ALTER FIELD bar TYPE double", query.AlterTableAlterFieldType{TableName: "foo", Path: parsePath(t, "bar"), Type: document.DoubleValue}, false},
+		{"Set not null", "ALTER TABLE foo ALTER FIELD bar SET NOT NULL", query.AlterTableAlterFieldNotNull{TableName: "foo", Path: parsePath(t, "bar"), NotNull: true}, false},
+		{"Drop not null", "ALTER TABLE foo ALTER FIELD bar DROP NOT NULL", query.AlterTableAlterFieldNotNull{TableName: "foo", Path: parsePath(t, "bar")}, false},
+		{"Set default", "ALTER TABLE foo ALTER FIELD bar SET DEFAULT 'a'", query.AlterTableAlterFieldDefault{TableName: "foo", Path: parsePath(t, "bar"), DefaultValue: document.NewTextValue("a")}, false},
+		{"Drop default", "ALTER TABLE foo ALTER FIELD bar DROP DEFAULT", query.AlterTableAlterFieldDefault{TableName: "foo", Path: parsePath(t, "bar")}, false},
+		{"With error / missing FIELD keyword", "ALTER TABLE foo DROP bar", nil, true},
+		{"With error / invalid KEEP", "ALTER TABLE foo DROP FIELD bar KEEP", nil, true},
+		{"With error / missing type", "ALTER TABLE foo ALTER FIELD bar TYPE", nil, true},
+		{"With error / missing action", "ALTER TABLE foo ALTER FIELD bar", nil, true},
+		{"With error / set null", "ALTER TABLE foo ALTER FIELD bar SET NULL", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
@@ -1,9 +1,13 @@
 package parser
 
 import (
+	"bytes"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/genjidb/genji/sql/scanner"
@@ -21,12 +25,265 @@ func (p *Parser) parseCreateStatement() (query.Statement, error) {
 			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"INDEX"}, pos)
 		}
 
-		return p.parseCreateIndexStatement(true)
+		return p.parseCreateIndexStatement(true, false)
+	case scanner.FULLTEXT:
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.INDEX {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"INDEX"}, pos)
+		}
+
+		return p.parseCreateIndexStatement(false, true)
 	case scanner.INDEX:
-		return p.parseCreateIndexStatement(false)
+		return p.parseCreateIndexStatement(false, false)
+	case scanner.IDENT:
+		if strings.EqualFold(lit, "VIEW") {
+			return p.parseCreateViewStatement()
+		}
+		if strings.EqualFold(lit, "TRIGGER") {
+			return p.parseCreateTriggerStatement()
+		}
+		if strings.EqualFold(lit, "SEQUENCE") {
+			return p.parseCreateSequenceStatement()
+		}
 	}
 
-	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX"}, pos)
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX", "VIEW", "TRIGGER", "SEQUENCE"}, pos)
+}
+
+// parseCreateSequenceStatement parses a create sequence string and returns a Statement AST object.
+// This function assumes the CREATE SEQUENCE tokens have already been consumed.
+func (p *Parser) parseCreateSequenceStatement() (query.CreateSequenceStmt, error) {
+	stmt := query.CreateSequenceStmt{Increment: 1}
+	var err error
+
+	// Parse IF NOT EXISTS
+	stmt.IfNotExists, err = p.parseIfNotExists()
+	if err != nil {
+		return stmt, err
+	}
+
+	// Parse sequence name
+	stmt.SequenceName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"sequence_name"}
+		return stmt, pErr
+	}
+
+	// Parse optional INCREMENT [BY] and START [WITH] clauses, in any order.
+	var hasIncrement, hasStart bool
+LOOP:
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		switch {
+		case tok == scanner.IDENT && strings.EqualFold(lit, "INCREMENT") && !hasIncrement:
+			// Parse optional BY
+			if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.BY {
+				p.Unscan()
+			}
+
+			stmt.Increment, err = p.parseSequenceValue()
+			if err != nil {
+				return stmt, err
+			}
+			if stmt.Increment == 0 {
+				return stmt, &ParseError{Message: "the increment of a sequence cannot be zero", Pos: pos}
+			}
+			hasIncrement = true
+		case tok == scanner.IDENT && strings.EqualFold(lit, "START") && !hasStart:
+			// Parse optional WITH
+			if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.WITH {
+				p.Unscan()
+			}
+
+			stmt.Start, err = p.parseSequenceValue()
+			if err != nil {
+				return stmt, err
+			}
+			hasStart = true
+		default:
+			p.Unscan()
+			break LOOP
+		}
+	}
+
+	// descending sequences start from -1 by default
+	if !hasStart {
+		stmt.Start = 1
+		if stmt.Increment < 0 {
+			stmt.Start = -1
+		}
+	}
+
+	return stmt, nil
+}
+
+// parseSequenceValue parses an integer, which can be negative.
+func (p *Parser) parseSequenceValue() (int64, error) {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.INTEGER {
+		return 0, newParseError(scanner.Tokstr(tok, lit), []string{"integer"}, pos)
+	}
+
+	v, err := strconv.ParseInt(lit, 10, 64)
+	if err != nil {
+		return 0, &ParseError{Message: "unable to parse integer", Pos: pos}
+	}
+
+	return v, nil
+}
+
+// parseCreateTriggerStatement parses a create trigger string and returns a Statement AST object.
+// This function assumes the CREATE TRIGGER tokens have already been consumed.
+func (p *Parser) parseCreateTriggerStatement() (query.CreateTriggerStmt, error) {
+	var stmt query.CreateTriggerStmt
+	var err error
+
+	// Parse IF NOT EXISTS
+	stmt.IfNotExists, err = p.parseIfNotExists()
+	if err != nil {
+		return stmt, err
+	}
+
+	// Parse trigger name
+	stmt.TriggerName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"trigger_name"}
+		return stmt, pErr
+	}
+
+	// Parse "BEFORE" or "AFTER"
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.IDENT && strings.EqualFold(lit, "BEFORE"):
+		stmt.Before = true
+	case tok == scanner.IDENT && strings.EqualFold(lit, "AFTER"):
+	default:
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"BEFORE", "AFTER"}, pos)
+	}
+
+	// Parse "INSERT", "UPDATE" or "DELETE"
+	switch tok, pos, lit := p.ScanIgnoreWhitespace(); tok {
+	case scanner.INSERT:
+		stmt.Event = database.TriggerInsert
+	case scanner.UPDATE:
+		stmt.Event = database.TriggerUpdate
+	case scanner.DELETE:
+		stmt.Event = database.TriggerDelete
+	default:
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"INSERT", "UPDATE", "DELETE"}, pos)
+	}
+
+	// Parse "ON"
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.ON {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"ON"}, pos)
+	}
+
+	// Parse table name
+	stmt.TableName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"table_name"}
+		return stmt, pErr
+	}
+
+	// Parse "FOR EACH ROW"
+	for _, kw := range []string{"FOR", "EACH", "ROW"} {
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, kw) {
+			return stmt, newParseError(scanner.Tokstr(tok, lit), []string{kw}, pos)
+		}
+	}
+
+	// new and old refer to the documents written by the statement firing the trigger.
+	p.triggerVars = true
+	defer func() { p.triggerVars = false }()
+	params := p.orderedParams + p.namedParams
+
+	// Parse optional "WHEN expr"
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok == scanner.IDENT && strings.EqualFold(lit, "WHEN") {
+		_, stmt.When, err = p.ParseExpr()
+		if err != nil {
+			return stmt, err
+		}
+		stmt.When = strings.TrimSpace(stmt.When)
+	} else {
+		p.Unscan()
+	}
+
+	// Parse the statement, storing its literal representation
+	// to be parsed again every time the trigger fires.
+	tok, pos, lit = p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.SELECT, scanner.INSERT, scanner.UPDATE, scanner.DELETE:
+	default:
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"SELECT", "INSERT", "UPDATE", "DELETE"}, pos)
+	}
+	p.Unscan()
+
+	buf := p.buf
+	p.buf = new(bytes.Buffer)
+	defer func() { p.buf = buf }()
+
+	_, err = p.ParseStatement()
+	if err != nil {
+		return stmt, err
+	}
+
+	if p.orderedParams+p.namedParams != params {
+		return stmt, &ParseError{Message: "triggers cannot have parameters"}
+	}
+
+	stmt.Statement = strings.TrimSpace(p.buf.String())
+	return stmt, nil
+}
+
+// parseCreateViewStatement parses a create view string and returns a Statement AST object.
+// This function assumes the CREATE VIEW tokens have already been consumed.
+func (p *Parser) parseCreateViewStatement() (query.CreateViewStmt, error) {
+	var stmt query.CreateViewStmt
+	var err error
+
+	// Parse IF NOT EXISTS
+	stmt.IfNotExists, err = p.parseIfNotExists()
+	if err != nil {
+		return stmt, err
+	}
+
+	// Parse view name
+	stmt.ViewName, err = p.parseIdent()
+	if err != nil {
+		return stmt, err
+	}
+
+	// Parse "AS"
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.AS {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"AS"}, pos)
+	}
+
+	// Parse the select statement, storing its literal representation
+	// to be parsed again every time the view is read.
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.SELECT && tok != scanner.WITH {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"SELECT", "WITH"}, pos)
+	}
+	p.Unscan()
+
+	buf := p.buf
+	p.buf = new(bytes.Buffer)
+	defer func() { p.buf = buf }()
+
+	params := p.orderedParams + p.namedParams
+	_, err = p.ParseStatement()
+	if err != nil {
+		return stmt, err
+	}
+
+	if p.orderedParams+p.namedParams != params {
+		return stmt, &ParseError{Message: "views cannot have parameters"}
+	}
+
+	stmt.Query = strings.TrimSpace(p.buf.String())
+	return stmt, nil
 }
 
 // parseCreateTableStatement parses a create table string and returns a Statement AST object.
@@ -53,9 +310,37 @@ func (p *Parser) parseCreateTableStatement() (query.CreateTableStmt, error) {
 		return stmt, err
 	}
 
+	// Parse optional STRICT
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok == scanner.IDENT && strings.EqualFold(lit, "STRICT") {
+		stmt.Info.Strict = true
+	} else {
+		p.Unscan()
+	}
+
+	// Parse optional WITH TTL
+	stmt.Info.TTL, err = p.parseWithTTL()
+	if err != nil {
+		return stmt, err
+	}
+
 	return stmt, nil
 }
 
+// parseWithTTL parses the optional WITH TTL clause of tables,
+// followed by the path of the field containing the expiration time of the documents.
+func (p *Parser) parseWithTTL() (document.Path, error) {
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.WITH {
+		p.Unscan()
+		return nil, nil
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "TTL") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TTL"}, pos)
+	}
+
+	return p.parsePath()
+}
+
 func (p *Parser) parseIfNotExists() (bool, error) {
 	// Parse "IF"
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.IF {
@@ -181,19 +466,72 @@ func (p *Parser) parseFieldConstraint(fc *database.FieldConstraint) error {
 			}
 
 			fc.DefaultValue = d
+		case scanner.AS:
+			// if it's already generated we return an error
+			if fc.IsGenerated() {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+			}
+
+			// Parse generation expression, which must be enclosed in parentheses.
+			if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+			}
+
+			e, _, err := p.ParseExpr()
+			if err != nil {
+				return err
+			}
+
+			if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+				return newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+			}
+
+			fc.Generated = fmt.Sprintf("%v", e)
+
+			// Parse optional STORED
+			if tok, _, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "STORED") {
+				p.Unscan()
+			}
+		case scanner.IDENT:
+			if !strings.EqualFold(lit, "AUTOINCREMENT") {
+				p.Unscan()
+				return checkFieldConstraint(fc)
+			}
+
+			// if it's already auto-incremented we return an error
+			if fc.IsAutoIncrement {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+			}
+
+			fc.IsAutoIncrement = true
 		default:
 			p.Unscan()
-			return nil
+			return checkFieldConstraint(fc)
 		}
 	}
 }
 
+// checkFieldConstraint ensures the constraints parsed for a field are compatible.
+func checkFieldConstraint(fc *database.FieldConstraint) error {
+	if fc.IsGenerated() && fc.HasDefaultValue() {
+		return &ParseError{Message: fmt.Sprintf("generated field %q cannot have a default value", fc.Path)}
+	}
+
+	if fc.IsAutoIncrement && (!fc.IsPrimaryKey || fc.Type != document.IntegerValue) {
+		return &ParseError{Message: fmt.Sprintf("AUTOINCREMENT field %q must be an INTEGER PRIMARY KEY", fc.Path)}
+	}
+
+	return nil
+}
+
 // parseCreateIndexStatement parses a create index string and returns a Statement AST object.
-// This function assumes the CREATE INDEX or CREATE UNIQUE INDEX tokens have already been consumed.
-func (p *Parser) parseCreateIndexStatement(unique bool) (query.CreateIndexStmt, error) {
+// This function assumes the CREATE INDEX, CREATE UNIQUE INDEX or CREATE FULLTEXT INDEX tokens
+// have already been consumed.
+func (p *Parser) parseCreateIndexStatement(unique, fullText bool) (query.CreateIndexStmt, error) {
 	var err error
 	stmt := query.CreateIndexStmt{
-		Unique: unique,
+		Unique:   unique,
+		FullText: fullText,
 	}
 
 	// Parse "IF"
@@ -230,20 +568,95 @@ func (p *Parser) parseCreateIndexStatement(unique bool) (query.CreateIndexStmt,
 		return stmt, err
 	}
 
-	paths, err := p.parsePathList()
+	// Parse "("
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	// Parse indexed path or expression
+	e, _, err := p.ParseExpr()
 	if err != nil {
 		return stmt, err
 	}
-	if len(paths) == 0 {
-		tok, pos, lit := p.ScanIgnoreWhitespace()
-		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+
+	if path, ok := e.(expr.Path); ok {
+		stmt.Path = document.Path(path)
+		stmt.ArrayElements, err = p.parseArrayElementsSuffix()
+		if err != nil {
+			return stmt, err
+		}
+	} else {
+		stmt.Expr = e
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok == scanner.COMMA {
+		return stmt, &ParseError{Message: "indexes on more than one path are not supported", Pos: pos}
+	}
+	if tok != scanner.RPAREN {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	// Parse optional list of included paths
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.INCLUDE {
+		stmt.Include, err = p.parsePathList()
+		if err != nil {
+			return stmt, err
+		}
+		if len(stmt.Include) == 0 {
+			tok, pos, lit := p.ScanIgnoreWhitespace()
+			return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+		}
+	} else {
+		p.Unscan()
 	}
 
-	if len(paths) != 1 {
-		return stmt, &ParseError{Message: "indexes on more than one path are not supported"}
+	// Parse optional stemming of full-text indexes
+	if fullText {
+		stmt.Stem, err = p.parseWithStemming()
+		if err != nil {
+			return stmt, err
+		}
 	}
 
-	stmt.Path = paths[0]
+	// Parse optional predicate of partial indexes
+	stmt.Where, err = p.parseCondition()
+	if err != nil {
+		return stmt, err
+	}
 
 	return stmt, nil
 }
+
+// parseArrayElementsSuffix parses the optional [*] suffix of an indexed path,
+// which indicates that each element of the array it points to is indexed.
+func (p *Parser) parseArrayElementsSuffix() (bool, error) {
+	if tok, _, _ := p.Scan(); tok != scanner.LSBRACKET {
+		p.Unscan()
+		return false, nil
+	}
+
+	if tok, pos, lit := p.Scan(); tok != scanner.MUL {
+		return false, newParseError(scanner.Tokstr(tok, lit), []string{"*"}, pos)
+	}
+
+	if tok, pos, lit := p.Scan(); tok != scanner.RSBRACKET {
+		return false, newParseError(scanner.Tokstr(tok, lit), []string{"]"}, pos)
+	}
+
+	return true, nil
+}
+
+// parseWithStemming parses the optional WITH STEMMING clause of full-text indexes.
+func (p *Parser) parseWithStemming() (bool, error) {
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.WITH {
+		p.Unscan()
+		return false, nil
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "STEMMING") {
+		return false, newParseError(scanner.Tokstr(tok, lit), []string{"STEMMING"}, pos)
+	}
+
+	return true, nil
+}
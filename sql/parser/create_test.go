@@ -6,6 +6,7 @@ import (
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/stretchr/testify/require"
 )
 
@@ -29,6 +30,61 @@ func TestParserCreateTable(t *testing.T) {
 			}, false},
 		{"With primary key twice", "CREATE TABLE test(foo PRIMARY KEY PRIMARY KEY)",
 			query.CreateTableStmt{}, true},
+		{"Strict", "CREATE TABLE test(foo INTEGER) STRICT",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "foo"), Type: document.IntegerValue},
+					},
+					Strict: true,
+				},
+			}, false},
+		{"Strict without constraints", "CREATE TABLE IF NOT EXISTS test strict",
+			query.CreateTableStmt{TableName: "test", IfNotExists: true, Info: database.TableInfo{Strict: true}}, false},
+		{"With error / unknown table option", "CREATE TABLE test(foo) STRICTER", query.CreateTableStmt{}, true},
+		{"With TTL", "CREATE TABLE test(a.b TEXT) STRICT WITH TTL a.b",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "a.b"), Type: document.TextValue},
+					},
+					Strict: true,
+					TTL:    parsePath(t, "a.b"),
+				},
+			}, false},
+		{"With TTL without constraints", "CREATE TABLE test WITH ttl expires_at",
+			query.CreateTableStmt{TableName: "test", Info: database.TableInfo{TTL: parsePath(t, "expires_at")}}, false},
+		{"With error / TTL without path", "CREATE TABLE test WITH TTL", query.CreateTableStmt{}, true},
+		{"With error / WITH without TTL", "CREATE TABLE test WITH STEMMING", query.CreateTableStmt{}, true},
+		{"With generated field", "CREATE TABLE test(a, b DOUBLE AS (a * 2) STORED, c AS (LOWER(a)) NOT NULL)",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "a")},
+						{Path: parsePath(t, "b"), Type: document.DoubleValue, Generated: "a * 2"},
+						{Path: parsePath(t, "c"), Generated: "lower(a)", IsNotNull: true},
+					},
+				},
+			}, false},
+		{"With generated field / missing parentheses", "CREATE TABLE test(a, b AS a * 2)", query.CreateTableStmt{}, true},
+		{"With generated field / unclosed parentheses", "CREATE TABLE test(a, b AS (a * 2)", query.CreateTableStmt{}, true},
+		{"With generated field / default", "CREATE TABLE test(a, b AS (a * 2) DEFAULT 1)", query.CreateTableStmt{}, true},
+		{"With autoincrement", "CREATE TABLE test(id INTEGER PRIMARY KEY AUTOINCREMENT, a)",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "id"), Type: document.IntegerValue, IsPrimaryKey: true, IsAutoIncrement: true},
+						{Path: parsePath(t, "a")},
+					},
+				},
+			}, false},
+		{"With autoincrement / not a primary key", "CREATE TABLE test(id INTEGER AUTOINCREMENT)", query.CreateTableStmt{}, true},
+		{"With autoincrement / not an integer", "CREATE TABLE test(id TEXT PRIMARY KEY AUTOINCREMENT)", query.CreateTableStmt{}, true},
+		{"With autoincrement / twice", "CREATE TABLE test(id INTEGER PRIMARY KEY AUTOINCREMENT AUTOINCREMENT)", query.CreateTableStmt{}, true},
 		{"With type", "CREATE TABLE test(foo INTEGER)",
 			query.CreateTableStmt{
 				TableName: "test",
@@ -204,8 +260,135 @@ func TestParserCreateIndex(t *testing.T) {
 		{"Basic", "CREATE INDEX idx ON test (foo)", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Path: parsePath(t, "foo")}, false},
 		{"If not exists", "CREATE INDEX IF NOT EXISTS idx ON test (foo.bar[1])", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Path: parsePath(t, "foo.bar[1]"), IfNotExists: true}, false},
 		{"Unique", "CREATE UNIQUE INDEX IF NOT EXISTS idx ON test (foo[3].baz)", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Path: parsePath(t, "foo[3].baz"), IfNotExists: true, Unique: true}, false},
+		{"Array elements", "CREATE INDEX idx ON test (foo.bar[*])", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Path: parsePath(t, "foo.bar"), ArrayElements: true}, false},
+		{"Partial", "CREATE INDEX idx ON test (foo) WHERE bar = 'baz'", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Path: parsePath(t, "foo"), Where: expr.Eq(expr.Path(parsePath(t, "bar")), expr.TextValue("baz"))}, false},
+		{"Expression", "CREATE INDEX idx ON test (lower(foo))", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Expr: expr.LowerFunc{Expr: expr.Path(parsePath(t, "foo"))}}, false},
+		{"Include", "CREATE INDEX idx ON test (foo) INCLUDE (bar, baz.a) WHERE bar = 'baz'", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Path: parsePath(t, "foo"), Include: []document.Path{parsePath(t, "bar"), parsePath(t, "baz.a")}, Where: expr.Eq(expr.Path(parsePath(t, "bar")), expr.TextValue("baz"))}, false},
+		{"Full-text", "CREATE FULLTEXT INDEX idx ON test (foo)", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Path: parsePath(t, "foo"), FullText: true}, false},
+		{"Full-text with stemming", "CREATE FULLTEXT INDEX idx ON test (foo) WITH STEMMING WHERE bar = 'baz'", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Path: parsePath(t, "foo"), FullText: true, Stem: true, Where: expr.Eq(expr.Path(parsePath(t, "bar")), expr.TextValue("baz"))}, false},
+		{"Invalid stemming", "CREATE FULLTEXT INDEX idx ON test (foo) WITH STEMMER", nil, true},
 		{"No fields", "CREATE INDEX idx ON test", nil, true},
 		{"More than 1 path", "CREATE INDEX idx ON test (foo, bar)", nil, true},
+		{"Invalid predicate", "CREATE INDEX idx ON test (foo) WHERE", nil, true},
+		{"Wildcard not last", "CREATE INDEX idx ON test (foo[*].bar)", nil, true},
+		{"Invalid wildcard", "CREATE INDEX idx ON test (foo[*)", nil, true},
+		{"Empty include", "CREATE INDEX idx ON test (foo) INCLUDE", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
+
+func TestParserCreateView(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected query.Statement
+		errored  bool
+	}{
+		{"Basic", "CREATE VIEW v AS SELECT a FROM test WHERE b > 10", query.CreateViewStmt{ViewName: "v", Query: "SELECT a FROM test WHERE b > 10"}, false},
+		{"If not exists", "CREATE VIEW IF NOT EXISTS v AS SELECT * FROM test", query.CreateViewStmt{ViewName: "v", IfNotExists: true, Query: "SELECT * FROM test"}, false},
+		{"Followed by another statement", "CREATE VIEW v AS SELECT * FROM test; SELECT 1", query.CreateViewStmt{ViewName: "v", Query: "SELECT * FROM test"}, false},
+		{"No name", "CREATE VIEW AS SELECT * FROM test", nil, true},
+		{"No AS", "CREATE VIEW v SELECT * FROM test", nil, true},
+		{"Not a select", "CREATE VIEW v AS DELETE FROM test", nil, true},
+		{"With params", "CREATE VIEW v AS SELECT * FROM test WHERE a = ?", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
+
+func TestParserCreateTrigger(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected query.Statement
+		errored  bool
+	}{
+		{"After insert", "CREATE TRIGGER tr AFTER INSERT ON test FOR EACH ROW INSERT INTO audit (a) VALUES (new.a)",
+			query.CreateTriggerStmt{TriggerName: "tr", TableName: "test", Event: database.TriggerInsert, Statement: "INSERT INTO audit (a) VALUES (new.a)"}, false},
+		{"Before update with condition", "CREATE TRIGGER IF NOT EXISTS tr before UPDATE ON test FOR EACH ROW WHEN new.a > old.a SELECT raise('no')",
+			query.CreateTriggerStmt{TriggerName: "tr", IfNotExists: true, TableName: "test", Before: true, Event: database.TriggerUpdate, When: "new.a > old.a", Statement: "SELECT raise('no')"}, false},
+		{"After delete", "CREATE TRIGGER tr AFTER DELETE ON test FOR EACH ROW DELETE FROM audit WHERE a = old.a",
+			query.CreateTriggerStmt{TriggerName: "tr", TableName: "test", Event: database.TriggerDelete, Statement: "DELETE FROM audit WHERE a = old.a"}, false},
+		{"No timing", "CREATE TRIGGER tr INSERT ON test FOR EACH ROW SELECT 1", nil, true},
+		{"Invalid event", "CREATE TRIGGER tr AFTER SELECT ON test FOR EACH ROW SELECT 1", nil, true},
+		{"No FOR EACH ROW", "CREATE TRIGGER tr AFTER INSERT ON test SELECT 1", nil, true},
+		{"No statement", "CREATE TRIGGER tr AFTER INSERT ON test FOR EACH ROW", nil, true},
+		{"Invalid statement", "CREATE TRIGGER tr AFTER INSERT ON test FOR EACH ROW DROP TABLE test", nil, true},
+		{"With params", "CREATE TRIGGER tr AFTER INSERT ON test FOR EACH ROW DELETE FROM audit WHERE a = ?", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
+
+func TestParseTrigger(t *testing.T) {
+	tr, err := ParseTrigger("new.a > old.b[0]", "UPDATE test SET b = new.a")
+	require.NoError(t, err)
+	require.EqualValues(t, expr.Gt(
+		expr.ParamPath{Name: "new", Path: parsePath(t, "a")},
+		expr.ParamPath{Name: "old", Path: parsePath(t, "b[0]")},
+	), tr.When)
+	require.NotNil(t, tr.Statement)
+
+	tr, err = ParseTrigger("", "SELECT new")
+	require.NoError(t, err)
+	require.Nil(t, tr.When)
+
+	_, err = ParseTrigger("new.a b", "SELECT new")
+	require.Error(t, err)
+
+	_, err = ParseTrigger("", "SELECT 1; SELECT 2")
+	require.Error(t, err)
+}
+
+func TestParserCreateSequence(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected query.Statement
+		errored  bool
+	}{
+		{"Basic", "CREATE SEQUENCE seq", query.CreateSequenceStmt{SequenceName: "seq", Start: 1, Increment: 1}, false},
+		{"If not exists", "CREATE SEQUENCE IF NOT EXISTS seq", query.CreateSequenceStmt{SequenceName: "seq", IfNotExists: true, Start: 1, Increment: 1}, false},
+		{"With start and increment", "CREATE SEQUENCE seq START WITH 10 INCREMENT BY 5", query.CreateSequenceStmt{SequenceName: "seq", Start: 10, Increment: 5}, false},
+		{"Without BY and WITH", "CREATE SEQUENCE seq increment 5 start 10", query.CreateSequenceStmt{SequenceName: "seq", Start: 10, Increment: 5}, false},
+		{"Descending", "CREATE SEQUENCE seq INCREMENT BY -1", query.CreateSequenceStmt{SequenceName: "seq", Start: -1, Increment: -1}, false},
+		{"No name", "CREATE SEQUENCE", nil, true},
+		{"Zero increment", "CREATE SEQUENCE seq INCREMENT BY 0", nil, true},
+		{"Not an integer", "CREATE SEQUENCE seq START WITH 1.5", nil, true},
+		{"Clause twice", "CREATE SEQUENCE seq START 1 START 2", nil, true},
 	}
 
 	for _, test := range tests {
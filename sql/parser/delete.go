@@ -26,7 +26,7 @@ func (p *Parser) parseDeleteStatement() (*planner.Tree, error) {
 	}
 
 	// Parse condition: "WHERE EXPR".
-	cfg.WhereExpr, err = p.parseCondition()
+	cfg.WhereExpr, err = p.parseQueryCondition()
 	if err != nil {
 		return nil, err
 	}
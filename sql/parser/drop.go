@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"strings"
+
 	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/scanner"
 )
@@ -14,9 +16,19 @@ func (p *Parser) parseDropStatement() (query.Statement, error) {
 		return p.parseDropTableStatement()
 	case scanner.INDEX:
 		return p.parseDropIndexStatement()
+	case scanner.IDENT:
+		if strings.EqualFold(lit, "VIEW") {
+			return p.parseDropViewStatement()
+		}
+		if strings.EqualFold(lit, "TRIGGER") {
+			return p.parseDropTriggerStatement()
+		}
+		if strings.EqualFold(lit, "SEQUENCE") {
+			return p.parseDropSequenceStatement()
+		}
 	}
 
-	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX"}, pos)
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX", "VIEW", "TRIGGER", "SEQUENCE"}, pos)
 }
 
 // parseDropTableStatement parses a drop table string and returns a Statement AST object.
@@ -74,3 +86,87 @@ func (p *Parser) parseDropIndexStatement() (query.DropIndexStmt, error) {
 
 	return stmt, nil
 }
+
+// parseDropViewStatement parses a drop view string and returns a Statement AST object.
+// This function assumes the DROP VIEW tokens have already been consumed.
+func (p *Parser) parseDropViewStatement() (query.DropViewStmt, error) {
+	var stmt query.DropViewStmt
+	var err error
+
+	// Parse "IF"
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.IF {
+		// Parse "EXISTS"
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EXISTS {
+			return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"EXISTS"}, pos)
+		}
+		stmt.IfExists = true
+	} else {
+		p.Unscan()
+	}
+
+	// Parse view name
+	stmt.ViewName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"view_name"}
+		return stmt, pErr
+	}
+
+	return stmt, nil
+}
+
+// parseDropTriggerStatement parses a drop trigger string and returns a Statement AST object.
+// This function assumes the DROP TRIGGER tokens have already been consumed.
+func (p *Parser) parseDropTriggerStatement() (query.DropTriggerStmt, error) {
+	var stmt query.DropTriggerStmt
+	var err error
+
+	// Parse "IF"
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.IF {
+		// Parse "EXISTS"
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EXISTS {
+			return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"EXISTS"}, pos)
+		}
+		stmt.IfExists = true
+	} else {
+		p.Unscan()
+	}
+
+	// Parse trigger name
+	stmt.TriggerName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"trigger_name"}
+		return stmt, pErr
+	}
+
+	return stmt, nil
+}
+
+// parseDropSequenceStatement parses a drop sequence string and returns a Statement AST object.
+// This function assumes the DROP SEQUENCE tokens have already been consumed.
+func (p *Parser) parseDropSequenceStatement() (query.DropSequenceStmt, error) {
+	var stmt query.DropSequenceStmt
+	var err error
+
+	// Parse "IF"
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.IF {
+		// Parse "EXISTS"
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EXISTS {
+			return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"EXISTS"}, pos)
+		}
+		stmt.IfExists = true
+	} else {
+		p.Unscan()
+	}
+
+	// Parse sequence name
+	stmt.SequenceName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"sequence_name"}
+		return stmt, pErr
+	}
+
+	return stmt, nil
+}
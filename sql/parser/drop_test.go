@@ -18,6 +18,13 @@ func TestParserDrop(t *testing.T) {
 		{"Drop table If not exists", "DROP TABLE IF EXISTS test", query.DropTableStmt{TableName: "test", IfExists: true}, false},
 		{"Drop index", "DROP INDEX test", query.DropIndexStmt{IndexName: "test"}, false},
 		{"Drop index if exists", "DROP INDEX IF EXISTS test", query.DropIndexStmt{IndexName: "test", IfExists: true}, false},
+		{"Drop view", "DROP VIEW test", query.DropViewStmt{ViewName: "test"}, false},
+		{"Drop view if exists", "DROP view IF EXISTS test", query.DropViewStmt{ViewName: "test", IfExists: true}, false},
+		{"Drop view without name", "DROP VIEW", nil, true},
+		{"Drop trigger", "DROP TRIGGER test", query.DropTriggerStmt{TriggerName: "test"}, false},
+		{"Drop trigger if exists", "DROP TRIGGER IF EXISTS test", query.DropTriggerStmt{TriggerName: "test", IfExists: true}, false},
+		{"Drop sequence", "DROP SEQUENCE test", query.DropSequenceStmt{SequenceName: "test"}, false},
+		{"Drop sequence if exists", "DROP SEQUENCE IF EXISTS test", query.DropSequenceStmt{SequenceName: "test", IfExists: true}, false},
 	}
 
 	for _, test := range tests {
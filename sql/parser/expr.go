@@ -32,6 +32,9 @@ func (p *Parser) ParseExpr() (e expr.Expr, lit string, err error) {
 		p.buf = new(bytes.Buffer)
 		defer func() { p.buf = nil }()
 	}
+	// the buffer may already be used to store the literal representation
+	// of an enclosing statement.
+	start := p.buf.Len()
 
 	// Dummy root node.
 	var root expr.Operator = new(dummyOperator)
@@ -52,7 +55,10 @@ func (p *Parser) ParseExpr() (e expr.Expr, lit string, err error) {
 			return nil, "", err
 		}
 		if tok == 0 {
-			return root.RightHand(), strings.TrimSpace(p.buf.String()), nil
+			if start > p.buf.Len() {
+				start = p.buf.Len()
+			}
+			return root.RightHand(), strings.TrimSpace(p.buf.String()[start:]), nil
 		}
 
 		var rhs expr.Expr
@@ -143,6 +149,8 @@ func (p *Parser) parseOperator() (func(lhs, rhs expr.Expr) expr.Expr, scanner.To
 		return nil, 0, newParseError(scanner.Tokstr(tok, lit), []string{"IN, LIKE"}, pos)
 	case scanner.LIKE:
 		return expr.Like, op, nil
+	case scanner.MATCH:
+		return expr.Match, op, nil
 	}
 
 	panic(fmt.Sprintf("unknown operator %q", op))
@@ -168,6 +176,11 @@ func (p *Parser) parseUnaryExpr() (expr.Expr, error) {
 		if err != nil {
 			return nil, err
 		}
+		if p.triggerVars {
+			if name := strings.ToLower(field[0].FieldName); name == "new" || name == "old" {
+				return expr.ParamPath{Name: name, Path: field[1:]}, nil
+			}
+		}
 		fs := expr.Path(field)
 		return fs, nil
 	case scanner.NAMEDPARAM:
@@ -215,6 +228,15 @@ func (p *Parser) parseUnaryExpr() (expr.Expr, error) {
 		p.Unscan()
 		return p.parseExprList(scanner.LSBRACKET, scanner.RSBRACKET)
 	case scanner.LPAREN:
+		// a left parenthesis followed by SELECT is a subquery
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.SELECT {
+			if !p.subqueries {
+				return nil, &ParseError{Message: "subqueries are only allowed in the projection and the WHERE clause of a query", Pos: pos}
+			}
+			return p.parseSubquery()
+		}
+		p.Unscan()
+
 		e, _, err := p.ParseExpr()
 		if err != nil {
 			return nil, err
@@ -440,6 +462,12 @@ LOOP:
 		case scanner.LSBRACKET:
 			// scan the next token for an integer
 			tok, pos, lit := p.Scan()
+			// [*] is not part of the path, let the caller deal with it
+			if tok == scanner.MUL {
+				p.Unscan()
+				p.Unscan()
+				break LOOP
+			}
 			if tok != scanner.INTEGER || lit[0] == '-' {
 				return nil, newParseError(lit, []string{"array index"}, pos)
 			}
@@ -501,10 +529,101 @@ func (p *Parser) parseExprList(leftToken, rightToken scanner.Token) (expr.Litera
 	return p.parseExprListUntil(rightToken)
 }
 
-// parseFunction parses a function call.
+// parseFunction parses a function call, optionally followed by an OVER clause.
+func (p *Parser) parseFunction() (expr.Expr, error) {
+	e, err := p.parseFunctionCall()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse optional OVER clause.
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.OVER {
+		p.Unscan()
+		if expr.RequiresWindow(e) {
+			return nil, &ParseError{Message: fmt.Sprintf("window function %v requires an OVER clause", e)}
+		}
+		return e, nil
+	}
+
+	if !expr.IsWindowFunc(e) {
+		return nil, &ParseError{Message: fmt.Sprintf("%v is not a window function", e)}
+	}
+
+	w, err := p.parseWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	return &expr.WindowFunc{Func: e, Window: w}, nil
+}
+
+// parseWindow parses a window definition of the form
+// "(PARTITION BY expr [, expr...] ORDER BY expr [ASC|DESC])", where both clauses are optional.
+// This function assumes the OVER token has already been consumed.
+func (p *Parser) parseWindow() (expr.Window, error) {
+	var w expr.Window
+
+	// Parse required ( token.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		return w, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	// Parse optional PARTITION BY clause.
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.PARTITION {
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.BY {
+			return w, newParseError(scanner.Tokstr(tok, lit), []string{"BY"}, pos)
+		}
+
+		for {
+			e, _, err := p.ParseExpr()
+			if err != nil {
+				return w, err
+			}
+
+			w.PartitionBy = append(w.PartitionBy, e)
+
+			if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+				p.Unscan()
+				break
+			}
+		}
+	} else {
+		p.Unscan()
+	}
+
+	// Parse optional ORDER BY clause.
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ORDER {
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.BY {
+			return w, newParseError(scanner.Tokstr(tok, lit), []string{"BY"}, pos)
+		}
+
+		e, _, err := p.ParseExpr()
+		if err != nil {
+			return w, err
+		}
+		w.OrderBy = e
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ASC || tok == scanner.DESC {
+			w.OrderByDirection = tok
+		} else {
+			p.Unscan()
+		}
+	} else {
+		p.Unscan()
+	}
+
+	// Parse required ) token.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return w, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	return w, nil
+}
+
+// parseFunctionCall parses a function call.
 // a function is an identifier followed by a parenthesis,
 // an optional coma-separated list of expressions and a closing parenthesis.
-func (p *Parser) parseFunction() (expr.Expr, error) {
+func (p *Parser) parseFunctionCall() (expr.Expr, error) {
 	// Parse function name.
 	fname, err := p.parseIdent()
 	if err != nil {
@@ -532,6 +651,28 @@ func (p *Parser) parseFunction() (expr.Expr, error) {
 	}
 	p.Unscan()
 
+	// Special case: COUNT(DISTINCT expr)
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok == scanner.DISTINCT {
+		e, err := p.parseFunctionCallArgs(fname)
+		if err != nil {
+			return nil, err
+		}
+
+		c, ok := e.(*expr.CountFunc)
+		if !ok {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"expression"}, pos)
+		}
+		c.Distinct = true
+		return c, nil
+	}
+	p.Unscan()
+
+	return p.parseFunctionCallArgs(fname)
+}
+
+// parseFunctionCallArgs parses the coma-separated list of expressions passed to the fname function,
+// followed by a closing parenthesis.
+func (p *Parser) parseFunctionCallArgs(fname string) (expr.Expr, error) {
 	var exprs []expr.Expr
 
 	// Parse expressions.
@@ -6,6 +6,7 @@ import (
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/genjidb/genji/sql/scanner"
 	"github.com/stretchr/testify/require"
 )
 
@@ -128,6 +129,8 @@ func TestParserExpr(t *testing.T) {
 		{"%", "age % 10", expr.Mod(expr.Path(parsePath(t, "age")), expr.IntegerValue(10)), false},
 		{"&", "age & 10", expr.BitwiseAnd(expr.Path(parsePath(t, "age")), expr.IntegerValue(10)), false},
 		{"IN", "age IN ages", expr.In(expr.Path(parsePath(t, "age")), expr.Path(parsePath(t, "ages"))), false},
+		{"IN subquery outside of a query", "age IN (SELECT a FROM test)", nil, true},
+		{"MATCH", "body MATCH 'foo bar'", expr.Match(expr.Path(parsePath(t, "body")), expr.TextValue("foo bar")), false},
 		{"IS", "age IS NULL", expr.Is(expr.Path(parsePath(t, "age")), expr.NullValue()), false},
 		{"IS NOT", "age IS NOT NULL", expr.IsNot(expr.Path(parsePath(t, "age")), expr.NullValue()), false},
 		{"precedence", "4 > 1 + 2", expr.Gt(
@@ -159,7 +162,28 @@ func TestParserExpr(t *testing.T) {
 		{"pk() function", "pk()", &expr.PKFunc{}, false},
 		{"count(expr) function", "count(a)", &expr.CountFunc{Expr: expr.Path(parsePath(t, "a"))}, false},
 		{"count(*) function", "count(*)", &expr.CountFunc{Wildcard: true}, false},
+		{"count(DISTINCT expr) function", "count(DISTINCT a)", &expr.CountFunc{Expr: expr.Path(parsePath(t, "a")), Distinct: true}, false},
+		{"DISTINCT with other function", "sum(DISTINCT a)", nil, true},
+		{"string_agg function", "string_agg(a, ', ')", &expr.StringAggFunc{Expr: expr.Path(parsePath(t, "a")), Separator: expr.TextValue(", ")}, false},
 		{"CAST", "CAST(a.b[1][0] AS TEXT)", expr.CastFunc{Expr: expr.Path(parsePath(t, "a.b[1][0]")), CastAs: document.TextValue}, false},
+		{"window function", "row_number() OVER (PARTITION BY a, b ORDER BY c DESC)", &expr.WindowFunc{
+			Func: expr.RowNumberFunc{},
+			Window: expr.Window{
+				PartitionBy:      []expr.Expr{expr.Path(parsePath(t, "a")), expr.Path(parsePath(t, "b"))},
+				OrderBy:          expr.Path(parsePath(t, "c")),
+				OrderByDirection: scanner.DESC,
+			},
+		}, false},
+		{"window function with empty window", "lag(a, 2) OVER ()", &expr.WindowFunc{
+			Func: expr.LagFunc{Expr: expr.Path(parsePath(t, "a")), Offset: expr.IntegerValue(2)},
+		}, false},
+		{"aggregate as window function", "sum(a) OVER (ORDER BY b)", &expr.WindowFunc{
+			Func:   &expr.SumFunc{Expr: expr.Path(parsePath(t, "a"))},
+			Window: expr.Window{OrderBy: expr.Path(parsePath(t, "b"))},
+		}, false},
+		{"window function without OVER", "row_number()", nil, true},
+		{"OVER on a scalar function", "lower(a) OVER ()", nil, true},
+		{"window with invalid clause", "rank() OVER (LIMIT 10)", nil, true},
 	}
 
 	for _, test := range tests {
@@ -176,6 +200,17 @@ func TestParserExpr(t *testing.T) {
 	}
 }
 
+func TestParseExprEOF(t *testing.T) {
+	e, err := ParseExpr("  a > 1  ")
+	require.NoError(t, err)
+	require.EqualValues(t, expr.Gt(expr.Path(parsePath(t, "a")), expr.IntegerValue(1)), e)
+
+	for _, s := range []string{"a > 1; DROP TABLE foo", "a > 1 ORDER BY a", "a b"} {
+		_, err := ParseExpr(s)
+		require.Error(t, err, s)
+	}
+}
+
 func TestParsePath(t *testing.T) {
 	tests := []struct {
 		name     string
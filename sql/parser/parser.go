@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/planner"
 	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/genjidb/genji/sql/scanner"
@@ -19,6 +20,13 @@ type Parser struct {
 	namedParams   int
 	buf           *bytes.Buffer
 	functions     expr.Functions
+	// common table expressions visible to the statement being parsed
+	ctes []*planner.CommonTableExpression
+	// if true, paths starting with new or old refer to the documents
+	// written by the statement firing a trigger
+	triggerVars bool
+	// if true, subqueries are allowed in the expression being parsed
+	subqueries bool
 }
 
 // NewParser returns a new instance of Parser.
@@ -46,9 +54,54 @@ func ParsePath(s string) (document.Path, error) {
 }
 
 // ParseExpr parses an expression.
+// The whole string must be a single expression, anything after it is an error.
 func ParseExpr(s string) (expr.Expr, error) {
-	e, _, err := NewParser(strings.NewReader(s)).ParseExpr()
-	return e, err
+	p := NewParser(strings.NewReader(s))
+	e, _, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EOF {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"EOF"}, pos)
+	}
+
+	return e, nil
+}
+
+// ParseTrigger parses the condition, which can be empty, and the statement of a trigger.
+// Within them, paths starting with new or old refer to the document being written
+// and to the document it replaces or deletes.
+func ParseTrigger(when, statement string) (*query.Trigger, error) {
+	var t query.Trigger
+
+	if when != "" {
+		p := NewParser(strings.NewReader(when))
+		p.triggerVars = true
+
+		e, _, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EOF {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"EOF"}, pos)
+		}
+		t.When = e
+	}
+
+	p := NewParser(strings.NewReader(statement))
+	p.triggerVars = true
+
+	q, err := p.ParseQuery()
+	if err != nil {
+		return nil, err
+	}
+	if len(q.Statements) != 1 {
+		return nil, fmt.Errorf("expected one statement, got %d", len(q.Statements))
+	}
+	t.Statement = q.Statements[0]
+
+	return &t, nil
 }
 
 // MustParseExpr calls ParseExpr and panics if it returns an error.
@@ -114,17 +167,40 @@ func (p *Parser) ParseStatement() (query.Statement, error) {
 		return p.parseReIndexStatement()
 	case scanner.ROLLBACK:
 		return p.parseRollbackStatement()
+	case scanner.WITH:
+		return p.parseWithStatement()
 	}
 
 	return nil, newParseError(scanner.Tokstr(tok, lit), []string{
-		"ALTER", "BEGIN", "COMMIT", "SELECT", "DELETE", "UPDATE", "INSERT", "CREATE", "DROP", "EXPLAIN", "REINDEX", "ROLLBACK",
+		"ALTER", "BEGIN", "COMMIT", "SELECT", "DELETE", "UPDATE", "INSERT", "CREATE", "DROP", "EXPLAIN", "REINDEX", "ROLLBACK", "WITH",
 	}, pos)
 }
 
+// allowSubqueries sets whether subqueries are allowed in the expressions parsed next
+// and returns a function restoring the previous setting.
+// Subqueries are only bound to the database in the projection and in the WHERE clause
+// of SELECT, UPDATE and DELETE statements, they must be rejected everywhere else.
+func (p *Parser) allowSubqueries(allow bool) (restore func()) {
+	prev := p.subqueries
+	p.subqueries = allow
+	return func() {
+		p.subqueries = prev
+	}
+}
+
+// parseQueryCondition parses the "WHERE" clause of a SELECT, UPDATE or DELETE statement,
+// in which subqueries are allowed.
+func (p *Parser) parseQueryCondition() (expr.Expr, error) {
+	defer p.allowSubqueries(true)()
+
+	return p.parseCondition()
+}
+
 // parseCondition parses the "WHERE" clause of the query, if it exists.
 func (p *Parser) parseCondition() (expr.Expr, error) {
 	// Check if the WHERE token exists.
-	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.WHERE {
+	tok, pos, _ := p.ScanIgnoreWhitespace()
+	if tok != scanner.WHERE {
 		p.Unscan()
 		return nil, nil
 	}
@@ -135,6 +211,11 @@ func (p *Parser) parseCondition() (expr.Expr, error) {
 		return nil, err
 	}
 
+	err = rejectWindowFuncs(expr, tok, pos)
+	if err != nil {
+		return nil, err
+	}
+
 	return expr, nil
 }
 
@@ -1,7 +1,9 @@
 package parser
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/planner"
@@ -11,62 +13,172 @@ import (
 
 // parseSelectStatement parses a select string and returns a Statement AST object.
 // This function assumes the SELECT token has already been consumed.
+// Multiple SELECT statements can be combined using UNION [ALL], INTERSECT and EXCEPT,
+// in which case they are evaluated from left to right and the ORDER BY, LIMIT and OFFSET
+// clauses apply to the combined result.
 func (p *Parser) parseSelectStatement() (*planner.Tree, error) {
-	var cfg selectConfig
-	var err error
+	cfg, err := p.parseSelectCore()
+	if err != nil {
+		return nil, err
+	}
 
-	cfg.Distinct, err = p.parseDistinct()
+	// Parse compound operators: "UNION [ALL] | INTERSECT | EXCEPT SELECT ..."
+	var n planner.Node
+	for {
+		op, all, found, err := p.parseCompoundOperator()
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			break
+		}
+
+		right, err := p.parseSelectCore()
+		if err != nil {
+			return nil, err
+		}
+
+		if n == nil {
+			left, err := cfg.ToTree()
+			if err != nil {
+				return nil, err
+			}
+			n = left.Root
+		}
+
+		rt, err := right.ToTree()
+		if err != nil {
+			return nil, err
+		}
+
+		n = planner.NewSetOperationNode(op, all, planner.NewTree(n), rt)
+	}
+
+	// Parse order by: "ORDER BY path [ASC|DESC]?"
+	cfg.OrderBy, cfg.OrderByDirection, err = p.parseOrderBy()
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse path list or query.Wildcard
-	cfg.ProjectionExprs, err = p.parseResultFields()
+	// Parse limit: "LIMIT expr"
+	cfg.LimitExpr, err = p.parseLimit()
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse "FROM".
-	var found bool
-	cfg.TableName, found, err = p.parseFrom()
+	// Parse offset: "OFFSET expr"
+	cfg.OffsetExpr, err = p.parseOffset()
 	if err != nil {
 		return nil, err
 	}
-	if !found {
+
+	if n == nil {
 		return cfg.ToTree()
 	}
 
-	// Parse condition: "WHERE expr".
-	cfg.WhereExpr, err = p.parseCondition()
+	n, err = cfg.orderAndLimit(n)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse group by: "GROUP BY expr"
-	cfg.GroupByExpr, err = p.parseGroupBy()
+	return planner.NewTree(n), nil
+}
+
+// parseSelectCore parses the clauses of a select statement that precede
+// the ORDER BY clause.
+func (p *Parser) parseSelectCore() (cfg selectConfig, err error) {
+	cfg.Distinct, err = p.parseDistinct()
 	if err != nil {
-		return nil, err
+		return
 	}
 
-	// Parse order by: "ORDER BY path [ASC|DESC]?"
-	cfg.OrderBy, cfg.OrderByDirection, err = p.parseOrderBy()
+	// Parse path list or query.Wildcard
+	restore := p.allowSubqueries(true)
+	cfg.ProjectionExprs, err = p.parseResultFields()
+	restore()
 	if err != nil {
-		return nil, err
+		return
 	}
 
-	// Parse limit: "LIMIT expr"
-	cfg.LimitExpr, err = p.parseLimit()
+	// Parse "FROM".
+	var found bool
+	cfg.TableName, found, err = p.parseFrom()
+	if err != nil || !found {
+		return
+	}
+	cfg.CTE = p.lookupCTE(cfg.TableName)
+
+	// Parse condition: "WHERE expr".
+	cfg.WhereExpr, err = p.parseQueryCondition()
 	if err != nil {
-		return nil, err
+		return
 	}
 
-	// Parse offset: "OFFSET expr"
-	cfg.OffsetExpr, err = p.parseOffset()
+	// Parse group by: "GROUP BY expr [, expr...]"
+	cfg.GroupByExprs, err = p.parseGroupBy()
+	if err != nil {
+		return
+	}
+
+	// Parse having: "HAVING expr"
+	cfg.HavingExpr, err = p.parseHaving()
+	return
+}
+
+// parseCompoundOperator parses "UNION [ALL]", "INTERSECT" or "EXCEPT", followed by the SELECT token.
+func (p *Parser) parseCompoundOperator() (op scanner.Token, all bool, found bool, err error) {
+	op, _, _ = p.ScanIgnoreWhitespace()
+	if op != scanner.UNION && op != scanner.INTERSECT && op != scanner.EXCEPT {
+		p.Unscan()
+		return 0, false, false, nil
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok == scanner.ALL {
+		if op != scanner.UNION {
+			return 0, false, false, newParseError(scanner.Tokstr(tok, lit), []string{"SELECT"}, pos)
+		}
+
+		all = true
+		tok, pos, lit = p.ScanIgnoreWhitespace()
+	}
+
+	if tok != scanner.SELECT {
+		return 0, false, false, newParseError(scanner.Tokstr(tok, lit), []string{"SELECT"}, pos)
+	}
+
+	return op, all, true, nil
+}
+
+// parseSubquery parses a select statement followed by a right parenthesis.
+// This function assumes the left parenthesis and the SELECT token have already been consumed.
+func (p *Parser) parseSubquery() (expr.Expr, error) {
+	// only the clauses of the subquery that allow it can contain other subqueries.
+	defer p.allowSubqueries(false)()
+
+	// store the literal representation of the subquery
+	if p.buf == nil {
+		p.buf = new(bytes.Buffer)
+		defer func() { p.buf = nil }()
+	}
+	start := p.buf.Len()
+
+	tree, err := p.parseSelectStatement()
 	if err != nil {
 		return nil, err
 	}
 
-	return cfg.ToTree()
+	if start > p.buf.Len() {
+		start = p.buf.Len()
+	}
+	q := "SELECT " + strings.TrimSpace(p.buf.String()[start:])
+
+	// Parse required ) token.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	return &planner.SubqueryExpr{Tree: tree, Query: q}, nil
 }
 
 // parseResultFields parses the list of result fields.
@@ -154,9 +266,10 @@ func (p *Parser) parseFrom() (string, bool, error) {
 	return ident, true, nil
 }
 
-func (p *Parser) parseGroupBy() (expr.Expr, error) {
+func (p *Parser) parseGroupBy() ([]expr.Expr, error) {
 	// parse GROUP token
-	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.GROUP {
+	tok, pos, _ := p.ScanIgnoreWhitespace()
+	if tok != scanner.GROUP {
 		p.Unscan()
 		return nil, nil
 	}
@@ -166,12 +279,46 @@ func (p *Parser) parseGroupBy() (expr.Expr, error) {
 		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"BY"}, pos)
 	}
 
-	// parse expr
+	// parse expr list
+	var exprs []expr.Expr
+	for {
+		e, _, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		err = rejectWindowFuncs(e, tok, pos)
+		if err != nil {
+			return nil, err
+		}
+
+		exprs = append(exprs, e)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			return exprs, nil
+		}
+	}
+}
+
+func (p *Parser) parseHaving() (expr.Expr, error) {
+	// parse HAVING token
+	tok, pos, _ := p.ScanIgnoreWhitespace()
+	if tok != scanner.HAVING {
+		p.Unscan()
+		return nil, nil
+	}
+
 	e, _, err := p.ParseExpr()
-	return e, err
+	if err != nil {
+		return nil, err
+	}
+
+	return e, rejectWindowFuncs(e, tok, pos)
 }
 
-func (p *Parser) parseOrderBy() (expr.Path, scanner.Token, error) {
+// parseOrderBy parses "ORDER BY expr [ASC|DESC]", where expr is a path or the rank() function.
+func (p *Parser) parseOrderBy() (expr.Expr, scanner.Token, error) {
 	// parse ORDER token
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.ORDER {
 		p.Unscan()
@@ -183,19 +330,27 @@ func (p *Parser) parseOrderBy() (expr.Path, scanner.Token, error) {
 		return nil, 0, newParseError(scanner.Tokstr(tok, lit), []string{"BY"}, pos)
 	}
 
-	// parse path
-	path, err := p.parsePath()
+	// parse path or rank()
+	_, pos, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+	e, _, err := p.ParseExpr()
 	if err != nil {
 		return nil, 0, err
 	}
 
+	switch e.(type) {
+	case expr.Path, expr.RankFunc:
+	default:
+		return nil, 0, &ParseError{Message: fmt.Sprintf("cannot order by %v, expected a path or rank()", e), Pos: pos}
+	}
+
 	// parse optional ASC or DESC
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ASC || tok == scanner.DESC {
-		return expr.Path(path), tok, nil
+		return e, tok, nil
 	}
 	p.Unscan()
 
-	return expr.Path(path), 0, nil
+	return e, 0, nil
 }
 
 func (p *Parser) parseLimit() (expr.Expr, error) {
@@ -223,10 +378,12 @@ func (p *Parser) parseOffset() (expr.Expr, error) {
 // SelectConfig holds SELECT configuration.
 type selectConfig struct {
 	TableName        string
+	CTE              *planner.CommonTableExpression
 	Distinct         bool
 	WhereExpr        expr.Expr
-	GroupByExpr      expr.Expr
-	OrderBy          expr.Path
+	GroupByExprs     []expr.Expr
+	HavingExpr       expr.Expr
+	OrderBy          expr.Expr
 	OrderByDirection scanner.Token
 	OffsetExpr       expr.Expr
 	LimitExpr        expr.Expr
@@ -237,23 +394,34 @@ type selectConfig struct {
 func (cfg selectConfig) ToTree() (*planner.Tree, error) {
 	var n planner.Node
 
-	if cfg.TableName != "" {
-		n = planner.NewTableInputNode(cfg.TableName)
+	// common table expressions are not stored in the database
+	tableName := cfg.TableName
+	if cfg.CTE != nil {
+		n = planner.NewCTEInputNode(cfg.CTE)
+		tableName = ""
+	} else if tableName != "" {
+		n = planner.NewTableInputNode(tableName)
 	}
 
 	if cfg.WhereExpr != nil {
 		n = planner.NewSelectionNode(n, cfg.WhereExpr)
 	}
 
-	// when using GROUP BY, only aggregation functions or GroupByExpr can be selected
-	if cfg.GroupByExpr != nil {
+	var aggregators []document.AggregatorBuilder
+	var groups []*planner.ProjectedGroupAggregatorBuilder
+
+	// when using GROUP BY, only aggregation functions or GroupByExprs can be selected
+	if cfg.GroupByExprs != nil {
 		// add Group node
-		n = planner.NewGroupingNode(n, cfg.GroupByExpr)
+		n = planner.NewGroupingNode(n, cfg.GroupByExprs)
+
+		for i, e := range cfg.GroupByExprs {
+			groups = append(groups, &planner.ProjectedGroupAggregatorBuilder{Expr: e, Index: i})
+		}
 
 		var invalidProjectedField planner.ProjectedField
-		var aggregators []document.AggregatorBuilder
 
-		for _, pe := range cfg.ProjectionExprs {
+		for i, pe := range cfg.ProjectionExprs {
 			pre, ok := pe.(planner.ProjectedExpr)
 			if !ok {
 				invalidProjectedField = pe
@@ -267,9 +435,11 @@ func (cfg selectConfig) ToTree() (*planner.Tree, error) {
 				continue
 			}
 
-			// check if this is the same expression as the one used in the GROUP BY clause
-			if expr.Equal(e, cfg.GroupByExpr) {
-				aggregators = append(aggregators, &planner.ProjectedGroupAggregatorBuilder{Expr: pre.Expr})
+			// check if this is the same expression as one of those used in the GROUP BY clause
+			// and if so, replace it by the value of the group once documents are aggregated
+			if g := groupFor(groups, e); g != nil {
+				aggregators = appendAggregator(aggregators, g)
+				cfg.ProjectionExprs[i] = planner.ProjectedExpr{Expr: g, ExprName: pre.ExprName}
 				continue
 			}
 
@@ -281,14 +451,8 @@ func (cfg selectConfig) ToTree() (*planner.Tree, error) {
 		if invalidProjectedField != nil {
 			return nil, fmt.Errorf("field %q must appear in the GROUP BY clause or be used in an aggregate function", invalidProjectedField)
 		}
-
-		// add Aggregation node
-		n = planner.NewAggregationNode(n, aggregators)
 	} else {
 		// if there is no GROUP BY clause, check if there are any aggregation function
-		// and if so add an aggregation node
-		var aggregators []document.AggregatorBuilder
-
 		for _, pe := range cfg.ProjectionExprs {
 			pre, ok := pe.(planner.ProjectedExpr)
 			if !ok {
@@ -301,19 +465,55 @@ func (cfg selectConfig) ToTree() (*planner.Tree, error) {
 				aggregators = append(aggregators, agg)
 			}
 		}
+	}
+
+	// the HAVING clause is evaluated on aggregated documents,
+	// it can only reference GROUP BY expressions, aggregation functions and aliases.
+	var having expr.Expr
+	if cfg.HavingExpr != nil {
+		var err error
+		having, aggregators, err = cfg.resolveHavingExpr(cfg.HavingExpr, groups, aggregators)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-		// add Aggregation node
-		if len(aggregators) > 0 {
-			n = planner.NewAggregationNode(n, aggregators)
+	// add Aggregation node
+	if cfg.GroupByExprs != nil || len(aggregators) > 0 || having != nil {
+		n = planner.NewAggregationNode(n, aggregators)
+	}
+
+	if having != nil {
+		n = planner.NewSelectionNode(n, having)
+	}
+
+	// if there are any window functions, add a window node to compute them
+	var windowFuncs []*expr.WindowFunc
+	for _, pe := range cfg.ProjectionExprs {
+		if pre, ok := pe.(planner.ProjectedExpr); ok {
+			windowFuncs = appendWindowFuncs(windowFuncs, pre.Expr)
 		}
 	}
+	if len(windowFuncs) > 0 {
+		n = planner.NewWindowNode(n, windowFuncs)
+	}
 
-	n = planner.NewProjectionNode(n, cfg.ProjectionExprs, cfg.TableName)
+	n = planner.NewProjectionNode(n, cfg.ProjectionExprs, tableName)
 
 	if cfg.Distinct {
-		n = planner.NewDedupNode(n, cfg.TableName)
+		n = planner.NewDedupNode(n, tableName)
 	}
 
+	n, err := cfg.orderAndLimit(n)
+	if err != nil {
+		return nil, err
+	}
+
+	return &planner.Tree{Root: n}, nil
+}
+
+// orderAndLimit adds the nodes required by the ORDER BY, OFFSET and LIMIT clauses on top of n.
+func (cfg selectConfig) orderAndLimit(n planner.Node) (planner.Node, error) {
 	if cfg.OrderBy != nil {
 		n = planner.NewSortNode(n, cfg.OrderBy, cfg.OrderByDirection)
 	}
@@ -354,5 +554,130 @@ func (cfg selectConfig) ToTree() (*planner.Tree, error) {
 		n = planner.NewLimitNode(n, int(v.V.(int64)))
 	}
 
-	return &planner.Tree{Root: n}, nil
+	return n, nil
+}
+
+// appendWindowFuncs appends the window functions found in e to funcs,
+// ignoring the ones that are already part of the list.
+func appendWindowFuncs(funcs []*expr.WindowFunc, e expr.Expr) []*expr.WindowFunc {
+	switch t := e.(type) {
+	case *expr.WindowFunc:
+		for _, f := range funcs {
+			if f.IsEqual(t) {
+				return funcs
+			}
+		}
+		return append(funcs, t)
+	case expr.Parentheses:
+		return appendWindowFuncs(funcs, t.E)
+	case expr.CastFunc:
+		return appendWindowFuncs(funcs, t.Expr)
+	case expr.LowerFunc:
+		return appendWindowFuncs(funcs, t.Expr)
+	case expr.UpperFunc:
+		return appendWindowFuncs(funcs, t.Expr)
+	case expr.LiteralExprList:
+		for _, e := range t {
+			funcs = appendWindowFuncs(funcs, e)
+		}
+	case expr.Operator:
+		funcs = appendWindowFuncs(funcs, t.LeftHand())
+		funcs = appendWindowFuncs(funcs, t.RightHand())
+	}
+
+	return funcs
+}
+
+// rejectWindowFuncs returns an error if e, parsed in the clause starting with the given token,
+// contains a window function. Window functions are computed once documents are filtered and grouped,
+// they can only be used in the projection.
+func rejectWindowFuncs(e expr.Expr, clause scanner.Token, pos scanner.Pos) error {
+	if len(appendWindowFuncs(nil, e)) == 0 {
+		return nil
+	}
+
+	name := clause.String()
+	if clause == scanner.GROUP {
+		name = "GROUP BY"
+	}
+
+	return &ParseError{Message: fmt.Sprintf("window functions are not allowed in %s", name), Pos: pos}
+}
+
+// groupFor returns the GROUP BY expression equal to e, if any.
+func groupFor(groups []*planner.ProjectedGroupAggregatorBuilder, e expr.Expr) *planner.ProjectedGroupAggregatorBuilder {
+	for _, g := range groups {
+		if expr.Equal(g.Expr, e) {
+			return g
+		}
+	}
+
+	return nil
+}
+
+// appendAggregator appends agg to aggregators, unless it's already part of the list.
+func appendAggregator(aggregators []document.AggregatorBuilder, agg document.AggregatorBuilder) []document.AggregatorBuilder {
+	for _, a := range aggregators {
+		if ae, ok := a.(expr.Expr); ok && expr.Equal(ae, agg.(expr.Expr)) {
+			return aggregators
+		}
+	}
+
+	return append(aggregators, agg)
+}
+
+// resolveHavingExpr returns a copy of e that can be evaluated on aggregated documents.
+// GROUP BY expressions are replaced by the value of their group, aliases are replaced by the
+// expression they refer to and aggregation functions are added to the list of aggregators.
+func (cfg selectConfig) resolveHavingExpr(e expr.Expr, groups []*planner.ProjectedGroupAggregatorBuilder, aggregators []document.AggregatorBuilder) (expr.Expr, []document.AggregatorBuilder, error) {
+	if g := groupFor(groups, e); g != nil {
+		return g, appendAggregator(aggregators, g), nil
+	}
+
+	var err error
+
+	switch t := e.(type) {
+	case document.AggregatorBuilder:
+		return e, appendAggregator(aggregators, t), nil
+	case expr.Path:
+		for _, pe := range cfg.ProjectionExprs {
+			if pre, ok := pe.(planner.ProjectedExpr); ok && pre.ExprName == t.String() {
+				if _, ok := pre.Expr.(document.AggregatorBuilder); ok {
+					return pre.Expr, aggregators, nil
+				}
+			}
+		}
+
+		return nil, nil, fmt.Errorf("field %q must appear in the GROUP BY clause or be used in an aggregate function", t)
+	case expr.Parentheses:
+		t.E, aggregators, err = cfg.resolveHavingExpr(t.E, groups, aggregators)
+		return t, aggregators, err
+	case expr.CastFunc:
+		t.Expr, aggregators, err = cfg.resolveHavingExpr(t.Expr, groups, aggregators)
+		return t, aggregators, err
+	case expr.LiteralExprList:
+		list := make(expr.LiteralExprList, len(t))
+		for i := range t {
+			list[i], aggregators, err = cfg.resolveHavingExpr(t[i], groups, aggregators)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		return list, aggregators, nil
+	case expr.Operator:
+		var l, r expr.Expr
+		l, aggregators, err = cfg.resolveHavingExpr(t.LeftHand(), groups, aggregators)
+		if err != nil {
+			return nil, nil, err
+		}
+		r, aggregators, err = cfg.resolveHavingExpr(t.RightHand(), groups, aggregators)
+		if err != nil {
+			return nil, nil, err
+		}
+		t.SetLeftHandExpr(l)
+		t.SetRightHandExpr(r)
+		return t, aggregators, nil
+	}
+
+	return e, aggregators, nil
 }
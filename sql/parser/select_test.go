@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/genjidb/genji/document"
@@ -117,6 +119,26 @@ func TestParserSelect(t *testing.T) {
 					"test",
 				)),
 			false},
+		{"WithSubquery", "SELECT * FROM test WHERE age IN (SELECT a FROM foo)",
+			planner.NewTree(
+				planner.NewProjectionNode(
+					planner.NewSelectionNode(
+						planner.NewTableInputNode("test"),
+						expr.In(expr.Path(parsePath(t, "age")), &planner.SubqueryExpr{
+							Tree: planner.NewTree(planner.NewProjectionNode(planner.NewTableInputNode("foo"),
+								[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"}},
+								"foo")),
+							Query: "SELECT a FROM foo",
+						}),
+					),
+					[]planner.ProjectedField{planner.Wildcard{}},
+					"test",
+				)),
+			false},
+		{"WithInvalidSubquery", "SELECT * FROM test WHERE age IN (SELECT a FROM foo", nil, true},
+		{"WithSubqueryInGroupBy", "SELECT COUNT(*) FROM test GROUP BY (SELECT a FROM foo)", nil, true},
+		{"WithSubqueryInLimit", "SELECT * FROM test LIMIT (SELECT a FROM foo)", nil, true},
+		{"WithNestedSubqueryInGroupBy", "SELECT * FROM test WHERE age IN (SELECT COUNT(*) FROM foo GROUP BY (SELECT a FROM bar))", nil, true},
 		{"WithGroupBy", "SELECT a.b.c FROM test WHERE age = 10 GROUP BY a.b.c",
 			planner.NewTree(
 				planner.NewProjectionNode(
@@ -126,11 +148,53 @@ func TestParserSelect(t *testing.T) {
 								planner.NewTableInputNode("test"),
 								expr.Eq(expr.Path(parsePath(t, "age")), expr.IntegerValue(10)),
 							),
-							expr.Path(parsePath(t, "a.b.c")),
+							[]expr.Expr{expr.Path(parsePath(t, "a.b.c"))},
 						),
 						[]document.AggregatorBuilder{&planner.ProjectedGroupAggregatorBuilder{Expr: expr.Path(parsePath(t, "a.b.c"))}},
 					),
-					[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a.b.c")), ExprName: "a.b.c"}},
+					[]planner.ProjectedField{planner.ProjectedExpr{Expr: &planner.ProjectedGroupAggregatorBuilder{Expr: expr.Path(parsePath(t, "a.b.c"))}, ExprName: "a.b.c"}},
+					"test",
+				)),
+			false},
+		{"WithGroupBy multiple expressions and Having", "SELECT b, COUNT(*) AS c FROM test GROUP BY a, b HAVING a > 1 AND c > 2",
+			planner.NewTree(
+				planner.NewProjectionNode(
+					planner.NewSelectionNode(
+						planner.NewAggregationNode(
+							planner.NewGroupingNode(
+								planner.NewTableInputNode("test"),
+								[]expr.Expr{expr.Path(parsePath(t, "a")), expr.Path(parsePath(t, "b"))},
+							),
+							[]document.AggregatorBuilder{
+								&planner.ProjectedGroupAggregatorBuilder{Expr: expr.Path(parsePath(t, "b")), Index: 1},
+								&expr.CountFunc{Wildcard: true},
+								&planner.ProjectedGroupAggregatorBuilder{Expr: expr.Path(parsePath(t, "a"))},
+							},
+						),
+						expr.And(
+							expr.Gt(&planner.ProjectedGroupAggregatorBuilder{Expr: expr.Path(parsePath(t, "a"))}, expr.IntegerValue(1)),
+							expr.Gt(&expr.CountFunc{Wildcard: true}, expr.IntegerValue(2)),
+						),
+					),
+					[]planner.ProjectedField{
+						planner.ProjectedExpr{Expr: &planner.ProjectedGroupAggregatorBuilder{Expr: expr.Path(parsePath(t, "b")), Index: 1}, ExprName: "b"},
+						planner.ProjectedExpr{Expr: &expr.CountFunc{Wildcard: true}, ExprName: "c"},
+					},
+					"test",
+				)),
+			false},
+		{"With Invalid Having", "SELECT a FROM test GROUP BY a HAVING b > 1", nil, true},
+		{"With Having without GroupBy", "SELECT COUNT(*) FROM test HAVING COUNT(*) > 1",
+			planner.NewTree(
+				planner.NewProjectionNode(
+					planner.NewSelectionNode(
+						planner.NewAggregationNode(
+							planner.NewTableInputNode("test"),
+							[]document.AggregatorBuilder{&expr.CountFunc{Wildcard: true}},
+						),
+						expr.Gt(&expr.CountFunc{Wildcard: true}, expr.IntegerValue(1)),
+					),
+					[]planner.ProjectedField{planner.ProjectedExpr{Expr: &expr.CountFunc{Wildcard: true}, ExprName: "COUNT(*)"}},
 					"test",
 				)),
 			false},
@@ -181,6 +245,22 @@ func TestParserSelect(t *testing.T) {
 					scanner.DESC,
 				)),
 			false},
+		{"WithOrderBy rank()", "SELECT a FROM test WHERE b MATCH 'foo' ORDER BY rank() DESC",
+			planner.NewTree(
+				planner.NewSortNode(
+					planner.NewProjectionNode(
+						planner.NewSelectionNode(
+							planner.NewTableInputNode("test"),
+							expr.Match(expr.Path(parsePath(t, "b")), expr.TextValue("foo")),
+						),
+						[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"}},
+						"test",
+					),
+					expr.RankFunc{},
+					scanner.DESC,
+				)),
+			false},
+		{"WithOrderBy expression", "SELECT * FROM test ORDER BY a + 1", nil, true},
 		{"WithLimit", "SELECT * FROM test WHERE age = 10 LIMIT 20",
 			planner.NewTree(
 				planner.NewLimitNode(
@@ -238,6 +318,67 @@ func TestParserSelect(t *testing.T) {
 					"test",
 				)),
 			false},
+		{"With window function", "SELECT a, rank() OVER (ORDER BY b) FROM test",
+			planner.NewTree(
+				planner.NewProjectionNode(
+					planner.NewWindowNode(
+						planner.NewTableInputNode("test"),
+						[]*expr.WindowFunc{{Func: expr.RankFunc{}, Window: expr.Window{OrderBy: expr.Path(parsePath(t, "b"))}}},
+					),
+					[]planner.ProjectedField{
+						planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"},
+						planner.ProjectedExpr{Expr: &expr.WindowFunc{Func: expr.RankFunc{}, Window: expr.Window{OrderBy: expr.Path(parsePath(t, "b"))}}, ExprName: "rank() OVER (ORDER BY b)"},
+					},
+					"test",
+				)),
+			false},
+		{"With union", "SELECT a FROM test UNION SELECT b FROM foo",
+			planner.NewTree(
+				planner.NewSetOperationNode(scanner.UNION, false,
+					planner.NewTree(planner.NewProjectionNode(planner.NewTableInputNode("test"),
+						[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"}},
+						"test")),
+					planner.NewTree(planner.NewProjectionNode(planner.NewTableInputNode("foo"),
+						[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "b")), ExprName: "b"}},
+						"foo")),
+				)),
+			false},
+		{"With compound operators, order by and limit", "SELECT a FROM test WHERE a > 1 UNION ALL SELECT 1 EXCEPT SELECT b FROM foo ORDER BY a DESC LIMIT 10",
+			planner.NewTree(
+				planner.NewLimitNode(
+					planner.NewSortNode(
+						planner.NewSetOperationNode(scanner.EXCEPT, false,
+							planner.NewTree(planner.NewSetOperationNode(scanner.UNION, true,
+								planner.NewTree(planner.NewProjectionNode(
+									planner.NewSelectionNode(planner.NewTableInputNode("test"),
+										expr.Gt(expr.Path(parsePath(t, "a")), expr.IntegerValue(1))),
+									[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"}},
+									"test")),
+								planner.NewTree(planner.NewProjectionNode(nil,
+									[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.IntegerValue(1), ExprName: "1"}},
+									"")),
+							)),
+							planner.NewTree(planner.NewProjectionNode(planner.NewTableInputNode("foo"),
+								[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "b")), ExprName: "b"}},
+								"foo")),
+						),
+						expr.Path(parsePath(t, "a")), scanner.DESC),
+					10)),
+			false},
+		{"With intersect", "SELECT a FROM test INTERSECT SELECT a FROM foo",
+			planner.NewTree(
+				planner.NewSetOperationNode(scanner.INTERSECT, false,
+					planner.NewTree(planner.NewProjectionNode(planner.NewTableInputNode("test"),
+						[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"}},
+						"test")),
+					planner.NewTree(planner.NewProjectionNode(planner.NewTableInputNode("foo"),
+						[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"}},
+						"foo")),
+				)),
+			false},
+		{"With intersect all", "SELECT a FROM test INTERSECT ALL SELECT a FROM foo", nil, true},
+		{"With union without select", "SELECT a FROM test UNION a FROM foo", nil, true},
+		{"With order by before union", "SELECT a FROM test ORDER BY a UNION SELECT a FROM foo", nil, true},
 		{"Invalid use of MIN() aggregator", "SELECT * FROM test LIMIT min(0)", nil, true},
 		{"Invalid use of COUNT() aggregator", "SELECT * FROM test OFFSET x(*)", nil, true},
 		{"Invalid use of MAX() aggregator", "SELECT * FROM test LIMIT max(0)", nil, true},
@@ -258,3 +399,27 @@ func TestParserSelect(t *testing.T) {
 		})
 	}
 }
+
+func TestParserSubqueryString(t *testing.T) {
+	tests := []string{
+		"a IN (SELECT b FROM test)",
+		"a NOT IN (SELECT b FROM test WHERE c > 10 AND d IN (SELECT e FROM foo))",
+		"a IN (SELECT b FROM test UNION SELECT c FROM foo ORDER BY b LIMIT 10)",
+	}
+
+	parse := func(s string) expr.Expr {
+		p := NewParser(strings.NewReader(s))
+		p.subqueries = true
+		e, _, err := p.ParseExpr()
+		require.NoError(t, err)
+		return e
+	}
+
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			s := fmt.Sprintf("%v", parse(test))
+			require.Equal(t, test, s)
+			require.Equal(t, s, fmt.Sprintf("%v", parse(s)))
+		})
+	}
+}
@@ -36,7 +36,7 @@ func (p *Parser) parseUpdateStatement() (*planner.Tree, error) {
 	}
 
 	// Parse condition: "WHERE EXPR".
-	cfg.WhereExpr, err = p.parseCondition()
+	cfg.WhereExpr, err = p.parseQueryCondition()
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"github.com/genjidb/genji/sql/planner"
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+// parseWithStatement parses a select statement preceded by a list of common table expressions,
+// in the form: WITH [RECURSIVE] name [(column, ...)] AS (select-stmt) [, ...] select-stmt.
+// Each common table expression can be referenced by the ones that follow it and by the statement.
+// With RECURSIVE, a common table expression can also reference itself.
+// This function assumes the WITH token has already been consumed.
+func (p *Parser) parseWithStatement() (query.Statement, error) {
+	var recursive bool
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.RECURSIVE {
+		recursive = true
+	} else {
+		p.Unscan()
+	}
+
+	// common table expressions are only visible to this statement
+	defer func(n int) {
+		p.ctes = p.ctes[:n]
+	}(len(p.ctes))
+
+	for {
+		cte, err := p.parseCommonTableExpression(recursive)
+		if err != nil {
+			return nil, err
+		}
+
+		if !recursive {
+			p.ctes = append(p.ctes, cte)
+		}
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	// Parse required SELECT token.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.SELECT {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"SELECT"}, pos)
+	}
+
+	return p.parseSelectStatement()
+}
+
+// parseCommonTableExpression parses: name [(column, ...)] AS (select-stmt).
+// If recursive is true, the expression is visible while parsing its own definition.
+func (p *Parser) parseCommonTableExpression(recursive bool) (*planner.CommonTableExpression, error) {
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	columns, _, err := p.parseFieldList()
+	if err != nil {
+		return nil, err
+	}
+
+	cte := planner.NewCommonTableExpression(name, columns)
+	if recursive {
+		p.ctes = append(p.ctes, cte)
+	}
+
+	// Parse required AS ( SELECT tokens.
+	for _, want := range []scanner.Token{scanner.AS, scanner.LPAREN, scanner.SELECT} {
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != want {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{want.String()}, pos)
+		}
+	}
+
+	tree, err := p.parseSelectStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse required ) token.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	err = cte.Define(tree)
+	if err != nil {
+		return nil, &ParseError{Message: err.Error()}
+	}
+
+	return cte, nil
+}
+
+// lookupCTE returns the visible common table expression with the given name, if any.
+func (p *Parser) lookupCTE(name string) *planner.CommonTableExpression {
+	for i := len(p.ctes) - 1; i >= 0; i-- {
+		if p.ctes[i].Name == name {
+			return p.ctes[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/sql/planner"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/genjidb/genji/sql/scanner"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserWith(t *testing.T) {
+	projection := func(n planner.Node, path, tableName string) *planner.Tree {
+		return planner.NewTree(planner.NewProjectionNode(n,
+			[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, path)), ExprName: path}},
+			tableName))
+	}
+
+	simple := planner.NewCommonTableExpression("foo", nil)
+	require.NoError(t, simple.Define(projection(planner.NewTableInputNode("test"), "a", "test")))
+
+	columns := planner.NewCommonTableExpression("foo", []string{"b"})
+	require.NoError(t, columns.Define(projection(planner.NewTableInputNode("test"), "a", "test")))
+
+	chained := planner.NewCommonTableExpression("bar", nil)
+	require.NoError(t, chained.Define(projection(planner.NewCTEInputNode(simple), "a", "")))
+
+	recursive := planner.NewCommonTableExpression("foo", nil)
+	require.NoError(t, recursive.Define(planner.NewTree(planner.NewSetOperationNode(scanner.UNION, true,
+		projection(planner.NewTableInputNode("test"), "a", "test"),
+		projection(planner.NewCTEInputNode(recursive), "a", ""),
+	))))
+
+	tests := []struct {
+		name     string
+		s        string
+		expected *planner.Tree
+		mustFail bool
+	}{
+		{"Simple", "WITH foo AS (SELECT a FROM test) SELECT a FROM foo",
+			projection(planner.NewCTEInputNode(simple), "a", ""), false},
+		{"With columns", "WITH foo(b) AS (SELECT a FROM test) SELECT b FROM foo",
+			projection(planner.NewCTEInputNode(columns), "b", ""), false},
+		{"Multiple", "WITH foo AS (SELECT a FROM test), bar AS (SELECT a FROM foo) SELECT a FROM bar",
+			projection(planner.NewCTEInputNode(chained), "a", ""), false},
+		{"Recursive", "WITH RECURSIVE foo AS (SELECT a FROM test UNION ALL SELECT a FROM foo) SELECT a FROM foo",
+			projection(planner.NewCTEInputNode(recursive), "a", ""), false},
+		{"Not recursive", "WITH foo AS (SELECT a FROM test UNION ALL SELECT a FROM foo) SELECT a FROM foo",
+			projection(planner.NewCTEInputNode(func() *planner.CommonTableExpression {
+				cte := planner.NewCommonTableExpression("foo", nil)
+				require.NoError(t, cte.Define(planner.NewTree(planner.NewSetOperationNode(scanner.UNION, true,
+					projection(planner.NewTableInputNode("test"), "a", "test"),
+					projection(planner.NewTableInputNode("foo"), "a", "foo"),
+				))))
+				return cte
+			}()), "a", ""), false},
+		{"Invalid recursive", "WITH RECURSIVE foo AS (SELECT a FROM foo) SELECT a FROM foo", nil, true},
+		{"Recursive initial select", "WITH RECURSIVE foo AS (SELECT a FROM foo UNION SELECT a FROM test) SELECT a FROM foo", nil, true},
+		{"Missing AS", "WITH foo (SELECT a FROM test) SELECT a FROM foo", nil, true},
+		{"Missing parentheses", "WITH foo AS SELECT a FROM test SELECT a FROM foo", nil, true},
+		{"Missing statement", "WITH foo AS (SELECT a FROM test)", nil, true},
+		{"Not a select", "WITH foo AS (SELECT a FROM test) DELETE FROM foo", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(test.s)
+			if test.mustFail {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+
+	t.Run("Scope", func(t *testing.T) {
+		q, err := ParseQuery("WITH foo AS (SELECT a FROM test) SELECT a FROM foo; SELECT a FROM foo")
+		require.NoError(t, err)
+		require.Len(t, q.Statements, 2)
+		require.EqualValues(t, projection(planner.NewTableInputNode("foo"), "a", "foo"), q.Statements[1])
+	})
+}
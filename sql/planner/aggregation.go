@@ -1,6 +1,7 @@
 package planner
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -52,21 +53,49 @@ func (n *AggregationNode) String() string {
 	return fmt.Sprintf("Aggregate(%s)", b.String())
 }
 
-// ProjectedGroupAggregatorBuilder references the expression used in the GROUP BY clause
-// so that it can be used in the SELECT clause.
+// ProjectedGroupAggregatorBuilder references one of the expressions used in the GROUP BY clause
+// so that it can be used in the SELECT and HAVING clauses.
+// Once documents are aggregated, it evaluates to the value of the expression for the group.
 type ProjectedGroupAggregatorBuilder struct {
-	Expr     expr.Expr
+	Expr expr.Expr
+	// Index is the position of Expr in the GROUP BY clause.
+	Index    int
 	exprName string
 }
 
 // Aggregator implements the document.AggregatorBuilder interface. It creates a projectedGroupAggregator.
 func (p *ProjectedGroupAggregatorBuilder) Aggregator(group document.Value) document.Aggregator {
+	v := document.NewNullValue()
+	if group.Type == document.ArrayValue {
+		gv, err := group.V.(document.Array).GetByIndex(p.Index)
+		if err == nil {
+			v = gv
+		}
+	}
+
 	return &projectedGroupAggregator{
 		Name:  p.String(),
-		Group: group,
+		Group: v,
 	}
 }
 
+// Eval extracts the value of the group from the aggregated document and returns it.
+func (p *ProjectedGroupAggregatorBuilder) Eval(env *expr.Environment) (document.Value, error) {
+	v, ok := env.GetCurrentValue()
+	if !ok || v.Type != document.DocumentValue {
+		return document.Value{}, errors.New("misuse of GROUP BY expression")
+	}
+
+	return v.V.(document.Document).GetByField(p.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (p *ProjectedGroupAggregatorBuilder) IsEqual(other expr.Expr) bool {
+	o, ok := other.(*ProjectedGroupAggregatorBuilder)
+	return ok && p.Index == o.Index && expr.Equal(p.Expr, o.Expr)
+}
+
 func (p *ProjectedGroupAggregatorBuilder) String() string {
 	if p.exprName == "" {
 		p.exprName = fmt.Sprintf("%v", p.Expr)
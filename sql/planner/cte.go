@@ -0,0 +1,295 @@
+package planner
+
+import (
+	"fmt"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+// A CommonTableExpression is a named temporary result set, defined by a tree,
+// that can be referenced in the FROM clause of a statement.
+// Non-recursive common table expressions are inlined: their tree is evaluated every time they are read.
+// Recursive common table expressions are made of an initial tree and a recursive tree combined
+// with UNION [ALL]. The initial tree is evaluated first, then the recursive tree is evaluated
+// repeatedly, reading the documents returned by the previous iteration, until it doesn't return
+// any new document, or fails after maxRecursionDepth iterations. The result is materialized in memory.
+type CommonTableExpression struct {
+	Name    string
+	Columns []string
+
+	tree          *Tree
+	recursiveTree *Tree
+	all           bool
+
+	binding bool
+	// if true, references to the expression read the working set
+	// instead of evaluating the expression.
+	recursing bool
+	working   []document.Document
+}
+
+// maxRecursionDepth is the maximum number of times the recursive tree of a common table
+// expression is evaluated, which prevents expressions that never stop generating new documents,
+// such as UNION ALL over cyclic data, from consuming all the memory.
+const maxRecursionDepth = 1000
+
+// NewCommonTableExpression creates a common table expression. If columns is not empty,
+// the fields of the documents returned by the expression are renamed accordingly.
+// The expression must be defined using the Define method before being used.
+func NewCommonTableExpression(name string, columns []string) *CommonTableExpression {
+	return &CommonTableExpression{
+		Name:    name,
+		Columns: columns,
+	}
+}
+
+// Define sets the tree of the expression. If the tree references the expression,
+// it must be of the form: initial-select UNION [ALL] recursive-select, where only
+// the recursive select references the expression.
+func (c *CommonTableExpression) Define(t *Tree) error {
+	c.tree = t
+	c.recursiveTree = nil
+
+	if !c.isReferencedBy(t) {
+		return nil
+	}
+
+	sn, ok := t.Root.(*setOperationNode)
+	if !ok || sn.operator != scanner.UNION || c.isReferencedBy(sn.leftTree) {
+		return fmt.Errorf("recursive common table expression %q must be of the form: initial-select UNION [ALL] recursive-select", c.Name)
+	}
+
+	c.tree = sn.leftTree
+	c.recursiveTree = sn.rightTree
+	c.all = sn.all
+	return nil
+}
+
+// isReferencedBy returns true if the tree reads the expression,
+// either directly or using a subquery.
+func (c *CommonTableExpression) isReferencedBy(t *Tree) bool {
+	var found bool
+	inSubquery := func(e expr.Expr) bool {
+		_ = walkSubqueries(e, func(s *SubqueryExpr) error {
+			found = found || c.isReferencedBy(s.Tree)
+			return nil
+		})
+		return found
+	}
+
+	for n := t.Root; n != nil; n = n.Left() {
+		switch t := n.(type) {
+		case *cteInputNode:
+			if t.cte == c {
+				return true
+			}
+		case *setOperationNode:
+			if c.isReferencedBy(t.leftTree) || c.isReferencedBy(t.rightTree) {
+				return true
+			}
+		case *selectionNode:
+			if inSubquery(t.cond) {
+				return true
+			}
+		case *ProjectionNode:
+			for _, e := range t.Expressions {
+				if pe, ok := e.(ProjectedExpr); ok && inSubquery(pe.Expr) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func (c *CommonTableExpression) bind(tx *database.Transaction, params []expr.Param) (err error) {
+	if c.tree == nil {
+		return fmt.Errorf("common table expression %q is not defined", c.Name)
+	}
+
+	// the recursive tree references the expression itself
+	if c.binding {
+		return nil
+	}
+	c.binding = true
+	defer func() {
+		c.binding = false
+	}()
+
+	for _, t := range []**Tree{&c.tree, &c.recursiveTree} {
+		if *t == nil {
+			continue
+		}
+
+		err = Bind(*t, tx, params)
+		if err != nil {
+			return err
+		}
+
+		*t, err = Optimize(*t)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Iterate evaluates the expression and calls fn for every document.
+func (c *CommonTableExpression) Iterate(fn func(d document.Document) error) error {
+	if c.recursing {
+		return document.NewIterator(c.working...).Iterate(fn)
+	}
+
+	if c.recursiveTree == nil {
+		st, err := c.tree.stream()
+		if err != nil {
+			return err
+		}
+
+		return st.Iterate(func(d document.Document) error {
+			d, err := c.rename(d)
+			if err != nil {
+				return err
+			}
+
+			return fn(d)
+		})
+	}
+
+	docs, err := c.materialize()
+	if err != nil {
+		return err
+	}
+
+	return document.NewIterator(docs...).Iterate(fn)
+}
+
+// materialize evaluates a recursive expression and returns every document it generates.
+func (c *CommonTableExpression) materialize() ([]document.Document, error) {
+	var seen *documentHashSet
+	if !c.all {
+		seen = newSpillingDocumentHashSet(setOperationMaxMemoryKeys)
+		defer seen.Close()
+	}
+
+	var docs []document.Document
+	collect := func(t *Tree) ([]document.Document, error) {
+		st, err := t.stream()
+		if err != nil {
+			return nil, err
+		}
+
+		var added []document.Document
+		err = st.Iterate(func(d document.Document) error {
+			d, err := c.rename(d)
+			if err != nil {
+				return err
+			}
+
+			if seen != nil {
+				ok, err := seen.Filter(d)
+				if err != nil || !ok {
+					return err
+				}
+			}
+
+			fb := document.NewFieldBuffer()
+			err = fb.Copy(d)
+			if err != nil {
+				return err
+			}
+
+			added = append(added, fb)
+			return nil
+		})
+		return added, err
+	}
+
+	working, err := collect(c.tree)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		c.recursing = false
+		c.working = nil
+	}()
+
+	for depth := 0; len(working) > 0; depth++ {
+		if depth >= maxRecursionDepth {
+			return nil, fmt.Errorf("common table expression %q: too many recursions, the maximum is %d", c.Name, maxRecursionDepth)
+		}
+
+		docs = append(docs, working...)
+
+		c.recursing = true
+		c.working = working
+		working, err = collect(c.recursiveTree)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return docs, nil
+}
+
+// rename the fields of d using the columns of the expression.
+func (c *CommonTableExpression) rename(d document.Document) (document.Document, error) {
+	if len(c.Columns) == 0 {
+		return d, nil
+	}
+
+	var fb document.FieldBuffer
+	var i int
+	err := d.Iterate(func(_ string, v document.Value) error {
+		if i < len(c.Columns) {
+			fb.Add(c.Columns[i], v)
+		}
+		i++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if i != len(c.Columns) {
+		return nil, fmt.Errorf("common table expression %q has %d values for %d columns", c.Name, i, len(c.Columns))
+	}
+
+	return &fb, nil
+}
+
+type cteInputNode struct {
+	node
+
+	cte *CommonTableExpression
+}
+
+var _ inputNode = (*cteInputNode)(nil)
+
+// NewCTEInputNode creates an input node that reads the documents of a common table expression.
+func NewCTEInputNode(cte *CommonTableExpression) Node {
+	return &cteInputNode{
+		node: node{
+			op: Input,
+		},
+		cte: cte,
+	}
+}
+
+func (n *cteInputNode) Bind(tx *database.Transaction, params []expr.Param) error {
+	return n.cte.bind(tx, params)
+}
+
+func (n *cteInputNode) buildStream() (document.Stream, error) {
+	return document.NewStream(n.cte), nil
+}
+
+func (n *cteInputNode) String() string {
+	return fmt.Sprintf("CTE(%s)", n.cte.Name)
+}
@@ -1,6 +1,8 @@
 package planner
 
 import (
+	"errors"
+
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/query/expr"
@@ -24,7 +26,15 @@ func NewDedupNode(n Node, tableName string) Node {
 }
 
 func (n *dedupNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
+	if n.tableName == "" {
+		return
+	}
+
+	// views have no indexes
 	table, err := tx.GetTable(n.tableName)
+	if errors.Is(err, database.ErrTableNotFound) {
+		return nil
+	}
 	if err != nil {
 		return
 	}
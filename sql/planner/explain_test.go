@@ -28,9 +28,38 @@ func TestExplainStmt(t *testing.T) {
 		{"EXPLAIN UPDATE test SET a = 10", false, `"Table(test) -> Set(a = 10) -> Replace(test)"`},
 		{"EXPLAIN UPDATE test SET a = 10 WHERE c > 10", false, `"Table(test) -> σ(cond: c > 10) -> Set(a = 10) -> Replace(test)"`},
 		{"EXPLAIN UPDATE test SET a = 10 WHERE a > 10", false, `"Index(idx_a) -> Set(a = 10) -> Replace(test)"`},
+		{"EXPLAIN SELECT a, COUNT(*) FROM test GROUP BY a, b HAVING a > 10", false, `"Table(test) -> Group(a, b) -> Aggregate(a, COUNT(*)) -> σ(cond: a > 10) -> ∏(a, COUNT(*))"`},
+		{"EXPLAIN SELECT a FROM test WHERE a > 10 UNION ALL SELECT b AS a FROM test ORDER BY a", false, `"UnionAll(Index(idx_a) -> ∏(a), Table(test) -> ∏(b)) -> Sort(a ASC)"`},
+		{"EXPLAIN SELECT a FROM test INTERSECT SELECT a FROM test WHERE c > 10", false, `"Intersect(Table(test) -> ∏(a), Table(test) -> σ(cond: c > 10) -> ∏(a))"`},
+		{"EXPLAIN WITH foo AS (SELECT a FROM test WHERE a > 10) SELECT a FROM foo", false, `"CTE(foo) -> ∏(a)"`},
+		{"EXPLAIN SELECT a FROM test WHERE c IN (SELECT a FROM test WHERE a > 10)", false, `"Table(test) -> σ(cond: c IN (SELECT a FROM test WHERE a > 10)) -> ∏(a)"`},
 		{"EXPLAIN DELETE FROM test", false, `"Table(test) -> Delete(test)"`},
 		{"EXPLAIN DELETE FROM test WHERE c > 10", false, `"Table(test) -> σ(cond: c > 10) -> Delete(test)"`},
 		{"EXPLAIN DELETE FROM test WHERE a > 10", false, `"Index(idx_a) -> Delete(test)"`},
+		{"EXPLAIN SELECT * FROM test WHERE 'foo' IN c", false, `"Index(idx_c) -> σ(cond: \"foo\" IN c) -> ∏(*)"`},
+		{"EXPLAIN SELECT * FROM test WHERE c[1] = 'foo'", false, `"Index(idx_c) -> σ(cond: c[1] = \"foo\") -> ∏(*)"`},
+		{"EXPLAIN SELECT * FROM test WHERE 'foo' NOT IN c", false, `"Table(test) -> σ(cond: \"foo\" NOT IN c) -> ∏(*)"`},
+		{"EXPLAIN SELECT * FROM test WHERE c = 'foo'", false, `"Table(test) -> σ(cond: c = \"foo\") -> ∏(*)"`},
+		{"EXPLAIN SELECT * FROM test WHERE d > 10", false, `"Table(test) -> σ(cond: d > 10) -> ∏(*)"`},
+		{"EXPLAIN SELECT * FROM test WHERE d > 10 AND e = 'open'", false, `"Index(idx_d) -> σ(cond: e = \"open\") -> ∏(*)"`},
+		{"EXPLAIN SELECT * FROM test WHERE e = 'closed' AND d > 10", false, `"Table(test) -> σ(cond: d > 10) -> σ(cond: e = \"closed\") -> ∏(*)"`},
+		{"EXPLAIN SELECT * FROM test WHERE lower(f) = 'foo'", false, `"Index(idx_f) -> ∏(*)"`},
+		{"EXPLAIN SELECT * FROM test WHERE 'foo' = lower(f)", false, `"Index(idx_f) -> ∏(*)"`},
+		{"EXPLAIN SELECT * FROM test WHERE lower(f) > 'foo'", false, `"Index(idx_f) -> ∏(*)"`},
+		{"EXPLAIN SELECT * FROM test WHERE upper(f) = 'foo'", false, `"Table(test) -> σ(cond: upper(f) = \"foo\") -> ∏(*)"`},
+		{"EXPLAIN SELECT g, h.i FROM test WHERE g > 10 AND h.i < 5 ORDER BY j", false, `"IndexOnly(idx_g) -> σ(cond: h.i < 5) -> ∏(g, h.i) -> Sort(j ASC)"`},
+		{"EXPLAIN SELECT * FROM test WHERE l MATCH 'foo'", false, `"Index(idx_l) -> ∏(*)"`},
+		{"EXPLAIN SELECT * FROM test WHERE l MATCH 'foo' AND b = 1", false, `"Index(idx_l) -> σ(cond: b = 1) -> ∏(*)"`},
+		{"EXPLAIN SELECT * FROM test WHERE l = 'foo'", false, `"Table(test) -> σ(cond: l = \"foo\") -> ∏(*)"`},
+		{"EXPLAIN SELECT * FROM test WHERE a MATCH 'foo'", false, `"Table(test) -> σ(cond: a MATCH \"foo\") -> ∏(*)"`},
+		{"EXPLAIN SELECT g FROM test WHERE g > 10 AND k < 5", false, `"Index(idx_g) -> σ(cond: k < 5) -> ∏(g)"`},
+		{"EXPLAIN SELECT * FROM test WHERE g > 10", false, `"Index(idx_g) -> ∏(*)"`},
+		{"EXPLAIN UPDATE test SET j = 1 WHERE g > 10", false, `"Index(idx_g) -> Set(j = 1) -> Replace(test)"`},
+		{"EXPLAIN SELECT g, row_number() OVER (PARTITION BY b ORDER BY g DESC) FROM test WHERE g > 10", false, `"Index(idx_g) -> Window(row_number() OVER (PARTITION BY b ORDER BY g DESC)) -> ∏(g, row_number() OVER (PARTITION BY b ORDER BY g DESC))"`},
+		{"EXPLAIN SELECT * FROM v", false, `"Table(test) -> σ(cond: c > 10) -> ∏(a, b, c) -> ∏(*)"`},
+		{"EXPLAIN SELECT * FROM v WHERE a > 10", false, `"Index(idx_a) -> σ(cond: c > 10) -> ∏(a, b, c) -> ∏(*)"`},
+		{"EXPLAIN SELECT a FROM v WHERE a > 10 AND x = 1", false, `"Index(idx_a) -> σ(cond: c > 10) -> ∏(a, b, c) -> σ(cond: x = 1) -> ∏(a)"`},
+		{"EXPLAIN SELECT * FROM vv WHERE a = 1 ORDER BY x", false, `"Index(idx_a) -> σ(cond: c > 10) -> ∏(a, b, c) -> σ(cond: x > 1) -> ∏(*) -> ∏(*) -> Sort(x ASC)"`},
 	}
 
 	for _, test := range tests {
@@ -44,6 +73,13 @@ func TestExplainStmt(t *testing.T) {
 			err = db.Exec(`
 						CREATE INDEX idx_a ON test (a);
 						CREATE UNIQUE INDEX idx_b ON test (b);
+						CREATE INDEX idx_c ON test (c[*]);
+						CREATE INDEX idx_d ON test (d) WHERE e = 'open';
+						CREATE INDEX idx_f ON test (lower(f));
+						CREATE INDEX idx_g ON test (g) INCLUDE (h, j);
+						CREATE FULLTEXT INDEX idx_l ON test (l);
+						CREATE VIEW v AS SELECT a, b AS x, c FROM test WHERE c > 10;
+						CREATE VIEW vv AS SELECT * FROM v WHERE x > 1;
 					`)
 			require.NoError(t, err)
 
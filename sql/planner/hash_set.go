@@ -1,8 +1,12 @@
 package planner
 
 import (
+	"encoding/binary"
 	"hash"
 	"hash/maphash"
+	"io/ioutil"
+	"os"
+	"sort"
 
 	"github.com/genjidb/genji/document"
 )
@@ -10,6 +14,11 @@ import (
 type documentHashSet struct {
 	hash hash.Hash64
 	set  map[uint64]struct{}
+
+	// if maxSize is greater than zero, keys are moved to a temporary file
+	// every time the set contains maxSize keys in memory.
+	maxSize int
+	runs    []*hashRun
 }
 
 func newDocumentHashSet(hash hash.Hash64) *documentHashSet {
@@ -23,7 +32,16 @@ func newDocumentHashSet(hash hash.Hash64) *documentHashSet {
 	}
 }
 
-func (s documentHashSet) generateKey(d document.Document) (uint64, error) {
+// newSpillingDocumentHashSet creates a set that keeps at most maxSize keys in memory
+// and stores the other ones on disk. The set must be closed after use to remove
+// the temporary files.
+func newSpillingDocumentHashSet(maxSize int) *documentHashSet {
+	s := newDocumentHashSet(nil)
+	s.maxSize = maxSize
+	return s
+}
+
+func (s *documentHashSet) generateKey(d document.Document) (uint64, error) {
 	defer s.hash.Reset()
 
 	fields, err := document.Fields(d)
@@ -39,6 +57,14 @@ func (s documentHashSet) generateKey(d document.Document) (uint64, error) {
 			return 0, err
 		}
 
+		// integers are converted to doubles so that 1 and 1.0 are considered equal
+		if value.Type == document.IntegerValue {
+			value, err = value.CastAsDouble()
+			if err != nil {
+				return 0, err
+			}
+		}
+
 		err = enc.Encode(value)
 		if err != nil {
 			return 0, err
@@ -48,17 +74,145 @@ func (s documentHashSet) generateKey(d document.Document) (uint64, error) {
 	return s.hash.Sum64(), nil
 }
 
-func (s documentHashSet) Filter(d document.Document) (bool, error) {
+// Filter adds d to the set and returns true if it wasn't already part of it.
+func (s *documentHashSet) Filter(d document.Document) (bool, error) {
 	k, err := s.generateKey(d)
 	if err != nil {
 		return false, err
 	}
 
-	_, ok := s.set[k]
-	if ok {
-		return false, nil
+	ok, err := s.has(k)
+	if err != nil || ok {
+		return false, err
 	}
 
+	return true, s.add(k)
+}
+
+// Add adds d to the set.
+func (s *documentHashSet) Add(d document.Document) error {
+	_, err := s.Filter(d)
+	return err
+}
+
+// Contains returns true if d is part of the set.
+func (s *documentHashSet) Contains(d document.Document) (bool, error) {
+	k, err := s.generateKey(d)
+	if err != nil {
+		return false, err
+	}
+
+	return s.has(k)
+}
+
+func (s *documentHashSet) has(k uint64) (bool, error) {
+	if _, ok := s.set[k]; ok {
+		return true, nil
+	}
+
+	for _, r := range s.runs {
+		ok, err := r.has(k)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+func (s *documentHashSet) add(k uint64) error {
 	s.set[k] = struct{}{}
-	return true, nil
+
+	if s.maxSize <= 0 || len(s.set) < s.maxSize {
+		return nil
+	}
+
+	r, err := newHashRun(s.set)
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, r)
+	s.set = map[uint64]struct{}{}
+
+	return nil
+}
+
+// Close removes the temporary files created by the set.
+func (s *documentHashSet) Close() error {
+	var err error
+	for _, r := range s.runs {
+		if cerr := r.close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	s.runs = nil
+
+	return err
+}
+
+// A hashRun is a temporary file containing a sorted list of keys.
+// Each key is encoded on 8 bytes, which allows to look for a key using a binary search.
+type hashRun struct {
+	f *os.File
+	n int
+}
+
+func newHashRun(set map[uint64]struct{}) (*hashRun, error) {
+	keys := make([]uint64, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	buf := make([]byte, 8*len(keys))
+	for i, k := range keys {
+		binary.BigEndian.PutUint64(buf[i*8:], k)
+	}
+
+	f, err := ioutil.TempFile("", "genji-hashset-")
+	if err != nil {
+		return nil, err
+	}
+
+	r := hashRun{f: f, n: len(keys)}
+	_, err = f.Write(buf)
+	if err != nil {
+		r.close()
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func (r *hashRun) has(k uint64) (bool, error) {
+	var buf [8]byte
+	var err error
+
+	i := sort.Search(r.n, func(i int) bool {
+		if err != nil {
+			return true
+		}
+
+		_, err = r.f.ReadAt(buf[:], int64(i)*8)
+		return binary.BigEndian.Uint64(buf[:]) >= k
+	})
+	if err != nil || i == r.n {
+		return false, err
+	}
+
+	_, err = r.f.ReadAt(buf[:], int64(i)*8)
+	if err != nil {
+		return false, err
+	}
+
+	return binary.BigEndian.Uint64(buf[:]) == k, nil
+}
+
+func (r *hashRun) close() error {
+	err := r.f.Close()
+	if rerr := os.Remove(r.f.Name()); rerr != nil && err == nil {
+		err = rerr
+	}
+
+	return err
 }
@@ -0,0 +1,44 @@
+package planner
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentHashSetSpill(t *testing.T) {
+	s := newSpillingDocumentHashSet(10)
+
+	doc := func(i int) document.Document {
+		return document.NewFieldBuffer().Add("a", document.NewIntegerValue(int64(i)))
+	}
+
+	for i := 0; i < 100; i++ {
+		ok, err := s.Filter(doc(i))
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	require.Len(t, s.runs, 10)
+
+	for i := 0; i < 100; i++ {
+		ok, err := s.Filter(doc(i))
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+
+	ok, err := s.Contains(doc(100))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	names := make([]string, len(s.runs))
+	for i, r := range s.runs {
+		names[i] = r.f.Name()
+	}
+
+	require.NoError(t, s.Close())
+	for _, name := range names {
+		require.NoFileExists(t, name)
+	}
+}
@@ -3,6 +3,8 @@ package planner
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
@@ -18,12 +20,18 @@ type tableInputNode struct {
 	indexes   map[string]database.Index
 	tx        *database.Transaction
 	params    []expr.Param
+
+	// if the name refers to a view, tree of the query of the view
+	view *Tree
+	// names of the views being expanded when the node was created,
+	// used to detect views that reference themselves.
+	expanding []string
 }
 
 var _ inputNode = (*tableInputNode)(nil)
 
 // NewTableInputNode creates an input node that can be used to read documents
-// from a table.
+// from a table or a view.
 func NewTableInputNode(tableName string) Node {
 	return &tableInputNode{
 		node: node{
@@ -37,6 +45,11 @@ func (n *tableInputNode) Bind(tx *database.Transaction, params []expr.Param) (er
 	n.tx = tx
 	n.params = params
 	n.table, err = tx.GetTable(n.tableName)
+	if errors.Is(err, database.ErrTableNotFound) {
+		if verr := n.bindView(tx, params); verr != database.ErrViewNotFound {
+			return verr
+		}
+	}
 	if err != nil {
 		return err
 	}
@@ -44,12 +57,83 @@ func (n *tableInputNode) Bind(tx *database.Transaction, params []expr.Param) (er
 	return
 }
 
+// bindView replaces the table by the tree of the view with the same name, if any.
+// The tree is bound independently of the tree of the node, and inlined in it by the optimizer.
+// It returns database.ErrViewNotFound if there is no such view, and an error if the view
+// references itself, directly or through other views.
+func (n *tableInputNode) bindView(tx *database.Transaction, params []expr.Param) error {
+	for _, name := range n.expanding {
+		if name == n.tableName {
+			return fmt.Errorf("view %q references itself: %s -> %s", n.tableName, strings.Join(n.expanding, " -> "), n.tableName)
+		}
+	}
+
+	q, err := tx.ParseView(n.tableName)
+	if errors.Is(err, database.ErrViewNotFound) {
+		return database.ErrViewNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	t, ok := q.(*Tree)
+	if !ok {
+		return fmt.Errorf("invalid query for view %q", n.tableName)
+	}
+
+	expanding := make([]string, len(n.expanding), len(n.expanding)+1)
+	copy(expanding, n.expanding)
+	expanding = append(expanding, n.tableName)
+	_ = walkTree(t, func(n Node) error {
+		if in, ok := n.(*tableInputNode); ok {
+			in.expanding = expanding
+		}
+		return nil
+	})
+
+	err = Bind(t, tx, params)
+	if err != nil {
+		return err
+	}
+
+	n.view = t
+	return nil
+}
+
 func (n *tableInputNode) String() string {
+	if n.view != nil {
+		return fmt.Sprintf("View(%s)", n.tableName)
+	}
+
 	return fmt.Sprintf("Table(%s)", n.tableName)
 }
 
 func (n *tableInputNode) buildStream() (document.Stream, error) {
-	return document.NewStream(n.table), nil
+	if n.view != nil {
+		return n.view.stream()
+	}
+
+	st := document.NewStream(n.table)
+
+	info, err := n.table.Info()
+	if err != nil {
+		return st, err
+	}
+
+	if info.TTL != nil {
+		st = st.Filter(notExpired(info, time.Now()))
+	}
+
+	return st, nil
+}
+
+// notExpired returns a filter ignoring the documents that have expired
+// but have not been deleted yet.
+func notExpired(info *database.TableInfo, now time.Time) func(d document.Document) (bool, error) {
+	return func(d document.Document) (bool, error) {
+		expired, err := info.IsExpired(d, now)
+		return !expired, err
+	}
 }
 
 type indexInputNode struct {
@@ -67,6 +151,12 @@ type indexInputNode struct {
 	filter           expr.Expr
 	evaluatedFilter  document.Value
 	orderByDirection scanner.Token
+
+	// if set to true, documents are read from the fields stored in the index
+	indexOnly bool
+
+	// if set to true, the table has a TTL field
+	ttl bool
 }
 
 var _ inputNode = (*indexInputNode)(nil)
@@ -104,6 +194,19 @@ func (n *indexInputNode) Bind(tx *database.Transaction, params []expr.Param) (er
 	n.tx = tx
 	n.params = params
 
+	info, err := n.table.Info()
+	if err != nil {
+		return err
+	}
+	n.ttl = info.TTL != nil
+
+	// expired documents are ignored using their TTL field,
+	// which must be stored in the index to read documents from it.
+	if n.ttl && n.indexOnly && !storesField(n.index, info.TTL[0].FieldName) {
+		n.indexOnly = false
+	}
+	n.index.IndexOnly = n.indexOnly
+
 	// evaluate the filter expression
 	n.evaluatedFilter, err = n.filter.Eval(&expr.Environment{
 		Params: n.params,
@@ -113,15 +216,11 @@ func (n *indexInputNode) Bind(tx *database.Transaction, params []expr.Param) (er
 	}
 
 	// if the indexed field has no constraint and the filter is an int, cast that int to a double.
+	// elements of arrays are never typed, unless the constraint targets a specific element.
 	if n.evaluatedFilter.Type == document.IntegerValue {
-		info, err := n.table.Info()
-		if err != nil {
-			return err
-		}
-
 		shouldBeConverted := true
 		for _, fc := range info.FieldConstraints {
-			if fc.Path.IsEqual(n.path) && fc.Type != 0 {
+			if !n.index.Opts.ArrayElements && fc.Path.IsEqual(n.path) && fc.Type != 0 {
 				shouldBeConverted = false
 				break
 			}
@@ -138,7 +237,7 @@ func (n *indexInputNode) Bind(tx *database.Transaction, params []expr.Param) (er
 }
 
 func (n *indexInputNode) buildStream() (document.Stream, error) {
-	return document.NewStream(&indexIterator{
+	st := document.NewStream(&indexIterator{
 		tx:     n.tx,
 		tb:     n.table,
 		params: n.params,
@@ -146,10 +245,36 @@ func (n *indexInputNode) buildStream() (document.Stream, error) {
 		path:   n.path,
 		filter: n.evaluatedFilter,
 		iop:    n.iop,
-	}), nil
+	})
+
+	if n.ttl {
+		info, err := n.table.Info()
+		if err != nil {
+			return st, err
+		}
+
+		st = st.Filter(notExpired(info, time.Now()))
+	}
+
+	return st, nil
+}
+
+// storesField returns true if the entries of idx contain the given top-level field.
+func storesField(idx *database.Index, field string) bool {
+	for _, f := range idx.StoredFields() {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (n *indexInputNode) String() string {
+	if n.indexOnly {
+		return fmt.Sprintf("IndexOnly(%s)", n.indexName)
+	}
+
 	return fmt.Sprintf("Index(%s)", n.indexName)
 }
 
@@ -178,7 +303,7 @@ func (it indexIterator) Iterate(fn func(d document.Document) error) error {
 
 		if it.orderByDirection == scanner.DESC {
 			err = it.index.DescendLessOrEqual(document.Value{}, func(val, key []byte, isEqual bool) error {
-				d, err := it.tb.GetDocument(key)
+				d, err := it.index.GetDocument(it.tb, key)
 				if err != nil {
 					return err
 				}
@@ -187,7 +312,7 @@ func (it indexIterator) Iterate(fn func(d document.Document) error) error {
 			})
 		} else {
 			err = it.index.AscendGreaterOrEqual(document.Value{}, func(val, key []byte, isEqual bool) error {
-				d, err := it.tb.GetDocument(key)
+				d, err := it.index.GetDocument(it.tb, key)
 				if err != nil {
 					return err
 				}
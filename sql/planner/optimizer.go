@@ -8,11 +8,13 @@ import (
 )
 
 var optimizerRules = []func(t *Tree) (*Tree, error){
+	InlineViewRule,
 	SplitANDConditionRule,
 	PrecalculateExprRule,
 	RemoveUnnecessarySelectionNodesRule,
 	RemoveUnnecessaryDedupNodeRule,
 	UseIndexBasedOnSelectionNodeRule,
+	UseIndexOnlyScanRule,
 }
 
 // Optimize takes a tree, applies a list of optimization rules
@@ -32,6 +34,132 @@ func Optimize(t *Tree) (*Tree, error) {
 	return t, nil
 }
 
+// InlineViewRule replaces the input node of the tree by the tree of the view it reads, if any,
+// so that the other rules can optimize both trees as a whole.
+// The conditions of the selection nodes located right above the projection node of the view
+// are then moved below it if it doesn't modify the fields they read, which allows
+// using the indexes of the table of the view.
+// Example:
+//   this:
+//     View(v) -> σ(a > 2) -> ∏(*)
+//     with v: Table(t) -> σ(b = 1) -> ∏(a, b)
+//   becomes this:
+//     Table(t) -> σ(b = 1) -> σ(a > 2) -> ∏(a, b) -> ∏(*)
+func InlineViewRule(t *Tree) (*Tree, error) {
+	var inlined bool
+
+	for {
+		var prev Node
+		n := t.Root
+		for n != nil && n.Operation() != Input {
+			prev = n
+			n = n.Left()
+		}
+
+		inpn, ok := n.(*tableInputNode)
+		if !ok || inpn.view == nil {
+			break
+		}
+
+		if prev == nil {
+			t.Root = inpn.view.Root
+		} else {
+			prev.SetLeft(inpn.view.Root)
+		}
+		inlined = true
+	}
+
+	if !inlined {
+		return t, nil
+	}
+
+	// split the conditions first, to move as many of them as possible.
+	t, err := SplitANDConditionRule(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var prev Node
+	n := t.Root
+	for n != nil {
+		sn, ok := n.(*selectionNode)
+		if !ok {
+			prev = n
+			n = n.Left()
+			continue
+		}
+
+		// move the selection node below the projection nodes and the other
+		// selection nodes located below it, as long as the projections
+		// return the fields read by the condition unchanged.
+		paths, ok := exprPaths(sn.cond)
+		var target Node
+		for cur := sn.Left(); ok && cur != nil; cur = cur.Left() {
+			if cur.Operation() == Selection {
+				continue
+			}
+
+			pn, isProjection := cur.(*ProjectionNode)
+			if !isProjection || !projectsPaths(pn, paths) {
+				break
+			}
+			target = cur
+		}
+
+		next := sn.Left()
+		if target != nil {
+			if prev == nil {
+				t.Root = next
+			} else {
+				prev.SetLeft(next)
+			}
+			sn.SetLeft(target.Left())
+			target.SetLeft(sn)
+		} else {
+			prev = n
+		}
+		n = next
+	}
+
+	return t, nil
+}
+
+// projectsPaths returns true if every path is read from a field that the projection
+// returns unchanged, either using a wildcard or an expression of the same name
+// that only reads that field.
+func projectsPaths(pn *ProjectionNode, paths []document.Path) bool {
+	for _, p := range paths {
+		var found bool
+		for _, f := range pn.Expressions {
+			switch t := f.(type) {
+			case Wildcard:
+				found = true
+				continue
+			case ProjectedExpr:
+				if t.ExprName != p[0].FieldName {
+					continue
+				}
+
+				ep, ok := t.Expr.(expr.Path)
+				if !ok || len(ep) != 1 || ep[0].FieldName != p[0].FieldName {
+					return false
+				}
+				found = true
+			default:
+				if f.Name() == p[0].FieldName {
+					return false
+				}
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
 // SplitANDConditionRule splits any selection node whose condition
 // is one or more AND operators into one or more selection nodes.
 // The condition won't be split if the expression tree contains an OR
@@ -285,6 +413,11 @@ func RemoveUnnecessaryDedupNodeRule(t *Tree) (*Tree, error) {
 }
 
 func isProjectionUnique(indexes map[string]database.Index, pn *ProjectionNode) bool {
+	// views have no table info, nothing is known about the uniqueness of their fields
+	if pn.info == nil {
+		return false
+	}
+
 	pk := pn.info.GetPrimaryKey()
 	for _, field := range pn.Expressions {
 		e, ok := field.(ProjectedExpr)
@@ -336,28 +469,52 @@ func UseIndexBasedOnSelectionNodeRule(t *Tree) (*Tree, error) {
 		return t, nil
 	}
 
-	// Here we will assume that at this point
-	// inputNodes can only be instances of tableInputNode.
-	inpn := inputNode.(*tableInputNode)
+	// indexes can only be used when reading from a table.
+	inpn, ok := inputNode.(*tableInputNode)
+	if !ok {
+		return t, nil
+	}
 
 	type candidate struct {
 		prevNode, nextNode Node
 		in                 *indexInputNode
+		keepSelection      bool
 	}
 
 	var candidates []candidate
 
+	// partial indexes can only be used if their predicate
+	// is implied by the conditions of the selection nodes
+	// selection nodes located above an aggregation or a projection node filter
+	// aggregated or projected documents and cannot use an index.
+	var conds []expr.Expr
+	for n = t.Root; n != nil; n = n.Left() {
+		if n.Operation() == Aggregation || n.Operation() == Projection {
+			conds = nil
+		}
+		if n.Operation() == Selection {
+			if sn := n.(*selectionNode); sn.cond != nil {
+				conds = append(conds, sn.cond)
+			}
+		}
+	}
+	indexes := usableIndexes(inpn.indexes, conds)
+
 	n = t.Root
 	// look for all selection nodes that satisfy our requirements
 	for n != nil {
+		if n.Operation() == Aggregation || n.Operation() == Projection {
+			candidates = nil
+		}
 		if n.Operation() == Selection {
 			sn := n.(*selectionNode)
-			indexedNode := selectionNodeValidForIndex(sn, inpn.tableName, inpn.indexes)
+			indexedNode, keepSelection := selectionNodeValidForIndex(sn, inpn.tableName, indexes)
 			if indexedNode != nil {
 				candidates = append(candidates, candidate{
-					prevNode: prev,
-					nextNode: n.Left(),
-					in:       indexedNode,
+					prevNode:      prev,
+					nextNode:      n.Left(),
+					in:            indexedNode,
+					keepSelection: keepSelection,
 				})
 			}
 		}
@@ -369,6 +526,8 @@ func UseIndexBasedOnSelectionNodeRule(t *Tree) (*Tree, error) {
 	// determine which index is the most interesting and replace it in the tree.
 	// we will assume that unique indexes are more interesting than list indexes
 	// because they usually have less elements.
+	// full-text indexes are always preferred since they are the only ones
+	// able to compute the relevance of the documents.
 	var selectedCandidate *candidate
 
 	for i, candidate := range candidates {
@@ -377,10 +536,14 @@ func UseIndexBasedOnSelectionNodeRule(t *Tree) (*Tree, error) {
 			continue
 		}
 
-		// if the candidate's related index is a unique index,
+		if selectedCandidate.in.index.FullText() != nil {
+			continue
+		}
+
+		// if the candidate's related index is a unique or full-text index,
 		// select it.
 		idx := candidate.in.index
-		if idx.Unique {
+		if idx.Unique || idx.FullText() != nil {
 			selectedCandidate = &candidates[i]
 		}
 	}
@@ -394,11 +557,14 @@ func UseIndexBasedOnSelectionNodeRule(t *Tree) (*Tree, error) {
 		return nil, err
 	}
 
-	// we remove the selection node from the tree
-	if selectedCandidate.prevNode == nil {
-		t.Root = selectedCandidate.nextNode
-	} else {
-		selectedCandidate.prevNode.SetLeft(selectedCandidate.nextNode)
+	// we remove the selection node from the tree,
+	// unless the index can return documents that don't match its condition.
+	if !selectedCandidate.keepSelection {
+		if selectedCandidate.prevNode == nil {
+			t.Root = selectedCandidate.nextNode
+		} else {
+			selectedCandidate.prevNode.SetLeft(selectedCandidate.nextNode)
+		}
 	}
 
 	n = t.Root
@@ -423,40 +589,298 @@ func UseIndexBasedOnSelectionNodeRule(t *Tree) (*Tree, error) {
 	return t, nil
 }
 
-func selectionNodeValidForIndex(sn *selectionNode, tableName string, indexes map[string]database.Index) *indexInputNode {
+// UseIndexOnlyScanRule turns the index input node of the tree into an index-only scan
+// if the index stores every field read by the query.
+// Documents are then built from the fields stored in the index instead of being
+// read from the table.
+// It only applies to trees made of projection, selection, sort, limit, offset and dedup nodes
+// whose expressions only read paths whose first field is stored in the index.
+func UseIndexOnlyScanRule(t *Tree) (*Tree, error) {
+	var in *indexInputNode
+	var projected bool
+	var paths []document.Path
+	for n := t.Root; n != nil; n = n.Left() {
+		switch n.Operation() {
+		case Projection:
+			projected = true
+			for _, f := range n.(*ProjectionNode).Expressions {
+				pe, ok := f.(ProjectedExpr)
+				if !ok {
+					return t, nil
+				}
+
+				ps, ok := exprPaths(pe.Expr)
+				if !ok {
+					return t, nil
+				}
+				paths = append(paths, ps...)
+			}
+		case Selection:
+			ps, ok := exprPaths(n.(*selectionNode).cond)
+			if !ok {
+				return t, nil
+			}
+			paths = append(paths, ps...)
+		case Sort:
+			ps, ok := exprPaths(n.(*sortNode).sortField)
+			if !ok {
+				return t, nil
+			}
+			paths = append(paths, ps...)
+		case Limit, Skip, Dedup:
+		case Input:
+			var ok bool
+			in, ok = n.(*indexInputNode)
+			if !ok {
+				return t, nil
+			}
+		default:
+			return t, nil
+		}
+	}
+
+	if in == nil || !projected {
+		return t, nil
+	}
+
+	fields := in.index.StoredFields()
+	if fields == nil {
+		return t, nil
+	}
+
+	for _, p := range paths {
+		var found bool
+		for _, f := range fields {
+			if p[0].FieldName == f {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return t, nil
+		}
+	}
+
+	in.indexOnly = true
+	in.index.IndexOnly = true
+
+	return t, nil
+}
+
+// exprPaths returns the paths read by e.
+// It returns false if e contains an expression it doesn't know about,
+// in which case the paths it reads cannot be determined.
+func exprPaths(e expr.Expr) ([]document.Path, bool) {
+	switch t := e.(type) {
+	case expr.Path:
+		return []document.Path{document.Path(t)}, true
+	case expr.LiteralValue, expr.NamedParam, expr.PositionalParam:
+		return nil, true
+	case expr.Parentheses:
+		return exprPaths(t.E)
+	case expr.LiteralExprList:
+		var paths []document.Path
+		for _, e := range t {
+			ps, ok := exprPaths(e)
+			if !ok {
+				return nil, false
+			}
+			paths = append(paths, ps...)
+		}
+		return paths, true
+	case expr.Operator:
+		lp, ok := exprPaths(t.LeftHand())
+		if !ok {
+			return nil, false
+		}
+		rp, ok := exprPaths(t.RightHand())
+		if !ok {
+			return nil, false
+		}
+		return append(lp, rp...), true
+	}
+
+	return nil, false
+}
+
+// usableIndexes returns the indexes that can be used to evaluate the given conditions,
+// keyed by the path they index.
+// A partial index is only usable if the conditions imply its predicate,
+// i.e. if each operand of the AND operators of the predicate is one of the conditions.
+// Usable partial indexes are preferred over regular indexes on the same path
+// since they contain less entries.
+func usableIndexes(indexes map[string]database.Index, conds []expr.Expr) map[string]database.Index {
+	m := make(map[string]database.Index, len(indexes))
+
+	for _, idx := range indexes {
+		key := idx.Opts.Key()
+		if idx.Opts.FullText {
+			key = "FULLTEXT " + key
+		}
+
+		if idx.Opts.Predicate == "" {
+			if _, ok := m[key]; !ok {
+				m[key] = idx
+			}
+			continue
+		}
+
+		if predicateImplied(idx.Predicate(), conds) {
+			m[key] = idx
+		}
+	}
+
+	return m
+}
+
+// predicateImplied reports whether the predicate of a partial index
+// is implied by the given conditions.
+func predicateImplied(predicate database.Expr, conds []expr.Expr) bool {
+	de, ok := predicate.(expr.DocumentExpr)
+	if !ok {
+		return false
+	}
+
+	for _, e := range splitANDExpr(de.E) {
+		// conditions are precalculated, the predicate must be as well
+		e = precalculateExpr(e)
+
+		var found bool
+		for _, cond := range conds {
+			if expr.Equal(e, cond) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// selectionNodeValidForIndex returns an index input node that can replace the table input node
+// if the condition of sn can be evaluated using one of the given indexes.
+// The returned boolean reports whether the selection node must be kept to filter
+// the documents returned by the index.
+func selectionNodeValidForIndex(sn *selectionNode, tableName string, indexes map[string]database.Index) (*indexInputNode, bool) {
 	if sn.cond == nil {
-		return nil
+		return nil, false
 	}
 
 	// the root of the condition must be an operator
 	op, ok := sn.cond.(expr.Operator)
 	if !ok {
-		return nil
+		return nil, false
 	}
 
 	// determine if the operator can read from the index
 	iop, ok := op.(IndexIteratorOperator)
 	if !ok {
-		return nil
+		return nil, false
+	}
+
+	// path MATCH expr can only be evaluated using a full-text index on path
+	if expr.IsMatchOperator(op) {
+		path, ok := op.LeftHand().(expr.Path)
+		if !ok || !isLiteralOrParam(op.RightHand()) {
+			return nil, false
+		}
+
+		idx, ok := indexes["FULLTEXT "+path.String()]
+		if !ok {
+			return nil, false
+		}
+
+		in := NewIndexInputNode(tableName, idx.Opts.IndexName, iop, path, op.RightHand(), scanner.ASC).(*indexInputNode)
+		in.index = &idx
+
+		return in, false
+	}
+
+	// expr IN path can be evaluated using an index on the elements of path
+	if rf, ok := op.RightHand().(expr.Path); ok && expr.IsInOperator(op) {
+		if !isLiteralOrParam(op.LeftHand()) {
+			return nil, false
+		}
+
+		return arrayElementsIndexInputNode(tableName, indexes, rf, op.LeftHand()), true
 	}
 
 	// determine if the operator can benefit from an index
 	ok, path, e := opCanUseIndex(op)
 	if !ok {
-		return nil
+		return exprIndexInputNode(op, iop, tableName, indexes), false
 	}
 
 	// analyse the other operand to make sure it's a literal or a param
 	if !isLiteralOrParam(e) {
-		return nil
+		return nil, false
 	}
 
 	// now, we look if an index exists for that path
 	idx, ok := indexes[path.String()]
+	if !ok {
+		// path[i] = expr can be evaluated using an index on the elements of path
+		if last := len(path) - 1; op.Token() == scanner.EQ && last > 0 && path[last].FieldName == "" {
+			return arrayElementsIndexInputNode(tableName, indexes, path[:last], e), true
+		}
+
+		return nil, false
+	}
+
+	in := NewIndexInputNode(tableName, idx.Opts.IndexName, iop, path, e, scanner.ASC).(*indexInputNode)
+	in.index = &idx
+
+	return in, false
+}
+
+// exprIndexInputNode returns an index input node if one of the operands of op
+// is an indexed expression and the other one is a literal or a param.
+// Indexed expressions are compared to the operands using expr.Equal.
+func exprIndexInputNode(op expr.Operator, iop IndexIteratorOperator, tableName string, indexes map[string]database.Index) *indexInputNode {
+	for _, idx := range indexes {
+		de, ok := idx.Expr().(expr.DocumentExpr)
+		if !ok {
+			continue
+		}
+
+		var e expr.Expr
+		switch {
+		// expr OP value
+		case expr.Equal(de.E, op.LeftHand()) && isLiteralOrParam(op.RightHand()):
+			e = op.RightHand()
+		// value = expr
+		case op.Token() == scanner.EQ && expr.Equal(de.E, op.RightHand()) && isLiteralOrParam(op.LeftHand()):
+			e = op.LeftHand()
+		default:
+			continue
+		}
+
+		idx := idx
+		in := NewIndexInputNode(tableName, idx.Opts.IndexName, iop, nil, e, scanner.ASC).(*indexInputNode)
+		in.index = &idx
+
+		return in
+	}
+
+	return nil
+}
+
+// arrayElementsIndexInputNode returns an index input node that looks up e
+// in the index on the elements of the array found at path, if it exists.
+// Such an index returns every document whose array contains e, at any position,
+// which is why the selection node must be kept when using it.
+func arrayElementsIndexInputNode(tableName string, indexes map[string]database.Index, path expr.Path, e expr.Expr) *indexInputNode {
+	idx, ok := indexes[path.String()+"[*]"]
 	if !ok {
 		return nil
 	}
 
+	iop := expr.Eq(path, e).(IndexIteratorOperator)
 	in := NewIndexInputNode(tableName, idx.Opts.IndexName, iop, path, e, scanner.ASC).(*indexInputNode)
 	in.index = &idx
 
@@ -19,8 +19,9 @@ type ProjectionNode struct {
 	Expressions []ProjectedField
 	tableName   string
 
-	info *database.TableInfo
-	tx   *database.Transaction
+	info   *database.TableInfo
+	tx     *database.Transaction
+	params []expr.Param
 }
 
 var _ operationNode = (*ProjectionNode)(nil)
@@ -40,11 +41,25 @@ func NewProjectionNode(n Node, expressions []ProjectedField, tableName string) N
 // Bind database resources to this node.
 func (n *ProjectionNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
 	n.tx = tx
+	n.params = params
+	for _, e := range n.Expressions {
+		if pe, ok := e.(ProjectedExpr); ok {
+			err = bindSubqueries(pe.Expr, tx, params)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	if n.tableName == "" {
 		return
 	}
 
+	// views have no table info
 	table, err := tx.GetTable(n.tableName)
+	if errors.Is(err, database.ErrTableNotFound) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
@@ -54,9 +69,17 @@ func (n *ProjectionNode) Bind(tx *database.Transaction, params []expr.Param) (er
 }
 
 func (n *ProjectionNode) toStream(st document.Stream) (document.Stream, error) {
+	for _, e := range n.Expressions {
+		if pe, ok := e.(ProjectedExpr); ok {
+			resetSubqueries(pe.Expr)
+		}
+	}
+
 	if st.IsEmpty() {
 		d := documentMask{
 			resultFields: n.Expressions,
+			params:       n.params,
+			tx:           n.tx,
 		}
 		var fb document.FieldBuffer
 		err := fb.ScanDocument(d)
@@ -71,6 +94,8 @@ func (n *ProjectionNode) toStream(st document.Stream) (document.Stream, error) {
 			dm.info = n.info
 			dm.d = d
 			dm.resultFields = n.Expressions
+			dm.params = n.params
+			dm.tx = n.tx
 
 			return &dm, nil
 		})
@@ -79,6 +104,18 @@ func (n *ProjectionNode) toStream(st document.Stream) (document.Stream, error) {
 	return st, nil
 }
 
+// advancesSequence returns true if e calls the nextval function.
+func advancesSequence(e expr.Expr) bool {
+	err := walkExpr(e, func(e expr.Expr) error {
+		if _, ok := e.(expr.NextValueFunc); ok {
+			return errStop
+		}
+		return nil
+	})
+
+	return err == errStop
+}
+
 func (n *ProjectionNode) String() string {
 	var b strings.Builder
 
@@ -96,6 +133,8 @@ type documentMask struct {
 	info         *database.TableInfo
 	d            document.Document
 	resultFields []ProjectedField
+	params       []expr.Param
+	tx           *database.Transaction
 }
 
 var _ document.Document = documentMask{}
@@ -108,7 +147,7 @@ func (d documentMask) GetByField(field string) (v document.Value, err error) {
 				return
 			}
 
-			var env expr.Environment
+			env := expr.Environment{Params: d.params, Tx: d.tx}
 			if d.d != nil {
 				env.SetCurrentValue(document.NewDocumentValue(d.d))
 			}
@@ -132,7 +171,7 @@ func (d documentMask) GetByField(field string) (v document.Value, err error) {
 }
 
 func (d documentMask) Iterate(fn func(field string, value document.Value) error) error {
-	var env expr.Environment
+	env := expr.Environment{Params: d.params, Tx: d.tx}
 	if d.d != nil {
 		env.SetCurrentValue(document.NewDocumentValue(d.d))
 	}
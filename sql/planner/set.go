@@ -0,0 +1,194 @@
+package planner
+
+import (
+	"fmt"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+// setOperationMaxMemoryKeys is the number of documents a set operation
+// keeps track of in memory before using temporary files.
+const setOperationMaxMemoryKeys = 1 << 20
+
+type setOperationNode struct {
+	node
+
+	operator  scanner.Token
+	all       bool
+	leftTree  *Tree
+	rightTree *Tree
+}
+
+var _ inputNode = (*setOperationNode)(nil)
+
+// NewSetOperationNode creates a node that combines the documents of two trees.
+// The operator must be one of UNION, INTERSECT or EXCEPT.
+// Unless all is true, duplicate documents are removed from the result.
+// Both trees must project the same fields, in the same order.
+func NewSetOperationNode(operator scanner.Token, all bool, left, right *Tree) Node {
+	return &setOperationNode{
+		node: node{
+			op: Input,
+		},
+		operator:  operator,
+		all:       all,
+		leftTree:  left,
+		rightTree: right,
+	}
+}
+
+// Bind binds and optimizes both trees and ensures they project the same fields.
+func (n *setOperationNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
+	for _, t := range []**Tree{&n.leftTree, &n.rightTree} {
+		err = Bind(*t, tx, params)
+		if err != nil {
+			return err
+		}
+
+		*t, err = Optimize(*t)
+		if err != nil {
+			return err
+		}
+	}
+
+	return n.checkProjections()
+}
+
+// checkProjections returns an error if both trees don't project the same number of fields,
+// with the same names. Projections selecting all the fields of a document using a wildcard
+// can only be checked when the documents are read, and are ignored.
+func (n *setOperationNode) checkProjections() error {
+	left, ok := projectedNames(n.leftTree)
+	if !ok {
+		return nil
+	}
+	right, ok := projectedNames(n.rightTree)
+	if !ok {
+		return nil
+	}
+
+	if len(left) != len(right) {
+		return fmt.Errorf("each %s query must have the same number of fields, got %d and %d", n.operator, len(left), len(right))
+	}
+
+	for i := range left {
+		if left[i] != right[i] {
+			return fmt.Errorf("each %s query must have the same field names, got %q and %q", n.operator, left[i], right[i])
+		}
+	}
+
+	return nil
+}
+
+// projectedNames returns the names of the fields projected by t.
+// It returns false if they cannot be determined.
+func projectedNames(t *Tree) ([]string, bool) {
+	pn := projectionOf(t)
+	if pn == nil {
+		return nil, false
+	}
+
+	names := make([]string, 0, len(pn.Expressions))
+	for _, e := range pn.Expressions {
+		if _, ok := e.(Wildcard); ok {
+			return nil, false
+		}
+
+		names = append(names, e.Name())
+	}
+
+	return names, true
+}
+
+func (n *setOperationNode) buildStream() (document.Stream, error) {
+	left, err := n.leftTree.stream()
+	if err != nil {
+		return left, err
+	}
+
+	right, err := n.rightTree.stream()
+	if err != nil {
+		return right, err
+	}
+
+	if n.operator == scanner.UNION && n.all {
+		return left.Append(right), nil
+	}
+
+	return document.NewStream(&setOperationIterator{
+		operator: n.operator,
+		left:     left,
+		right:    right,
+	}), nil
+}
+
+func (n *setOperationNode) String() string {
+	var op string
+	switch n.operator {
+	case scanner.UNION:
+		op = "Union"
+		if n.all {
+			op = "UnionAll"
+		}
+	case scanner.INTERSECT:
+		op = "Intersect"
+	case scanner.EXCEPT:
+		op = "Except"
+	}
+
+	return fmt.Sprintf("%s(%s, %s)", op, n.leftTree, n.rightTree)
+}
+
+type setOperationIterator struct {
+	operator    scanner.Token
+	left, right document.Stream
+}
+
+func (it *setOperationIterator) Iterate(fn func(d document.Document) error) error {
+	// seen contains the documents already returned
+	seen := newSpillingDocumentHashSet(setOperationMaxMemoryKeys)
+	defer seen.Close()
+
+	if it.operator == scanner.UNION {
+		return it.left.Append(it.right).Iterate(func(d document.Document) error {
+			ok, err := seen.Filter(d)
+			if err != nil || !ok {
+				return err
+			}
+
+			return fn(d)
+		})
+	}
+
+	// load the documents of the right stream
+	right := newSpillingDocumentHashSet(setOperationMaxMemoryKeys)
+	defer right.Close()
+
+	err := it.right.Iterate(right.Add)
+	if err != nil {
+		return err
+	}
+
+	return it.left.Iterate(func(d document.Document) error {
+		ok, err := right.Contains(d)
+		if err != nil {
+			return err
+		}
+
+		// INTERSECT returns the documents found in both streams,
+		// EXCEPT the ones that are only found in the left stream.
+		if ok != (it.operator == scanner.INTERSECT) {
+			return nil
+		}
+
+		ok, err = seen.Filter(d)
+		if err != nil || !ok {
+			return err
+		}
+
+		return fn(d)
+	})
+}
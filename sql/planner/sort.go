@@ -14,15 +14,15 @@ import (
 type sortNode struct {
 	node
 
-	sortField expr.Path
+	sortField expr.Expr
 	direction scanner.Token
 }
 
 var _ operationNode = (*sortNode)(nil)
 
 // NewSortNode creates a node that sorts a stream according to a given
-// document path and a sort direction.
-func NewSortNode(n Node, sortField expr.Path, direction scanner.Token) Node {
+// document path or the rank() function, and a sort direction.
+func NewSortNode(n Node, sortField expr.Expr, direction scanner.Token) Node {
 	if direction == 0 {
 		direction = scanner.ASC
 	}
@@ -60,7 +60,7 @@ func (n *sortNode) String() string {
 
 type sortIterator struct {
 	st        document.Stream
-	sortField expr.Path
+	sortField expr.Expr
 	direction scanner.Token
 }
 
@@ -92,8 +92,6 @@ func (it *sortIterator) Iterate(fn func(d document.Document) error) error {
 // This function is not memory efficient as it's loading the entire stream in memory before
 // returning the k-smallest or k-largest elements.
 func (it *sortIterator) sortStream(st document.Stream) (heap.Interface, error) {
-	path := document.Path(it.sortField)
-
 	var h heap.Interface
 	if it.direction == scanner.ASC {
 		h = new(minHeap)
@@ -104,29 +102,11 @@ func (it *sortIterator) sortStream(st document.Stream) (heap.Interface, error) {
 	heap.Init(h)
 
 	return h, st.Iterate(func(d document.Document) error {
-		// It is possible to sort by any projected field
-		// or field of the original document.
-		v, err := path.GetValueFromDocument(d)
-		if err != nil && err != document.ErrFieldNotFound {
+		v, err := it.sortValue(d)
+		if err != nil {
 			return err
 		}
 
-		// If a field is not found in the projected fields
-		// Look for fields in the original document.
-		if err == document.ErrFieldNotFound {
-			if dm, ok := d.(*documentMask); ok {
-				v, err = path.GetValueFromDocument(dm.d)
-				if err != nil && err != document.ErrFieldNotFound {
-					return err
-				}
-				if err == document.ErrFieldNotFound {
-					v = document.NewNullValue()
-				}
-			} else {
-				v = document.NewNullValue()
-			}
-		}
-
 		// We need to make sure sort behaviour
 		// if the same with or without indexes.
 		// To achieve that, the value must be encoded using the same method
@@ -152,6 +132,44 @@ func (it *sortIterator) sortStream(st document.Stream) (heap.Interface, error) {
 	})
 }
 
+// sortValue returns the value used to sort d.
+func (it *sortIterator) sortValue(d document.Document) (document.Value, error) {
+	path, ok := it.sortField.(expr.Path)
+	if !ok {
+		// functions like rank() are evaluated on the original document.
+		if dm, ok := d.(*documentMask); ok {
+			d = dm.d
+		}
+
+		return it.sortField.Eval(expr.NewEnvironment(document.NewDocumentValue(d)))
+	}
+
+	// It is possible to sort by any projected field
+	// or field of the original document.
+	v, err := document.Path(path).GetValueFromDocument(d)
+	if err != nil && err != document.ErrFieldNotFound {
+		return v, err
+	}
+
+	// If a field is not found in the projected fields
+	// Look for fields in the original document.
+	if err == document.ErrFieldNotFound {
+		if dm, ok := d.(*documentMask); ok {
+			v, err = document.Path(path).GetValueFromDocument(dm.d)
+			if err != nil && err != document.ErrFieldNotFound {
+				return v, err
+			}
+			if err == document.ErrFieldNotFound {
+				v = document.NewNullValue()
+			}
+		} else {
+			v = document.NewNullValue()
+		}
+	}
+
+	return v, nil
+}
+
 type heapNode struct {
 	value []byte
 	data  document.FieldBuffer
@@ -0,0 +1,191 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// A SubqueryExpr is an expression that evaluates a tree and returns an array containing
+// the value of the only field of every document of the stream.
+// It can be used as the right operand of the IN operator, as in: a IN (SELECT b FROM foo).
+// The tree is evaluated once, the first time the expression is evaluated, and its result
+// is reused until the stream of the node using the expression is built again.
+type SubqueryExpr struct {
+	Tree *Tree
+	// Query is the select statement of the subquery, as written.
+	Query string
+
+	bound     bool
+	result    document.Value
+	hasResult bool
+}
+
+// Eval evaluates the tree and returns the value of the field of every document.
+// It returns an error if a document has more than one field.
+func (s *SubqueryExpr) Eval(env *expr.Environment) (document.Value, error) {
+	if s.hasResult {
+		return s.result, nil
+	}
+	if !s.bound {
+		return document.Value{}, errors.New("subqueries are only allowed in the projection and the WHERE clause of a query")
+	}
+
+	st, err := s.Tree.stream()
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	vb := document.NewValueBuffer()
+	err = st.Iterate(func(d document.Document) error {
+		var i int
+		return d.Iterate(func(_ string, v document.Value) error {
+			if i > 0 {
+				return errSubqueryColumns
+			}
+			i++
+			vb = vb.Append(v)
+			return nil
+		})
+	})
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	s.result, s.hasResult = document.NewArrayValue(vb), true
+	return s.result, nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (s *SubqueryExpr) IsEqual(other expr.Expr) bool {
+	o, ok := other.(*SubqueryExpr)
+	return ok && o.Tree.String() == s.Tree.String()
+}
+
+func (s *SubqueryExpr) String() string {
+	return fmt.Sprintf("(%s)", s.Query)
+}
+
+// errSubqueryColumns is returned when a subquery returns documents with more than one field.
+var errSubqueryColumns = errors.New("subquery must return one column")
+
+func (s *SubqueryExpr) bind(tx *database.Transaction, params []expr.Param) (err error) {
+	if pn := projectionOf(s.Tree); pn != nil && len(pn.Expressions) > 1 {
+		return errSubqueryColumns
+	}
+
+	err = Bind(s.Tree, tx, params)
+	if err != nil {
+		return err
+	}
+
+	s.Tree, err = Optimize(s.Tree)
+	if err != nil {
+		return err
+	}
+
+	s.bound = true
+	return nil
+}
+
+// bindSubqueries binds every subquery found in e.
+func bindSubqueries(e expr.Expr, tx *database.Transaction, params []expr.Param) error {
+	return walkSubqueries(e, func(s *SubqueryExpr) error {
+		return s.bind(tx, params)
+	})
+}
+
+// resetSubqueries clears the result of every subquery found in e,
+// forcing them to be evaluated again.
+func resetSubqueries(e expr.Expr) {
+	_ = walkSubqueries(e, func(s *SubqueryExpr) error {
+		s.result, s.hasResult = document.Value{}, false
+		return nil
+	})
+}
+
+// walkSubqueries calls fn for every subquery found in e.
+func walkSubqueries(e expr.Expr, fn func(s *SubqueryExpr) error) error {
+	return walkExpr(e, func(e expr.Expr) error {
+		if s, ok := e.(*SubqueryExpr); ok {
+			return fn(s)
+		}
+		return nil
+	})
+}
+
+// walkExpr calls fn for e and every expression it contains, including the arguments of functions.
+// The trees of subqueries are not walked.
+func walkExpr(e expr.Expr, fn func(e expr.Expr) error) error {
+	if e == nil {
+		return nil
+	}
+
+	err := fn(e)
+	if err != nil {
+		return err
+	}
+
+	var children []expr.Expr
+	switch t := e.(type) {
+	case expr.Parentheses:
+		children = []expr.Expr{t.E}
+	case expr.CastFunc:
+		children = []expr.Expr{t.Expr}
+	case expr.LowerFunc:
+		children = []expr.Expr{t.Expr}
+	case expr.UpperFunc:
+		children = []expr.Expr{t.Expr}
+	case expr.RaiseFunc:
+		children = []expr.Expr{t.Expr}
+	case expr.NextValueFunc:
+		children = []expr.Expr{t.Expr}
+	case expr.CurrentValueFunc:
+		children = []expr.Expr{t.Expr}
+	case expr.LagFunc:
+		children = []expr.Expr{t.Expr, t.Offset, t.Default}
+	case expr.LeadFunc:
+		children = []expr.Expr{t.Expr, t.Offset, t.Default}
+	case *expr.CountFunc:
+		children = []expr.Expr{t.Expr}
+	case *expr.MinFunc:
+		children = []expr.Expr{t.Expr}
+	case *expr.MaxFunc:
+		children = []expr.Expr{t.Expr}
+	case *expr.SumFunc:
+		children = []expr.Expr{t.Expr}
+	case *expr.AvgFunc:
+		children = []expr.Expr{t.Expr}
+	case *expr.ArrayAggFunc:
+		children = []expr.Expr{t.Expr}
+	case *expr.StringAggFunc:
+		children = []expr.Expr{t.Expr, t.Separator}
+	case *expr.VarianceFunc:
+		children = []expr.Expr{t.Expr}
+	case *expr.ApproxCountDistinctFunc:
+		children = []expr.Expr{t.Expr}
+	case *expr.WindowFunc:
+		children = append([]expr.Expr{t.Func, t.Window.OrderBy}, t.Window.PartitionBy...)
+	case expr.LiteralExprList:
+		children = t
+	case expr.KVPairs:
+		for _, kv := range t {
+			children = append(children, kv.V)
+		}
+	case expr.Operator:
+		children = []expr.Expr{t.LeftHand(), t.RightHand()}
+	}
+
+	for _, c := range children {
+		err = walkExpr(c, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
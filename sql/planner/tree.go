@@ -6,7 +6,9 @@
 package planner
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
@@ -47,6 +49,8 @@ const (
 	Aggregation
 	// Dedup is an operation that removes duplicate documents from a stream
 	Dedup
+	// Window is an operation that computes window functions for every document of a stream.
+	Window
 )
 
 // A Tree describes the flow of a stream of documents.
@@ -77,27 +81,37 @@ func (t *Tree) Run(tx *database.Transaction, params []expr.Param) (query.Result,
 }
 
 func (t *Tree) execute() (query.Result, error) {
+	st, err := t.stream()
+	if err != nil {
+		return query.Result{}, err
+	}
+
+	return query.Result{
+		Stream: st,
+	}, nil
+}
+
+// stream returns the stream of documents generated by the tree.
+func (t *Tree) stream() (document.Stream, error) {
 	if t.Root == nil {
-		return query.Result{}, nil
+		return document.Stream{}, nil
 	}
+
+	if in, ok := t.Root.(inputNode); ok {
+		return in.buildStream()
+	}
+
 	var st document.Stream
 	var err error
 
 	if t.Root.Left() != nil {
 		st, err = nodeToStream(t.Root.Left())
 		if err != nil {
-			return query.Result{}, err
+			return st, err
 		}
 	}
 
-	st, err = t.Root.(operationNode).toStream(st)
-	if err != nil {
-		return query.Result{}, err
-	}
-
-	return query.Result{
-		Stream: st,
-	}, nil
+	return t.Root.(operationNode).toStream(st)
 }
 
 func (t *Tree) String() string {
@@ -124,9 +138,140 @@ func nodeToString(n Node) string {
 	return fmt.Sprintf("%s -> %v", s, n)
 }
 
+// walkTree calls fn for every node of the tree, including the nodes of the trees
+// it contains: the operands of set operations, the subqueries of selections and
+// projections and the trees of common table expressions.
+func walkTree(t *Tree, fn func(n Node) error) error {
+	return walkTreeCTE(t, fn, make(map[*CommonTableExpression]bool))
+}
+
+// walkTreeCTE is like walkTree but skips the common table expressions of visited,
+// since recursive ones reference themselves.
+func walkTreeCTE(t *Tree, fn func(n Node) error, visited map[*CommonTableExpression]bool) error {
+	if t == nil {
+		return nil
+	}
+
+	walkSub := func(e expr.Expr) error {
+		return walkSubqueries(e, func(s *SubqueryExpr) error {
+			return walkTreeCTE(s.Tree, fn, visited)
+		})
+	}
+
+	for n := t.Root; n != nil; n = n.Left() {
+		err := fn(n)
+		if err != nil {
+			return err
+		}
+
+		switch t := n.(type) {
+		case *setOperationNode:
+			err = walkTreeCTE(t.leftTree, fn, visited)
+			if err == nil {
+				err = walkTreeCTE(t.rightTree, fn, visited)
+			}
+		case *cteInputNode:
+			if !visited[t.cte] {
+				visited[t.cte] = true
+				err = walkTreeCTE(t.cte.tree, fn, visited)
+				if err == nil {
+					err = walkTreeCTE(t.cte.recursiveTree, fn, visited)
+				}
+			}
+		case *selectionNode:
+			err = walkSub(t.cond)
+		case *ProjectionNode:
+			for _, e := range t.Expressions {
+				if pe, ok := e.(ProjectedExpr); ok && err == nil {
+					err = walkSub(pe.Expr)
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// projectionOf returns the projection node of t, or nil if there is none.
+// If t combines the documents of other trees, the projection of the first tree is returned.
+func projectionOf(t *Tree) *ProjectionNode {
+	for n := t.Root; n != nil; n = n.Left() {
+		switch t := n.(type) {
+		case *ProjectionNode:
+			return t
+		case *setOperationNode:
+			return projectionOf(t.leftTree)
+		}
+	}
+
+	return nil
+}
+
 // IsReadOnly implements the query.Statement interface.
+// A tree is read-only if none of its nodes modify the documents of the stream
+// or advance a sequence, including the nodes of its subqueries, common table expressions
+// and set operations.
 func (t *Tree) IsReadOnly() bool {
-	return false
+	err := walkTree(t, func(n Node) error {
+		switch n.Operation() {
+		case Deletion, Replacement:
+			return errNotReadOnly
+		}
+
+		for _, e := range nodeExprs(n) {
+			if advancesSequence(e) {
+				return errNotReadOnly
+			}
+		}
+
+		return nil
+	})
+
+	return err == nil
+}
+
+// errNotReadOnly stops the walk of IsReadOnly as soon as a node writes.
+var errNotReadOnly = errors.New("not read-only")
+
+// nodeExprs returns the expressions evaluated by n.
+func nodeExprs(n Node) []expr.Expr {
+	switch t := n.(type) {
+	case *selectionNode:
+		return []expr.Expr{t.cond}
+	case *ProjectionNode:
+		var exprs []expr.Expr
+		for _, e := range t.Expressions {
+			if pe, ok := e.(ProjectedExpr); ok {
+				exprs = append(exprs, pe.Expr)
+			}
+		}
+		return exprs
+	case *GroupingNode:
+		return t.Exprs
+	case *AggregationNode:
+		var exprs []expr.Expr
+		for _, a := range t.Aggregators {
+			if e, ok := a.(expr.Expr); ok {
+				exprs = append(exprs, e)
+			}
+		}
+		return exprs
+	case *WindowNode:
+		exprs := make([]expr.Expr, len(t.Functions))
+		for i, f := range t.Functions {
+			exprs[i] = f
+		}
+		return exprs
+	case *setNode:
+		return []expr.Expr{t.e}
+	case *indexInputNode:
+		return []expr.Expr{t.filter}
+	}
+
+	return nil
 }
 
 func nodeToStream(n Node) (st document.Stream, err error) {
@@ -222,7 +367,7 @@ func NewSelectionNode(n Node, cond expr.Expr) Node {
 func (n *selectionNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
 	n.tx = tx
 	n.params = params
-	return
+	return bindSubqueries(n.cond, tx, params)
 }
 
 func (n *selectionNode) toStream(st document.Stream) (document.Stream, error) {
@@ -230,8 +375,11 @@ func (n *selectionNode) toStream(st document.Stream) (document.Stream, error) {
 		return st, nil
 	}
 
+	resetSubqueries(n.cond)
+
 	env := expr.Environment{
 		Params: n.params,
+		Tx:     n.tx,
 	}
 
 	return st.Filter(func(d document.Document) (bool, error) {
@@ -362,6 +510,7 @@ func (n *setNode) toStream(st document.Stream) (document.Stream, error) {
 
 	env := expr.Environment{
 		Params: n.params,
+		Tx:     n.tx,
 	}
 
 	return st.Map(func(d document.Document) (document.Document, error) {
@@ -444,24 +593,25 @@ func (n *unsetNode) String() string {
 }
 
 // A GroupingNode is a node that groups documents by value.
+// Documents are grouped using the values of every expression.
 type GroupingNode struct {
 	node
 
 	Tx     *database.Transaction
 	Params []expr.Param
-	Expr   expr.Expr
+	Exprs  []expr.Expr
 }
 
 var _ operationNode = (*GroupingNode)(nil)
 
 // NewGroupingNode creates a GroupingNode.
-func NewGroupingNode(n Node, e expr.Expr) Node {
+func NewGroupingNode(n Node, exprs []expr.Expr) Node {
 	return &GroupingNode{
 		node: node{
 			op:   Group,
 			left: n,
 		},
-		Expr: e,
+		Exprs: exprs,
 	}
 }
 
@@ -472,14 +622,38 @@ func (n *GroupingNode) Bind(tx *database.Transaction, params []expr.Param) (err
 	return
 }
 
-// toStream uses the GroupBy stream operation. It evaluates Expr for every field and returns
-// the result.
+// toStream uses the GroupBy stream operation. It evaluates every expression for each document
+// and returns an array containing the results, which is used as the group value.
+// Missing fields are grouped together as NULL.
 func (n *GroupingNode) toStream(st document.Stream) (document.Stream, error) {
 	return st.GroupBy(func(d document.Document) (document.Value, error) {
-		return n.Expr.Eval(expr.NewEnvironment(document.NewDocumentValue(d), n.Params...))
+		env := expr.NewEnvironment(document.NewDocumentValue(d), n.Params...)
+
+		vb := document.NewValueBuffer()
+		for _, e := range n.Exprs {
+			v, err := e.Eval(env)
+			if err == document.ErrFieldNotFound {
+				v = document.NewNullValue()
+			} else if err != nil {
+				return document.Value{}, err
+			}
+
+			vb = vb.Append(v)
+		}
+
+		return document.NewArrayValue(vb), nil
 	}), nil
 }
 
 func (n *GroupingNode) String() string {
-	return fmt.Sprintf("Group(%s)", n.Expr)
+	var b strings.Builder
+
+	for i, e := range n.Exprs {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(fmt.Sprintf("%v", e))
+	}
+
+	return fmt.Sprintf("Group(%s)", b.String())
 }
@@ -0,0 +1,43 @@
+package planner_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTreeIsReadOnly(t *testing.T) {
+	tests := []struct {
+		query    string
+		readOnly bool
+	}{
+		{"SELECT 1", true},
+		{"SELECT * FROM test", true},
+		{"SELECT a FROM test WHERE a > 10 ORDER BY a LIMIT 10", true},
+		{"SELECT a, COUNT(*) FROM test GROUP BY a", true},
+		{"SELECT a FROM test UNION ALL SELECT b FROM test", true},
+		{"UPDATE test SET a = 10", false},
+		{"UPDATE test SET a = 10 WHERE b > 10", false},
+		{"DELETE FROM test", false},
+		{"DELETE FROM test WHERE a > 10", false},
+		{"SELECT nextval('s')", false},
+		{"SELECT a FROM test WHERE nextval('s') > 0", false},
+		{"SELECT a FROM test ORDER BY a LIMIT 10 OFFSET 1", true},
+		{"SELECT COUNT(*) FROM test GROUP BY a + nextval('s')", false},
+		{"SELECT SUM(nextval('s')) FROM test", false},
+		{"SELECT a FROM test WHERE a IN (SELECT b FROM test)", true},
+		{"SELECT a FROM test WHERE a IN (SELECT nextval('s') FROM test)", false},
+		{"WITH c AS (SELECT nextval('s') AS a) SELECT a FROM c", false},
+		{"SELECT a FROM test UNION ALL SELECT nextval('s') FROM test", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.query)
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.Equal(t, test.readOnly, q.Statements[0].IsReadOnly())
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package planner
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+// A WindowNode is a node that computes window functions.
+// For each document of the stream, it computes the value of every window function
+// and makes it available to the rest of the tree, without changing the
+// number or the order of the documents.
+type WindowNode struct {
+	node
+
+	Functions []*expr.WindowFunc
+
+	params []expr.Param
+}
+
+var _ operationNode = (*WindowNode)(nil)
+
+// NewWindowNode creates a WindowNode.
+func NewWindowNode(n Node, functions []*expr.WindowFunc) Node {
+	return &WindowNode{
+		node: node{
+			op:   Window,
+			left: n,
+		},
+		Functions: functions,
+	}
+}
+
+// Bind database resources to this node.
+func (n *WindowNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
+	n.params = params
+	return
+}
+
+func (n *WindowNode) toStream(st document.Stream) (document.Stream, error) {
+	return document.NewStream(&windowIterator{
+		st:        st,
+		functions: n.Functions,
+		params:    n.params,
+	}), nil
+}
+
+func (n *WindowNode) String() string {
+	var b strings.Builder
+
+	for i, f := range n.Functions {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(f.String())
+	}
+
+	return fmt.Sprintf("Window(%s)", b.String())
+}
+
+type windowIterator struct {
+	st        document.Stream
+	functions []*expr.WindowFunc
+	params    []expr.Param
+}
+
+// Iterate loads the entire stream in memory, computes every window function
+// and calls fn for each document, in the order of the stream.
+func (it *windowIterator) Iterate(fn func(d document.Document) error) error {
+	var docs []*windowDocument
+	err := it.st.Iterate(func(d document.Document) error {
+		var wd windowDocument
+		err := wd.FieldBuffer.Copy(d)
+		if err != nil {
+			return err
+		}
+
+		docs = append(docs, &wd)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, f := range it.functions {
+		err = it.compute(f, docs)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, d := range docs {
+		err = fn(d)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compute splits the documents into partitions, sorts each partition and
+// stores the values of the window function in each document.
+func (it *windowIterator) compute(f *expr.WindowFunc, docs []*windowDocument) error {
+	var keys [][]byte
+	partitions := make(map[string][]int)
+
+	for i, d := range docs {
+		key, err := it.encode(f.Window.PartitionBy, d)
+		if err != nil {
+			return err
+		}
+
+		k := string(key)
+		if _, ok := partitions[k]; !ok {
+			keys = append(keys, key)
+		}
+		partitions[k] = append(partitions[k], i)
+	}
+
+	for _, key := range keys {
+		idxs := partitions[string(key)]
+
+		if f.Window.OrderBy != nil {
+			values := make(map[int][]byte, len(idxs))
+			for _, i := range idxs {
+				v, err := it.encode([]expr.Expr{f.Window.OrderBy}, docs[i])
+				if err != nil {
+					return err
+				}
+				values[i] = v
+			}
+
+			sort.SliceStable(idxs, func(i, j int) bool {
+				cmp := bytes.Compare(values[idxs[i]], values[idxs[j]])
+				if f.Window.OrderByDirection == scanner.DESC {
+					return cmp > 0
+				}
+				return cmp < 0
+			})
+		}
+
+		partition := make([]document.Document, len(idxs))
+		for i, idx := range idxs {
+			partition[i] = docs[idx]
+		}
+
+		values, err := f.Compute(partition, it.params)
+		if err != nil {
+			return err
+		}
+
+		for i, idx := range idxs {
+			docs[idx].values.Add(f.String(), values[i])
+		}
+	}
+
+	return nil
+}
+
+// encode evaluates the given expressions and encodes their values
+// using the same method as the index package, so that they can be compared.
+// Multiple values are encoded as an array.
+func (it *windowIterator) encode(exprs []expr.Expr, d document.Document) ([]byte, error) {
+	vb := document.NewValueBuffer()
+
+	for _, e := range exprs {
+		v, err := e.Eval(expr.NewEnvironment(document.NewDocumentValue(d), it.params...))
+		if err == document.ErrFieldNotFound {
+			v = document.NewNullValue()
+		} else if err != nil {
+			return nil, err
+		}
+
+		vb = vb.Append(v)
+	}
+
+	v := document.NewArrayValue(vb)
+	if vb.Len() == 1 {
+		v, _ = vb.GetByIndex(0)
+	}
+
+	var buf bytes.Buffer
+	err := document.NewValueEncoder(&buf).Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// windowDocument is a document along with the values computed by window functions.
+// These values can be read using GetByField but are not returned by Iterate.
+type windowDocument struct {
+	document.FieldBuffer
+
+	values document.FieldBuffer
+}
+
+func (d *windowDocument) GetByField(field string) (document.Value, error) {
+	v, err := d.values.GetByField(field)
+	if err != document.ErrFieldNotFound {
+		return v, err
+	}
+
+	return d.FieldBuffer.GetByField(field)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/query/expr"
 )
 
@@ -63,5 +64,244 @@ func (stmt AlterTableAddField) Run(tx *database.Transaction, _ []expr.Param) (Re
 	}
 
 	err := tx.AddField(stmt.TableName, stmt.Constraint)
+	if err != nil || !stmt.Constraint.IsGenerated() {
+		return res, err
+	}
+
+	// compute the new field for every existing document
+	t, err := tx.GetTable(stmt.TableName)
+	if err != nil {
+		return res, err
+	}
+
+	return res, t.Rewrite(nil)
+}
+
+// AlterTableRenameField renames a field in every document of a table.
+// Field constraints and indexes are updated accordingly.
+type AlterTableRenameField struct {
+	TableName string
+	OldPath   document.Path
+	NewPath   document.Path
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt AlterTableRenameField) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the ALTER TABLE RENAME FIELD statement in the given transaction.
+// It implements the Statement interface.
+func (stmt AlterTableRenameField) Run(tx *database.Transaction, _ []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.TableName == "" {
+		return res, errors.New("missing table name")
+	}
+
+	if stmt.OldPath == nil || stmt.NewPath == nil {
+		return res, errors.New("missing field name")
+	}
+
+	err := tx.RenameField(stmt.TableName, stmt.OldPath, stmt.NewPath)
 	return res, err
 }
+
+// AlterTableDropField removes the constraint of a field.
+// Unless KeepValues is true, the field is also removed from every document of the table.
+type AlterTableDropField struct {
+	TableName  string
+	Path       document.Path
+	KeepValues bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt AlterTableDropField) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the ALTER TABLE DROP FIELD statement in the given transaction.
+// It implements the Statement interface.
+func (stmt AlterTableDropField) Run(tx *database.Transaction, _ []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.TableName == "" {
+		return res, errors.New("missing table name")
+	}
+
+	if stmt.Path == nil {
+		return res, errors.New("missing field name")
+	}
+
+	err := tx.DropField(stmt.TableName, stmt.Path)
+	if err != nil || stmt.KeepValues {
+		return res, err
+	}
+
+	t, err := tx.GetTable(stmt.TableName)
+	if err != nil {
+		return res, err
+	}
+
+	err = t.Rewrite(func(fb *document.FieldBuffer) error {
+		err := fb.Delete(stmt.Path)
+		if err == document.ErrFieldNotFound {
+			return nil
+		}
+		return err
+	})
+	return res, err
+}
+
+// AlterTableAlterFieldType changes the type of a field.
+// Every document of the table is converted to the new type,
+// and the typed indexes of the field are rebuilt with the new type.
+type AlterTableAlterFieldType struct {
+	TableName string
+	Path      document.Path
+	Type      document.ValueType
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt AlterTableAlterFieldType) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the ALTER TABLE ALTER FIELD TYPE statement in the given transaction.
+// It implements the Statement interface.
+func (stmt AlterTableAlterFieldType) Run(tx *database.Transaction, _ []expr.Param) (Result, error) {
+	var res Result
+
+	// typed indexes only accept values of their type,
+	// they are dropped before converting the documents and created again with the new type.
+	list, err := tx.ListIndexes()
+	if err != nil {
+		return res, err
+	}
+	var typed []database.IndexConfig
+	for _, idx := range list {
+		if idx.TableName == stmt.TableName && idx.Path.IsEqual(stmt.Path) && idx.Type != 0 {
+			err = tx.DropIndex(idx.IndexName)
+			if err != nil {
+				return res, err
+			}
+			typed = append(typed, *idx)
+		}
+	}
+
+	err = alterField(tx, stmt.TableName, stmt.Path, true, func(fc *database.FieldConstraint) {
+		fc.Type = stmt.Type
+	})
+	if err != nil {
+		return res, err
+	}
+
+	for _, cfg := range typed {
+		cfg.Type = 0
+		err = tx.CreateIndex(cfg)
+		if err != nil {
+			return res, err
+		}
+
+		err = tx.ReIndex(cfg.IndexName)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
+
+// AlterTableAlterFieldNotNull adds or removes the NOT NULL constraint of a field.
+// When adding the constraint, every document of the table is validated.
+type AlterTableAlterFieldNotNull struct {
+	TableName string
+	Path      document.Path
+	NotNull   bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt AlterTableAlterFieldNotNull) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the ALTER TABLE ALTER FIELD SET NOT NULL or DROP NOT NULL statement in the given transaction.
+// It implements the Statement interface.
+func (stmt AlterTableAlterFieldNotNull) Run(tx *database.Transaction, _ []expr.Param) (Result, error) {
+	return Result{}, alterField(tx, stmt.TableName, stmt.Path, stmt.NotNull, func(fc *database.FieldConstraint) {
+		fc.IsNotNull = stmt.NotNull
+	})
+}
+
+// AlterTableAlterFieldDefault sets or removes the default value of a field.
+// If DefaultValue is the zero value, the default value is removed.
+// Existing documents are not modified.
+type AlterTableAlterFieldDefault struct {
+	TableName    string
+	Path         document.Path
+	DefaultValue document.Value
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt AlterTableAlterFieldDefault) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the ALTER TABLE ALTER FIELD SET DEFAULT or DROP DEFAULT statement in the given transaction.
+// It implements the Statement interface.
+func (stmt AlterTableAlterFieldDefault) Run(tx *database.Transaction, _ []expr.Param) (Result, error) {
+	return Result{}, alterField(tx, stmt.TableName, stmt.Path, false, func(fc *database.FieldConstraint) {
+		fc.DefaultValue = stmt.DefaultValue
+	})
+}
+
+// alterField modifies the constraint of the field found at path using fn, or a new one if there is none.
+// If rewrite is true, every document of the table is then validated and converted
+// using the new constraint.
+func alterField(tx *database.Transaction, tableName string, path document.Path, rewrite bool, fn func(fc *database.FieldConstraint)) error {
+	if tableName == "" {
+		return errors.New("missing table name")
+	}
+
+	if path == nil {
+		return errors.New("missing field name")
+	}
+
+	t, err := tx.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	info, err := t.Info()
+	if err != nil {
+		return err
+	}
+
+	idx := -1
+	constraints := make([]database.FieldConstraint, len(info.FieldConstraints))
+	copy(constraints, info.FieldConstraints)
+	for i, fc := range constraints {
+		if fc.Path.IsEqual(path) {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		constraints = append(constraints, database.FieldConstraint{Path: path})
+		idx = len(constraints) - 1
+	}
+
+	fn(&constraints[idx])
+
+	err = checkConstraints(constraints)
+	if err != nil {
+		return err
+	}
+
+	err = tx.ReplaceFieldConstraint(tableName, constraints[idx])
+	if err != nil || !rewrite {
+		return err
+	}
+
+	return t.Rewrite(nil)
+}
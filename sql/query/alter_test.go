@@ -1,6 +1,7 @@
 package query_test
 
 import (
+	"bytes"
 	"errors"
 	"testing"
 
@@ -44,3 +45,257 @@ func TestAlterTable(t *testing.T) {
 	err = db.Exec("ALTER TABLE __genji_tables RENAME TO bar")
 	require.Error(t, err)
 }
+
+func TestAlterTableFields(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo(id INTEGER PRIMARY KEY, a TEXT);
+		CREATE INDEX idx_foo_b ON foo(b);
+		INSERT INTO foo (id, a, b) VALUES (1, 'x', '10'), (2, 'y', '20'), (3, NULL, '30');
+	`)
+	require.NoError(t, err)
+
+	query := func(q string) string {
+		t.Helper()
+
+		res, err := db.Query(q)
+		require.NoError(t, err)
+		defer res.Close()
+
+		var buf bytes.Buffer
+		err = document.IteratorToJSONArray(&buf, res)
+		require.NoError(t, err)
+		return buf.String()
+	}
+
+	t.Run("Alter type", func(t *testing.T) {
+		err := db.Exec("ALTER TABLE foo ALTER FIELD b TYPE INTEGER")
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"id": 2, "b": 20}]`, query("SELECT id, b FROM foo WHERE b = 20"))
+		require.JSONEq(t, `[{"id": 1}, {"id": 2}]`, query("SELECT id FROM foo WHERE b < 25"))
+
+		// invalid conversions abort the statement
+		err = db.Exec("ALTER TABLE foo ALTER FIELD a TYPE INTEGER")
+		require.Error(t, err)
+		require.JSONEq(t, `[{"a": "x"}, {"a": "y"}, {"a": null}]`, query("SELECT a FROM foo"))
+
+		// the primary key cannot be altered
+		err = db.Exec("ALTER TABLE foo ALTER FIELD id TYPE DOUBLE")
+		require.Error(t, err)
+
+		// typed indexes are rebuilt with the new type
+		err = db.Exec("ALTER TABLE foo ALTER FIELD b TYPE DOUBLE")
+		require.NoError(t, err)
+		err = db.Exec("INSERT INTO foo (id, a, b) VALUES (8, 'w', 8.5)")
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"id": 8, "b": 8.5}]`, query("SELECT id, b FROM foo WHERE b = 8.5"))
+		require.JSONEq(t, `[{"id": 2, "b": 20.0}]`, query("SELECT id, b FROM foo WHERE b = 20.0"))
+		require.JSONEq(t, `[{"id": 8}, {"id": 1}]`, query("SELECT id FROM foo WHERE b < 15.0"))
+		err = db.Exec("DELETE FROM foo WHERE id = 8")
+		require.NoError(t, err)
+		require.JSONEq(t, `[]`, query("SELECT id FROM foo WHERE b = 8.5"))
+
+		err = db.Exec("ALTER TABLE foo ALTER FIELD b TYPE INTEGER")
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"id": 2, "b": 20}]`, query("SELECT id, b FROM foo WHERE b = 20"))
+	})
+
+	t.Run("Not null", func(t *testing.T) {
+		err := db.Exec("ALTER TABLE foo ALTER FIELD a SET NOT NULL")
+		require.Error(t, err)
+
+		err = db.Exec("ALTER TABLE foo ALTER FIELD b SET NOT NULL")
+		require.NoError(t, err)
+		err = db.Exec("INSERT INTO foo (id, a) VALUES (4, 'z')")
+		require.Error(t, err)
+
+		err = db.Exec("ALTER TABLE foo ALTER FIELD b DROP NOT NULL")
+		require.NoError(t, err)
+		err = db.Exec("INSERT INTO foo (id, a) VALUES (4, 'z')")
+		require.NoError(t, err)
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		err := db.Exec("ALTER TABLE foo ALTER FIELD b SET DEFAULT 50")
+		require.NoError(t, err)
+		err = db.Exec("INSERT INTO foo (id) VALUES (5)")
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"b": 50}]`, query("SELECT b FROM foo WHERE id = 5"))
+
+		err = db.Exec("ALTER TABLE foo ALTER FIELD b SET DEFAULT 'a'")
+		require.Error(t, err)
+
+		err = db.Exec("ALTER TABLE foo ALTER FIELD b DROP DEFAULT")
+		require.NoError(t, err)
+		err = db.Exec("INSERT INTO foo (id) VALUES (6)")
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"id": 6}]`, query("SELECT * FROM foo WHERE id = 6"))
+	})
+
+	t.Run("Drop field", func(t *testing.T) {
+		err := db.Exec("ALTER TABLE foo DROP FIELD a KEEP VALUES")
+		require.NoError(t, err)
+		err = db.Exec("INSERT INTO foo (id, a) VALUES (7, 1)")
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"a": "x"}, {"a": 1.0}]`, query("SELECT a FROM foo WHERE id = 1 OR id = 7"))
+
+		err = db.Exec("ALTER TABLE foo DROP FIELD b")
+		require.NoError(t, err)
+		require.JSONEq(t, `[]`, query("SELECT * FROM foo WHERE b IS NOT NULL"))
+		require.JSONEq(t, `[]`, query("SELECT * FROM foo WHERE b > 0"))
+
+		// the field has no constraint anymore
+		err = db.Exec("ALTER TABLE foo DROP FIELD b")
+		require.Error(t, err)
+
+		err = db.Exec("ALTER TABLE foo DROP FIELD id")
+		require.Error(t, err)
+	})
+}
+
+func TestAlterTableRenameField(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo(id INTEGER PRIMARY KEY, a.b TEXT NOT NULL, c INTEGER);
+		CREATE INDEX idx_foo_a_b ON foo(a.b);
+		CREATE INDEX idx_foo_c ON foo(c) INCLUDE (a);
+		INSERT INTO foo (id, a, c, d) VALUES (1, {b: 'x', z: 1}, 10, 1), (2, {b: 'y'}, 20, 2);
+		INSERT INTO foo (id, a, c) VALUES (3, {b: 'z'}, 30);
+	`)
+	require.NoError(t, err)
+
+	query := func(q string) string {
+		t.Helper()
+
+		res, err := db.Query(q)
+		require.NoError(t, err)
+		defer res.Close()
+
+		var buf bytes.Buffer
+		err = document.IteratorToJSONArray(&buf, res)
+		require.NoError(t, err)
+		return buf.String()
+	}
+
+	err = db.Exec("ALTER TABLE foo RENAME FIELD a.b TO a.e")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"id": 1, "a": {"e": "x", "z": 1.0}, "c": 10, "d": 1.0}]`, query("SELECT * FROM foo WHERE id = 1"))
+
+	// the constraint and the index follow the field
+	err = db.Exec("INSERT INTO foo (id, a) VALUES (4, {b: 'w'})")
+	require.Error(t, err)
+
+	d, err := db.QueryDocument("SELECT * FROM __genji_indexes WHERE index_name = 'idx_foo_a_b'")
+	require.NoError(t, err)
+	v, err := d.GetByField("path")
+	require.NoError(t, err)
+	require.Equal(t, `["a", "e"]`, v.String())
+	require.JSONEq(t, `[{"id": 2}]`, query("SELECT id FROM foo WHERE a.e = 'y'"))
+
+	// covering indexes are rebuilt
+	require.JSONEq(t, `[{"a": {"e": "z"}}]`, query("SELECT a FROM foo WHERE c = 30"))
+
+	err = db.Exec("ALTER TABLE foo RENAME FIELD c TO d")
+	require.Error(t, err)
+	err = db.Exec("ALTER TABLE foo RENAME FIELD a.e TO f")
+	require.Error(t, err)
+
+	err = db.Exec("ALTER TABLE foo RENAME FIELD id TO uid")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"uid": 2}]`, query("SELECT uid FROM foo WHERE uid = 2"))
+}
+
+func TestAlterTableRenameFieldUsedByExpressions(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo(id INTEGER PRIMARY KEY, price DOUBLE, qty INTEGER, total DOUBLE AS (price * qty) STORED, p INTEGER, email TEXT);
+		CREATE INDEX idx_foo_qty ON foo(qty) WHERE price > 0;
+		CREATE INDEX idx_foo_email ON foo(LOWER(email));
+		INSERT INTO foo (id, price, qty, p, email) VALUES (1, 2.5, 4, 1, 'A@B.C');
+	`)
+	require.NoError(t, err)
+
+	// fields read by generated fields or by index expressions cannot be renamed
+	err = db.Exec("ALTER TABLE foo RENAME FIELD price TO cost")
+	require.Error(t, err)
+	err = db.Exec("ALTER TABLE foo RENAME FIELD qty TO quantity")
+	require.Error(t, err)
+	err = db.Exec("ALTER TABLE foo RENAME FIELD email TO mail")
+	require.Error(t, err)
+
+	// fields whose name only appears in the text of the expressions can
+	err = db.Exec("ALTER TABLE foo RENAME FIELD p TO q")
+	require.NoError(t, err)
+
+	d, err := db.QueryDocument("SELECT total, q FROM foo")
+	require.NoError(t, err)
+	data, err := document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"total": 10.0, "q": 1}`, string(data))
+}
+
+func TestAlterTableDropFieldIndexes(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo(id INTEGER PRIMARY KEY, a INTEGER, b TEXT, c.d INTEGER);
+		CREATE INDEX idx_foo_a ON foo(a);
+		CREATE INDEX idx_foo_b ON foo(b) WHERE a > 0;
+		CREATE INDEX idx_foo_b_lower ON foo(LOWER(b));
+		CREATE INDEX idx_foo_id ON foo(id) INCLUDE (a);
+		CREATE INDEX idx_foo_c ON foo(c);
+		CREATE INDEX idx_foo_c_d ON foo(c.d);
+		INSERT INTO foo (id, a, b, c) VALUES (1, 10, 'X', {d: 1});
+	`)
+	require.NoError(t, err)
+
+	indexes := func() []string {
+		t.Helper()
+
+		var names []string
+		err := db.View(func(tx *genji.Tx) error {
+			list, err := tx.ListIndexes()
+			for _, idx := range list {
+				names = append(names, idx.IndexName)
+			}
+			return err
+		})
+		require.NoError(t, err)
+		return names
+	}
+
+	// indexes on the field, including it or using it in their predicate are dropped
+	err = db.Exec("ALTER TABLE foo DROP FIELD a")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"idx_foo_b_lower", "idx_foo_c", "idx_foo_c_d"}, indexes())
+
+	// the field can be written again with another type
+	err = db.Exec("INSERT INTO foo (id, a, c) VALUES (2, 1.5, {d: 2})")
+	require.NoError(t, err)
+	d, err := db.QueryDocument("SELECT id FROM foo WHERE a = 1.5")
+	require.NoError(t, err)
+	data, err := document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id": 2}`, string(data))
+
+	// indexes using the field in their expression are dropped
+	err = db.Exec("ALTER TABLE foo DROP FIELD b")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"idx_foo_c", "idx_foo_c_d"}, indexes())
+
+	// indexes on a parent or a child of the field are dropped
+	err = db.Exec("ALTER TABLE foo DROP FIELD c.d")
+	require.NoError(t, err)
+	require.Empty(t, indexes())
+}
@@ -2,6 +2,7 @@ package query
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
@@ -50,6 +51,24 @@ type CreateIndexStmt struct {
 	Path        document.Path
 	IfNotExists bool
 	Unique      bool
+
+	// If set to true, each element of the array found at Path is indexed.
+	ArrayElements bool
+
+	// If set, only the documents matching this predicate are indexed.
+	Where expr.Expr
+
+	// If set, the result of this expression is indexed instead of Path.
+	Expr expr.Expr
+
+	// Paths stored in the index along with the key of each document.
+	Include []document.Path
+
+	// If set to true, the words of the text found at Path are indexed.
+	FullText bool
+
+	// If set to true, the words of a full-text index are reduced to their stem.
+	Stem bool
 }
 
 // IsReadOnly always returns false. It implements the Statement interface.
@@ -70,19 +89,168 @@ func (stmt CreateIndexStmt) Run(tx *database.Transaction, args []expr.Param) (Re
 		return res, errors.New("missing index name")
 	}
 
-	if len(stmt.Path) == 0 {
+	if len(stmt.Path) == 0 && stmt.Expr == nil {
 		return res, errors.New("missing path")
 	}
 
-	err := tx.CreateIndex(database.IndexConfig{
-		Unique:    stmt.Unique,
-		IndexName: stmt.IndexName,
-		TableName: stmt.TableName,
-		Path:      stmt.Path,
-	})
+	cfg := database.IndexConfig{
+		Unique:        stmt.Unique,
+		IndexName:     stmt.IndexName,
+		TableName:     stmt.TableName,
+		Path:          stmt.Path,
+		ArrayElements: stmt.ArrayElements,
+		Include:       stmt.Include,
+		FullText:      stmt.FullText,
+		Stem:          stmt.Stem,
+	}
+	if stmt.Where != nil {
+		cfg.Predicate = fmt.Sprintf("%v", stmt.Where)
+	}
+	if stmt.Expr != nil {
+		cfg.Expr = fmt.Sprintf("%v", stmt.Expr)
+	}
+
+	err := tx.CreateIndex(cfg)
 	if stmt.IfNotExists && err == database.ErrIndexAlreadyExists {
 		err = nil
 	}
 
 	return res, err
 }
+
+// CreateViewStmt is a DSL that allows creating a full CREATE VIEW statement.
+type CreateViewStmt struct {
+	ViewName    string
+	IfNotExists bool
+
+	// Query is the text of the SELECT statement of the view.
+	Query string
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt CreateViewStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the Create view statement in the given transaction.
+// It implements the Statement interface.
+func (stmt CreateViewStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.ViewName == "" {
+		return res, errors.New("missing view name")
+	}
+
+	err := tx.CreateView(stmt.ViewName, stmt.Query)
+	if stmt.IfNotExists && err == database.ErrViewAlreadyExists {
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+
+	// make sure the query reads existing tables and views
+	// and doesn't reference the view itself.
+	err = stmt.check(tx)
+	if err != nil {
+		if derr := tx.DropView(stmt.ViewName); derr != nil {
+			return res, derr
+		}
+	}
+	return res, err
+}
+
+// check binds the query of the view, which fails if it reads unknown tables
+// or if it references the view.
+func (stmt CreateViewStmt) check(tx *database.Transaction) error {
+	q, err := tx.ParseView(stmt.ViewName)
+	if err != nil {
+		return err
+	}
+
+	s, ok := q.(Statement)
+	if !ok {
+		return fmt.Errorf("invalid query for view %q", stmt.ViewName)
+	}
+
+	_, err = s.Run(tx, nil)
+	return err
+}
+
+// CreateTriggerStmt is a DSL that allows creating a full CREATE TRIGGER statement.
+type CreateTriggerStmt struct {
+	TriggerName string
+	IfNotExists bool
+	TableName   string
+	Before      bool
+	Event       database.TriggerEvent
+
+	// When is the text of the optional condition of the trigger.
+	When string
+
+	// Statement is the text of the statement executed when the trigger fires.
+	Statement string
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt CreateTriggerStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the Create trigger statement in the given transaction.
+// It implements the Statement interface.
+func (stmt CreateTriggerStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.TriggerName == "" {
+		return res, errors.New("missing trigger name")
+	}
+
+	err := tx.CreateTrigger(&database.TriggerInfo{
+		TriggerName: stmt.TriggerName,
+		TableName:   stmt.TableName,
+		Before:      stmt.Before,
+		Event:       stmt.Event,
+		When:        stmt.When,
+		Statement:   stmt.Statement,
+	})
+	if stmt.IfNotExists && err == database.ErrTriggerAlreadyExists {
+		err = nil
+	}
+
+	return res, err
+}
+
+// CreateSequenceStmt is a DSL that allows creating a full CREATE SEQUENCE statement.
+type CreateSequenceStmt struct {
+	SequenceName string
+	IfNotExists  bool
+	Start        int64
+	Increment    int64
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt CreateSequenceStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the Create sequence statement in the given transaction.
+// It implements the Statement interface.
+func (stmt CreateSequenceStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.SequenceName == "" {
+		return res, errors.New("missing sequence name")
+	}
+
+	err := tx.CreateSequence(&database.SequenceInfo{
+		SequenceName: stmt.SequenceName,
+		Start:        stmt.Start,
+		Increment:    stmt.Increment,
+	})
+	if stmt.IfNotExists && err == database.ErrSequenceAlreadyExists {
+		err = nil
+	}
+
+	return res, err
+}
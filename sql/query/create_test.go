@@ -1,7 +1,10 @@
 package query_test
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/database"
@@ -39,6 +42,7 @@ func TestCreateTable(t *testing.T) {
 		{"With incoherent constraint(document)", "CREATE TABLE test(a INTEGER, a.b TEXT);", true},
 		{"With incoherent constraint(array)", "CREATE TABLE test(a INTEGER, a[0] TEXT);", true},
 		{"With duplicate constraints", "CREATE TABLE test(a INTEGER, a TEXT);", true},
+		{"Strict", "CREATE TABLE test(a INTEGER, b.c TEXT) STRICT", false},
 	}
 
 	for _, test := range tests {
@@ -215,6 +219,9 @@ func TestCreateIndex(t *testing.T) {
 		{"Unique", "CREATE UNIQUE INDEX IF NOT EXISTS idx ON test (foo[1])", false},
 		{"No fields", "CREATE INDEX idx ON test", true},
 		{"More than 1 field", "CREATE INDEX idx ON test (foo, bar)", true},
+		{"Expression", "CREATE INDEX idx ON test (lower(foo))", false},
+		{"Volatile expression", "CREATE INDEX idx ON test (foo + nextval('seq'))", true},
+		{"Volatile predicate", "CREATE INDEX idx ON test (foo) WHERE foo > currval('seq')", true},
 	}
 
 	for _, test := range tests {
@@ -235,3 +242,484 @@ func TestCreateIndex(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateTableWithTTL(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	err = db.Exec(`
+		CREATE TABLE sessions(id TEXT PRIMARY KEY, expires_at TEXT) WITH TTL expires_at;
+		CREATE INDEX idx_sessions_user ON sessions(user) INCLUDE (user);
+	`)
+	require.NoError(t, err)
+
+	err = db.Exec("INSERT INTO sessions (id, user, expires_at) VALUES ('a', 'foo', ?), ('b', 'foo', ?), ('c', 'bar', ?)", past, future, past)
+	require.NoError(t, err)
+
+	err = db.Exec("INSERT INTO sessions (id, user, expires_at) VALUES ('d', 'foo', 'soon')")
+	require.Error(t, err)
+
+	ids := func(q string, args ...interface{}) []string {
+		t.Helper()
+
+		res, err := db.Query(q, args...)
+		require.NoError(t, err)
+		defer res.Close()
+
+		var ids []string
+		err = res.Iterate(func(d document.Document) error {
+			v, err := d.GetByField("id")
+			if err != nil {
+				return err
+			}
+			ids = append(ids, v.V.(string))
+			return nil
+		})
+		require.NoError(t, err)
+		return ids
+	}
+
+	// expired documents are invisible, whether they are read from the table or an index
+	require.Equal(t, []string{"b"}, ids("SELECT id FROM sessions"))
+	require.Equal(t, []string{"b"}, ids("SELECT id FROM sessions WHERE id = 'a' OR id = 'b'"))
+	require.Equal(t, []string{"b"}, ids("SELECT id FROM sessions WHERE user = 'foo'"))
+	require.Empty(t, ids("SELECT id FROM sessions WHERE expires_at < ?", future))
+
+	// an expired document can be replaced
+	err = db.Exec("INSERT INTO sessions (id, user, expires_at) VALUES ('a', 'baz', ?)", future)
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO sessions (id, user, expires_at) VALUES ('b', 'baz', ?)", future)
+	require.Equal(t, database.ErrDuplicateDocument, err)
+
+	n, err := db.DB.PurgeExpired(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	// the TTL field must contain timestamps or numbers
+	err = db.Exec("CREATE TABLE test(exp BOOL) WITH TTL exp")
+	require.Error(t, err)
+}
+
+func TestCreateView(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE users(id INTEGER PRIMARY KEY, name TEXT, active BOOL);
+		CREATE INDEX idx_users_name ON users(name);
+		INSERT INTO users (id, name, active) VALUES (1, 'foo', true), (2, 'bar', false), (3, 'baz', true), (4, 'foo', true);
+		CREATE VIEW active_users AS SELECT id, name FROM users WHERE active = true;
+	`)
+	require.NoError(t, err)
+
+	call := func(q string, res ...string) {
+		t.Helper()
+
+		st, err := db.Query(q)
+		require.NoError(t, err)
+		defer st.Close()
+
+		var got []string
+		err = st.Iterate(func(d document.Document) error {
+			data, err := document.MarshalJSON(d)
+			if err != nil {
+				return err
+			}
+			got = append(got, string(data))
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, len(res), len(got), got)
+		for i := range res {
+			require.JSONEq(t, res[i], got[i])
+		}
+	}
+
+	call("SELECT * FROM active_users", `{"id": 1, "name": "foo"}`, `{"id": 3, "name": "baz"}`, `{"id": 4, "name": "foo"}`)
+	call("SELECT name FROM active_users WHERE id > 1 ORDER BY name", `{"name": "baz"}`, `{"name": "foo"}`)
+	call("SELECT DISTINCT name FROM active_users ORDER BY name", `{"name": "baz"}`, `{"name": "foo"}`)
+	call("SELECT COUNT(*) FROM active_users", `{"COUNT(*)": 3}`)
+
+	t.Run("View of a view", func(t *testing.T) {
+		err := db.Exec("CREATE VIEW active_foos AS SELECT id FROM active_users WHERE name = 'foo'")
+		require.NoError(t, err)
+
+		call("SELECT * FROM active_foos", `{"id": 1}`, `{"id": 4}`)
+
+		// the view reflects the changes of the underlying table
+		err = db.Exec("UPDATE users SET active = false WHERE id = 4")
+		require.NoError(t, err)
+		call("SELECT * FROM active_foos", `{"id": 1}`)
+	})
+
+	t.Run("Conditions on views", func(t *testing.T) {
+		err := db.Exec("CREATE VIEW renamed AS SELECT id, name AS n FROM users")
+		require.NoError(t, err)
+
+		// the condition uses the index of the table
+		call("SELECT id FROM active_users WHERE name = 'foo'", `{"id": 1}`)
+		// the condition reads a renamed field
+		call("SELECT id FROM renamed WHERE n = 'baz'", `{"id": 3}`)
+		// the view has no name field
+		call("SELECT id FROM renamed WHERE name = 'foo' OR id = 2", `{"id": 2}`)
+
+		err = db.Exec("DROP VIEW renamed")
+		require.NoError(t, err)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		// a table with the same name exists
+		err := db.Exec("CREATE VIEW users AS SELECT * FROM users")
+		require.True(t, errors.Is(err, database.ErrViewAlreadyExists))
+
+		// a view with the same name exists
+		err = db.Exec("CREATE TABLE active_users")
+		require.True(t, errors.Is(err, database.ErrTableAlreadyExists))
+		err = db.Exec("CREATE VIEW active_users AS SELECT * FROM users")
+		require.True(t, errors.Is(err, database.ErrViewAlreadyExists))
+		err = db.Exec("CREATE VIEW IF NOT EXISTS active_users AS SELECT * FROM users")
+		require.NoError(t, err)
+
+		// the query references an unknown table
+		err = db.Exec("CREATE VIEW v AS SELECT * FROM unknown")
+		require.Error(t, err)
+
+		// views are read-only
+		err = db.Exec("INSERT INTO active_users (id) VALUES (10)")
+		require.Error(t, err)
+	})
+
+	t.Run("Cycles", func(t *testing.T) {
+		err := db.Exec(`
+			CREATE TABLE t;
+			CREATE VIEW a AS SELECT * FROM t;
+			DROP TABLE t;
+		`)
+		require.NoError(t, err)
+
+		// the view would read itself
+		err = db.Exec("CREATE VIEW t AS SELECT * FROM a")
+		require.Error(t, err)
+		_, err = db.Query("SELECT * FROM t")
+		require.Error(t, err)
+		_, err = db.Query("SELECT * FROM a")
+		require.Error(t, err)
+
+		// cycles stored in the catalog are detected when reading the views
+		err = db.Update(func(tx *genji.Tx) error {
+			return tx.CreateView("t", "SELECT * FROM a")
+		})
+		require.NoError(t, err)
+		_, err = db.Query("SELECT * FROM a")
+		require.EqualError(t, err, `view "a" references itself: a -> t -> a`)
+
+		err = db.Exec("DROP VIEW t; DROP VIEW a")
+		require.NoError(t, err)
+	})
+
+	t.Run("Stored in __genji_views", func(t *testing.T) {
+		d, err := db.QueryDocument("SELECT sql FROM __genji_views WHERE view_name = 'active_users'")
+		require.NoError(t, err)
+
+		data, err := document.MarshalJSON(d)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"sql": "SELECT id, name FROM users WHERE active = true"}`, string(data))
+	})
+}
+
+func TestCreateTrigger(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE users(id INTEGER PRIMARY KEY, name TEXT);
+		CREATE TABLE audit;
+		CREATE TABLE counters(id INTEGER PRIMARY KEY, n INTEGER);
+		INSERT INTO counters (id, n) VALUES (1, 0);
+
+		CREATE TRIGGER lower_name BEFORE INSERT ON users FOR EACH ROW SELECT lower(new.name) AS name;
+		CREATE TRIGGER check_id BEFORE INSERT ON users FOR EACH ROW WHEN new.id < 0 SELECT raise('id must be positive');
+		CREATE TRIGGER audit_insert AFTER INSERT ON users FOR EACH ROW INSERT INTO audit (op, id, name) VALUES ('insert', new.id, new.name);
+		CREATE TRIGGER audit_update AFTER UPDATE ON users FOR EACH ROW INSERT INTO audit (op, id, name, old) VALUES ('update', new.id, new.name, old.name);
+		CREATE TRIGGER audit_delete AFTER DELETE ON users FOR EACH ROW INSERT INTO audit (op, id, name) VALUES ('delete', old.id, old.name);
+		CREATE TRIGGER count_users AFTER INSERT ON users FOR EACH ROW UPDATE counters SET n = n + 1 WHERE id = 1;
+	`)
+	require.NoError(t, err)
+
+	call := func(q string, res ...string) {
+		t.Helper()
+
+		st, err := db.Query(q)
+		require.NoError(t, err)
+		defer st.Close()
+
+		var got []string
+		err = st.Iterate(func(d document.Document) error {
+			data, err := document.MarshalJSON(d)
+			if err != nil {
+				return err
+			}
+			got = append(got, string(data))
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, len(res), len(got), got)
+		for i := range res {
+			require.JSONEq(t, res[i], got[i])
+		}
+	}
+
+	err = db.Exec(`
+		INSERT INTO users (id, name) VALUES (1, 'Foo'), (2, 'Bar');
+		UPDATE users SET name = 'baz' WHERE id = 2;
+		DELETE FROM users WHERE id = 1;
+	`)
+	require.NoError(t, err)
+
+	call("SELECT * FROM users", `{"id": 2, "name": "baz"}`)
+	call("SELECT op, id, name, old FROM audit",
+		`{"op": "insert", "id": 1, "name": "foo", "old": null}`,
+		`{"op": "insert", "id": 2, "name": "bar", "old": null}`,
+		`{"op": "update", "id": 2, "name": "baz", "old": "bar"}`,
+		`{"op": "delete", "id": 1, "name": "foo", "old": null}`,
+	)
+	call("SELECT n FROM counters", `{"n": 2}`)
+
+	t.Run("Rejected", func(t *testing.T) {
+		err := db.Exec("INSERT INTO users (id, name) VALUES (-1, 'foo')")
+		require.EqualError(t, err, `trigger "check_id": id must be positive`)
+
+		// the whole statement is rolled back
+		call("SELECT n FROM counters", `{"n": 2}`)
+	})
+
+	t.Run("Recursion", func(t *testing.T) {
+		err := db.Exec(`
+			CREATE TABLE loop;
+			CREATE TRIGGER loop_insert AFTER INSERT ON loop FOR EACH ROW INSERT INTO loop (a) VALUES (1);
+		`)
+		require.NoError(t, err)
+
+		err = db.Exec("INSERT INTO loop (a) VALUES (1)")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "too many nested triggers")
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		// the trigger already exists
+		err := db.Exec("CREATE TRIGGER lower_name AFTER DELETE ON users FOR EACH ROW DELETE FROM audit")
+		require.True(t, errors.Is(err, database.ErrTriggerAlreadyExists))
+		err = db.Exec("CREATE TRIGGER IF NOT EXISTS lower_name AFTER DELETE ON users FOR EACH ROW DELETE FROM audit")
+		require.NoError(t, err)
+
+		// unknown table
+		err = db.Exec("CREATE TRIGGER t AFTER DELETE ON unknown FOR EACH ROW DELETE FROM audit")
+		require.True(t, errors.Is(err, database.ErrTableNotFound))
+
+		// read-only table
+		err = db.Exec("CREATE TRIGGER t AFTER DELETE ON __genji_tables FOR EACH ROW DELETE FROM audit")
+		require.Error(t, err)
+	})
+
+	t.Run("Dropped with the table", func(t *testing.T) {
+		err := db.Exec("DROP TABLE loop")
+		require.NoError(t, err)
+
+		call("SELECT trigger_name FROM __genji_triggers WHERE table_name = 'loop'")
+		call("SELECT timing, event, when, sql FROM __genji_triggers WHERE trigger_name = 'check_id'",
+			`{"timing": "BEFORE", "event": "INSERT", "when": "new.id < 0", "sql": "SELECT raise('id must be positive')"}`)
+	})
+}
+
+func TestCreateSequence(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE SEQUENCE seq;
+		CREATE SEQUENCE IF NOT EXISTS seq;
+		CREATE SEQUENCE down INCREMENT BY -2 START WITH 10;
+		CREATE TABLE test(id INTEGER PRIMARY KEY, name TEXT);
+	`)
+	require.NoError(t, err)
+
+	call := func(q string, res ...string) {
+		t.Helper()
+
+		st, err := db.Query(q)
+		require.NoError(t, err)
+		defer st.Close()
+
+		var got []string
+		err = st.Iterate(func(d document.Document) error {
+			data, err := document.MarshalJSON(d)
+			if err != nil {
+				return err
+			}
+			got = append(got, string(data))
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, len(res), len(got), got)
+		for i := range res {
+			require.JSONEq(t, res[i], got[i])
+		}
+	}
+
+	t.Run("Currval before nextval", func(t *testing.T) {
+		_, err := db.QueryDocument("SELECT currval('seq')")
+		require.Error(t, err)
+	})
+
+	call("SELECT nextval('seq') AS a", `{"a": 1}`)
+	call("SELECT nextval('seq') AS a, currval('seq') AS b", `{"a": 2, "b": 2}`)
+	call("SELECT nextval('down') AS a", `{"a": 10}`)
+	call("SELECT nextval('down') AS a", `{"a": 8}`)
+
+	err = db.Exec(`
+		INSERT INTO test (id, name) VALUES (nextval('seq'), 'foo'), (nextval('seq'), 'bar');
+	`)
+	require.NoError(t, err)
+	call("SELECT * FROM test", `{"id": 3, "name": "foo"}`, `{"id": 4, "name": "bar"}`)
+	call("SELECT currval('seq') AS a", `{"a": 4}`)
+
+	t.Run("Rollback", func(t *testing.T) {
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		err = tx.Exec("SELECT nextval('seq')")
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+
+		call("SELECT currval('seq') AS a", `{"a": 4}`)
+	})
+
+	t.Run("Outside of the projection", func(t *testing.T) {
+		call("SELECT id FROM test WHERE nextval('seq') > 0", `{"id": 3}`, `{"id": 4}`)
+		call("SELECT currval('seq') AS a", `{"a": 6}`)
+		call("SELECT id FROM test WHERE id IN (SELECT nextval('down'))")
+		call("SELECT currval('down') AS a", `{"a": 6}`)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		err := db.Exec("CREATE SEQUENCE seq")
+		require.Equal(t, database.ErrSequenceAlreadyExists, err)
+
+		_, err = db.QueryDocument("SELECT nextval('unknown')")
+		require.True(t, errors.Is(err, database.ErrSequenceNotFound))
+
+		_, err = db.QueryDocument("SELECT nextval(1)")
+		require.Error(t, err)
+
+		err = db.Exec("CREATE SEQUENCE __genji_seq")
+		require.Error(t, err)
+	})
+}
+
+func TestCreateTableAutoIncrement(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)")
+	require.NoError(t, err)
+
+	call := func(q string, res ...string) {
+		t.Helper()
+
+		st, err := db.Query(q)
+		require.NoError(t, err)
+		defer st.Close()
+
+		var got []string
+		err = st.Iterate(func(d document.Document) error {
+			data, err := document.MarshalJSON(d)
+			if err != nil {
+				return err
+			}
+			got = append(got, string(data))
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, len(res), len(got), got)
+		for i := range res {
+			require.JSONEq(t, res[i], got[i])
+		}
+	}
+
+	err = db.Exec(`
+		INSERT INTO test (name) VALUES ('a'), ('b');
+		INSERT INTO test (id, name) VALUES (10, 'c');
+		INSERT INTO test (id, name) VALUES (NULL, 'd');
+		INSERT INTO test (id, name) VALUES (5, 'e');
+		INSERT INTO test (name) VALUES ('f');
+	`)
+	require.NoError(t, err)
+
+	call("SELECT pk(), id, name FROM test",
+		`{"pk()": 1, "id": 1, "name": "a"}`,
+		`{"pk()": 2, "id": 2, "name": "b"}`,
+		`{"pk()": 5, "id": 5, "name": "e"}`,
+		`{"pk()": 10, "id": 10, "name": "c"}`,
+		`{"pk()": 11, "id": 11, "name": "d"}`,
+		`{"pk()": 12, "id": 12, "name": "f"}`,
+	)
+
+	t.Run("Deleted keys are not reused", func(t *testing.T) {
+		err := db.Exec("DELETE FROM test WHERE id = 12; INSERT INTO test (name) VALUES ('g')")
+		require.NoError(t, err)
+
+		call("SELECT id FROM test WHERE name = 'g'", `{"id": 13}`)
+	})
+
+	t.Run("Renamed table", func(t *testing.T) {
+		err := db.Exec("ALTER TABLE test RENAME TO test2; INSERT INTO test2 (name) VALUES ('h')")
+		require.NoError(t, err)
+
+		call("SELECT id FROM test2 WHERE name = 'h'", `{"id": 14}`)
+	})
+
+	t.Run("Dropped table", func(t *testing.T) {
+		err := db.Exec(`
+			DROP TABLE test2;
+			CREATE TABLE test2(id INTEGER PRIMARY KEY AUTOINCREMENT);
+			INSERT INTO test2 VALUES {};
+		`)
+		require.NoError(t, err)
+
+		call("SELECT id FROM test2", `{"id": 1}`)
+
+		err = db.View(func(tx *genji.Tx) error {
+			seqs, err := tx.ListSequences()
+			if err != nil {
+				return err
+			}
+			require.Len(t, seqs, 1)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		err := db.Exec("CREATE TABLE bad(id TEXT PRIMARY KEY AUTOINCREMENT)")
+		require.Error(t, err)
+
+		err = db.Exec("CREATE TABLE bad(id INTEGER AUTOINCREMENT)")
+		require.Error(t, err)
+
+		err = db.Update(func(tx *genji.Tx) error {
+			return tx.CreateTable("bad", &database.TableInfo{
+				FieldConstraints: []database.FieldConstraint{
+					{Path: document.NewPath("id"), Type: document.DoubleValue, IsPrimaryKey: true, IsAutoIncrement: true},
+				},
+			})
+		})
+		require.Error(t, err)
+	})
+}
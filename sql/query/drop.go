@@ -62,3 +62,87 @@ func (stmt DropIndexStmt) Run(tx *database.Transaction, args []expr.Param) (Resu
 
 	return res, err
 }
+
+// DropViewStmt is a DSL that allows creating a DROP VIEW query.
+type DropViewStmt struct {
+	ViewName string
+	IfExists bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt DropViewStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the DropView statement in the given transaction.
+// It implements the Statement interface.
+func (stmt DropViewStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.ViewName == "" {
+		return res, errors.New("missing view name")
+	}
+
+	err := tx.DropView(stmt.ViewName)
+	if errors.Is(err, database.ErrViewNotFound) && stmt.IfExists {
+		err = nil
+	}
+
+	return res, err
+}
+
+// DropTriggerStmt is a DSL that allows creating a DROP TRIGGER query.
+type DropTriggerStmt struct {
+	TriggerName string
+	IfExists    bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt DropTriggerStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the DropTrigger statement in the given transaction.
+// It implements the Statement interface.
+func (stmt DropTriggerStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.TriggerName == "" {
+		return res, errors.New("missing trigger name")
+	}
+
+	err := tx.DropTrigger(stmt.TriggerName)
+	if errors.Is(err, database.ErrTriggerNotFound) && stmt.IfExists {
+		err = nil
+	}
+
+	return res, err
+}
+
+// DropSequenceStmt is a DSL that allows creating a DROP SEQUENCE query.
+type DropSequenceStmt struct {
+	SequenceName string
+	IfExists     bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt DropSequenceStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the DropSequence statement in the given transaction.
+// It implements the Statement interface.
+func (stmt DropSequenceStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.SequenceName == "" {
+		return res, errors.New("missing sequence name")
+	}
+
+	err := tx.DropSequence(stmt.SequenceName)
+	if errors.Is(err, database.ErrSequenceNotFound) && stmt.IfExists {
+		err = nil
+	}
+
+	return res, err
+}
@@ -76,3 +76,87 @@ func TestDropIndex(t *testing.T) {
 	require.Equal(t, "idx_test1_foo", indexes[0].IndexName)
 	require.Equal(t, false, indexes[0].Unique)
 }
+
+func TestDropView(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test; CREATE VIEW v AS SELECT * FROM test")
+	require.NoError(t, err)
+
+	err = db.Exec("DROP VIEW v")
+	require.NoError(t, err)
+
+	err = db.Exec("DROP VIEW IF EXISTS v")
+	require.NoError(t, err)
+
+	// Dropping a view that doesn't exist without "IF EXISTS"
+	// should return an error.
+	err = db.Exec("DROP VIEW v")
+	require.Error(t, err)
+
+	err = db.Exec("SELECT * FROM v")
+	require.Error(t, err)
+
+	// The view name can be used by a table once the view is dropped.
+	err = db.Exec("CREATE TABLE v")
+	require.NoError(t, err)
+}
+
+func TestDropTrigger(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test; CREATE TABLE audit;
+		CREATE TRIGGER tr AFTER INSERT ON test FOR EACH ROW INSERT INTO audit (a) VALUES (new.a);
+	`)
+	require.NoError(t, err)
+
+	err = db.Exec("DROP TRIGGER tr")
+	require.NoError(t, err)
+
+	err = db.Exec("DROP TRIGGER IF EXISTS tr")
+	require.NoError(t, err)
+
+	// Dropping a trigger that doesn't exist without "IF EXISTS"
+	// should return an error.
+	err = db.Exec("DROP TRIGGER tr")
+	require.Error(t, err)
+
+	// The trigger doesn't fire anymore.
+	err = db.Exec("INSERT INTO test (a) VALUES (1)")
+	require.NoError(t, err)
+
+	d, err := db.QueryDocument("SELECT COUNT(*) FROM audit")
+	require.NoError(t, err)
+	var n int
+	err = document.Scan(d, &n)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+}
+
+func TestDropSequence(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE SEQUENCE seq; SELECT nextval('seq')")
+	require.NoError(t, err)
+
+	err = db.Exec("DROP SEQUENCE seq")
+	require.NoError(t, err)
+
+	err = db.Exec("DROP SEQUENCE IF EXISTS seq")
+	require.NoError(t, err)
+
+	// Dropping a sequence that doesn't exist without "IF EXISTS"
+	// should return an error.
+	err = db.Exec("DROP SEQUENCE seq")
+	require.Error(t, err)
+
+	_, err = db.QueryDocument("SELECT nextval('seq')")
+	require.Error(t, err)
+}
@@ -35,7 +35,7 @@ var errStop = errors.New("errStop")
 func (op eqOp) IterateIndex(idx *database.Index, tb *database.Table, v document.Value, fn func(d document.Document) error) error {
 	err := idx.AscendGreaterOrEqual(v, func(val, key []byte, isEqual bool) error {
 		if isEqual {
-			d, err := tb.GetDocument(key)
+			d, err := idx.GetDocument(tb, key)
 			if err != nil {
 				return err
 			}
@@ -108,7 +108,7 @@ func (op gtOp) IterateIndex(idx *database.Index, tb *database.Table, v document.
 			return nil
 		}
 
-		d, err := tb.GetDocument(key)
+		d, err := idx.GetDocument(tb, key)
 		if err != nil {
 			return err
 		}
@@ -176,7 +176,7 @@ func Gte(a, b Expr) Expr {
 
 func (op gteOp) IterateIndex(idx *database.Index, tb *database.Table, v document.Value, fn func(d document.Document) error) error {
 	err := idx.AscendGreaterOrEqual(v, func(val, key []byte, isEqual bool) error {
-		d, err := tb.GetDocument(key)
+		d, err := idx.GetDocument(tb, key)
 		if err != nil {
 			return err
 		}
@@ -250,7 +250,7 @@ func (op ltOp) IterateIndex(idx *database.Index, tb *database.Table, v document.
 			return errStop
 		}
 
-		d, err := tb.GetDocument(key)
+		d, err := idx.GetDocument(tb, key)
 		if err != nil {
 			return err
 		}
@@ -327,7 +327,7 @@ func (op lteOp) IterateIndex(idx *database.Index, tb *database.Table, v document
 			return errStop
 		}
 
-		d, err := tb.GetDocument(key)
+		d, err := idx.GetDocument(tb, key)
 		if err != nil {
 			return err
 		}
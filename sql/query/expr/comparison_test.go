@@ -68,6 +68,28 @@ func TestComparisonINExpr(t *testing.T) {
 	}
 }
 
+func TestComparisonMATCHExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"'The quick brown fox' MATCH 'quick'", document.NewBoolValue(true), false},
+		{"'The quick brown fox' MATCH 'FOX, the'", document.NewBoolValue(true), false},
+		{"'The quick brown fox' MATCH 'quick dog'", document.NewBoolValue(false), false},
+		{"'The quick brown fox' MATCH 'qui'", document.NewBoolValue(false), false},
+		{"'The quick brown fox' MATCH ''", document.NewBoolValue(false), false},
+		{"1 MATCH 'quick'", document.NewBoolValue(false), false},
+		{"'The quick brown fox' MATCH 1", nullLitteral, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
+
 func TestComparisonNOTINExpr(t *testing.T) {
 	tests := []struct {
 		expr  string
@@ -3,6 +3,7 @@ package expr
 import (
 	"fmt"
 
+	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
 )
 
@@ -15,6 +16,9 @@ const (
 type Environment struct {
 	Params []Param
 	Buf    *document.FieldBuffer
+	// Tx is the transaction in which the expression is evaluated, if any.
+	// It is used by functions reading or writing the database, like nextval.
+	Tx *database.Transaction
 
 	Outer *Environment
 }
@@ -71,6 +75,16 @@ func (e *Environment) SetCurrentValue(v document.Value) {
 	e.Set(currentValueKey, v)
 }
 
+// GetTx returns the transaction of the environment or of its outer environments.
+// It returns nil if there is none.
+func (e *Environment) GetTx() *database.Transaction {
+	if e.Tx == nil && e.Outer != nil {
+		return e.Outer.GetTx()
+	}
+
+	return e.Tx
+}
+
 func (e *Environment) GetParamByName(name string) (v document.Value, err error) {
 	if len(e.Params) == 0 {
 		if e.Outer != nil {
@@ -106,6 +120,7 @@ func (e *Environment) Clone() (*Environment, error) {
 	newEnv := Environment{
 		Params: e.Params,
 		Buf:    document.NewFieldBuffer(),
+		Tx:     e.Tx,
 	}
 
 	err := newEnv.Buf.Copy(e.Buf)
@@ -1,6 +1,8 @@
 package expr
 
 import (
+	"fmt"
+
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/scanner"
 )
@@ -122,6 +124,136 @@ func (p Parentheses) Eval(env *Environment) (document.Value, error) {
 	return p.E.Eval(env)
 }
 
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (p Parentheses) IsEqual(other Expr) bool {
+	o, ok := other.(Parentheses)
+	if !ok {
+		return false
+	}
+
+	return Equal(p.E, o.E)
+}
+
+func (p Parentheses) String() string {
+	return fmt.Sprintf("(%v)", p.E)
+}
+
+// DocumentExpr evaluates an expression outside of a query, using a document
+// as the current value. It implements the database.Expr interface and is used
+// to evaluate the expressions stored in the catalog, like the predicates of partial indexes.
+type DocumentExpr struct {
+	E Expr
+}
+
+// Eval evaluates the expression using d as the current document.
+func (e DocumentExpr) Eval(d document.Document) (document.Value, error) {
+	return e.E.Eval(NewEnvironment(document.NewDocumentValue(d)))
+}
+
+// Paths returns the paths of the fields read by the expression.
+func (e DocumentExpr) Paths() ([]document.Path, bool) {
+	return Paths(e.E)
+}
+
+// IsDeterministic returns true if the result of the expression only depends on the document.
+func (e DocumentExpr) IsDeterministic() bool {
+	return IsDeterministic(e.E)
+}
+
+func (e DocumentExpr) String() string {
+	return fmt.Sprintf("%v", e.E)
+}
+
+// Paths returns the paths of the fields read by e.
+// It returns false if e contains an expression whose paths cannot be determined.
+func Paths(e Expr) ([]document.Path, bool) {
+	switch t := e.(type) {
+	case Path:
+		return []document.Path{document.Path(t)}, true
+	case LiteralValue, NamedParam, PositionalParam, PKFunc:
+		return nil, true
+	case Parentheses:
+		return Paths(t.E)
+	case CastFunc:
+		return Paths(t.Expr)
+	case LowerFunc:
+		return Paths(t.Expr)
+	case UpperFunc:
+		return Paths(t.Expr)
+	case LiteralExprList:
+		var paths []document.Path
+		for _, e := range t {
+			ps, ok := Paths(e)
+			if !ok {
+				return nil, false
+			}
+			paths = append(paths, ps...)
+		}
+		return paths, true
+	case KVPairs:
+		var paths []document.Path
+		for _, kv := range t {
+			ps, ok := Paths(kv.V)
+			if !ok {
+				return nil, false
+			}
+			paths = append(paths, ps...)
+		}
+		return paths, true
+	case Operator:
+		lp, ok := Paths(t.LeftHand())
+		if !ok {
+			return nil, false
+		}
+		rp, ok := Paths(t.RightHand())
+		if !ok {
+			return nil, false
+		}
+		return append(lp, rp...), true
+	}
+
+	return nil, false
+}
+
+// IsDeterministic returns true if the result of e only depends on the document it is evaluated with.
+// It returns false if e contains a function reading the state of the database or of the query,
+// like nextval(), currval(), rank(), aggregate or window functions.
+func IsDeterministic(e Expr) bool {
+	switch t := e.(type) {
+	case Path, LiteralValue, NamedParam, PositionalParam, PKFunc, *PKFunc:
+		return true
+	case Parentheses:
+		return IsDeterministic(t.E)
+	case CastFunc:
+		return IsDeterministic(t.Expr)
+	case LowerFunc:
+		return IsDeterministic(t.Expr)
+	case UpperFunc:
+		return IsDeterministic(t.Expr)
+	case RaiseFunc:
+		return IsDeterministic(t.Expr)
+	case LiteralExprList:
+		for _, e := range t {
+			if !IsDeterministic(e) {
+				return false
+			}
+		}
+		return true
+	case KVPairs:
+		for _, kv := range t {
+			if !IsDeterministic(kv.V) {
+				return false
+			}
+		}
+		return true
+	case Operator:
+		return IsDeterministic(t.LeftHand()) && IsDeterministic(t.RightHand())
+	}
+
+	return false
+}
+
 func invertBoolResult(f func(env *Environment) (document.Value, error)) func(env *Environment) (document.Value, error) {
 	return func(env *Environment) (document.Value, error) {
 		v, err := f(env)
@@ -1,10 +1,13 @@
 package expr
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"math"
 	"strings"
 
+	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
 )
 
@@ -22,6 +25,38 @@ func BuiltinFunctions() map[string]func(args ...Expr) (Expr, error) {
 			}
 			return new(PKFunc), nil
 		},
+		"rank": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, fmt.Errorf("rank() takes no arguments")
+			}
+			return RankFunc{}, nil
+		},
+		"row_number": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, fmt.Errorf("row_number() takes no arguments")
+			}
+			return RowNumberFunc{}, nil
+		},
+		"dense_rank": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, fmt.Errorf("dense_rank() takes no arguments")
+			}
+			return DenseRankFunc{}, nil
+		},
+		"lag": func(args ...Expr) (Expr, error) {
+			e, offset, def, err := newOffsetFunc("lag", args)
+			if err != nil {
+				return nil, err
+			}
+			return LagFunc{Expr: e, Offset: offset, Default: def}, nil
+		},
+		"lead": func(args ...Expr) (Expr, error) {
+			e, offset, def, err := newOffsetFunc("lead", args)
+			if err != nil {
+				return nil, err
+			}
+			return LeadFunc{Expr: e, Offset: offset, Default: def}, nil
+		},
 		"count": func(args ...Expr) (Expr, error) {
 			if len(args) != 1 {
 				return nil, fmt.Errorf("COUNT() takes 1 argument")
@@ -52,6 +87,66 @@ func BuiltinFunctions() map[string]func(args ...Expr) (Expr, error) {
 			}
 			return &AvgFunc{Expr: args[0]}, nil
 		},
+		"array_agg": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("ARRAY_AGG() takes 1 argument")
+			}
+			return &ArrayAggFunc{Expr: args[0]}, nil
+		},
+		"string_agg": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("STRING_AGG() takes 2 arguments")
+			}
+			return &StringAggFunc{Expr: args[0], Separator: args[1]}, nil
+		},
+		"variance": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("VARIANCE() takes 1 argument")
+			}
+			return &VarianceFunc{Expr: args[0]}, nil
+		},
+		"stddev": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("STDDEV() takes 1 argument")
+			}
+			return &VarianceFunc{Expr: args[0], Stddev: true}, nil
+		},
+		"approx_count_distinct": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("APPROX_COUNT_DISTINCT() takes 1 argument")
+			}
+			return &ApproxCountDistinctFunc{Expr: args[0]}, nil
+		},
+		"lower": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("lower() takes 1 argument")
+			}
+			return LowerFunc{Expr: args[0]}, nil
+		},
+		"upper": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("upper() takes 1 argument")
+			}
+			return UpperFunc{Expr: args[0]}, nil
+		},
+		"raise": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("raise() takes 1 argument")
+			}
+			return RaiseFunc{Expr: args[0]}, nil
+		},
+		"nextval": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("nextval() takes 1 argument")
+			}
+			return NextValueFunc{Expr: args[0]}, nil
+		},
+		"currval": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("currval() takes 1 argument")
+			}
+			return CurrentValueFunc{Expr: args[0]}, nil
+		},
 	}
 }
 
@@ -106,6 +201,38 @@ func (k PKFunc) String() string {
 	return "pk()"
 }
 
+// RankFunc represents the rank() function.
+// It returns the relevance score of the current document, as computed by
+// the full-text index used to evaluate a MATCH operator.
+type RankFunc struct{}
+
+// Eval returns the relevance score of the current document,
+// or NULL if the document was not returned by a full-text search.
+func (r RankFunc) Eval(env *Environment) (document.Value, error) {
+	v, ok := env.GetCurrentValue()
+	if !ok || v.Type != document.DocumentValue {
+		return nullLitteral, nil
+	}
+
+	d, ok := v.V.(*rankedDocument)
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	return document.NewDoubleValue(d.score), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (r RankFunc) IsEqual(other Expr) bool {
+	_, ok := other.(RankFunc)
+	return ok
+}
+
+func (r RankFunc) String() string {
+	return "rank()"
+}
+
 // CastFunc represents the CAST expression.
 type CastFunc struct {
 	Expr   Expr
@@ -149,11 +276,182 @@ func (c CastFunc) String() string {
 	return fmt.Sprintf("CAST(%v AS %v)", c.Expr, c.CastAs)
 }
 
+// LowerFunc represents the lower() function.
+// It returns its argument converted to lower case, or NULL if it is not a text.
+type LowerFunc struct {
+	Expr Expr
+}
+
+// Eval returns the argument converted to lower case.
+func (l LowerFunc) Eval(env *Environment) (document.Value, error) {
+	v, err := l.Expr.Eval(env)
+	if err != nil || v.Type != document.TextValue {
+		return nullLitteral, err
+	}
+
+	return document.NewTextValue(strings.ToLower(v.V.(string))), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (l LowerFunc) IsEqual(other Expr) bool {
+	o, ok := other.(LowerFunc)
+	return ok && Equal(l.Expr, o.Expr)
+}
+
+func (l LowerFunc) String() string {
+	return fmt.Sprintf("lower(%v)", l.Expr)
+}
+
+// UpperFunc represents the upper() function.
+// It returns its argument converted to upper case, or NULL if it is not a text.
+type UpperFunc struct {
+	Expr Expr
+}
+
+// Eval returns the argument converted to upper case.
+func (u UpperFunc) Eval(env *Environment) (document.Value, error) {
+	v, err := u.Expr.Eval(env)
+	if err != nil || v.Type != document.TextValue {
+		return nullLitteral, err
+	}
+
+	return document.NewTextValue(strings.ToUpper(v.V.(string))), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (u UpperFunc) IsEqual(other Expr) bool {
+	o, ok := other.(UpperFunc)
+	return ok && Equal(u.Expr, o.Expr)
+}
+
+func (u UpperFunc) String() string {
+	return fmt.Sprintf("upper(%v)", u.Expr)
+}
+
+// RaiseFunc represents the raise() function.
+// It returns an error whose message is its argument, which makes it possible
+// to abort a statement, for example to reject a document from a trigger.
+type RaiseFunc struct {
+	Expr Expr
+}
+
+// Eval returns an error containing the argument.
+func (r RaiseFunc) Eval(env *Environment) (document.Value, error) {
+	v, err := r.Expr.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if v.Type == document.TextValue {
+		return nullLitteral, errors.New(v.V.(string))
+	}
+
+	return nullLitteral, errors.New(v.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (r RaiseFunc) IsEqual(other Expr) bool {
+	o, ok := other.(RaiseFunc)
+	return ok && Equal(r.Expr, o.Expr)
+}
+
+func (r RaiseFunc) String() string {
+	return fmt.Sprintf("raise(%v)", r.Expr)
+}
+
+// NextValueFunc represents the nextval function.
+// It advances the sequence whose name is the result of Expr and returns its new value.
+type NextValueFunc struct {
+	Expr Expr
+}
+
+// Eval returns the next value of the sequence.
+func (n NextValueFunc) Eval(env *Environment) (document.Value, error) {
+	name, tx, err := evalSequenceName(env, n.Expr)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	v, err := tx.NextSequenceValue(name)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewIntegerValue(v), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (n NextValueFunc) IsEqual(other Expr) bool {
+	o, ok := other.(NextValueFunc)
+	return ok && Equal(n.Expr, o.Expr)
+}
+
+func (n NextValueFunc) String() string {
+	return fmt.Sprintf("nextval(%v)", n.Expr)
+}
+
+// CurrentValueFunc represents the currval function.
+// It returns the last value returned by the sequence whose name is the result of Expr.
+type CurrentValueFunc struct {
+	Expr Expr
+}
+
+// Eval returns the current value of the sequence.
+func (c CurrentValueFunc) Eval(env *Environment) (document.Value, error) {
+	name, tx, err := evalSequenceName(env, c.Expr)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	v, err := tx.CurrentSequenceValue(name)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewIntegerValue(v), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (c CurrentValueFunc) IsEqual(other Expr) bool {
+	o, ok := other.(CurrentValueFunc)
+	return ok && Equal(c.Expr, o.Expr)
+}
+
+func (c CurrentValueFunc) String() string {
+	return fmt.Sprintf("currval(%v)", c.Expr)
+}
+
+// evalSequenceName evaluates the name of a sequence and returns it
+// with the transaction of the environment.
+func evalSequenceName(env *Environment, e Expr) (string, *database.Transaction, error) {
+	v, err := e.Eval(env)
+	if err != nil {
+		return "", nil, err
+	}
+	if v.Type != document.TextValue {
+		return "", nil, fmt.Errorf("expected sequence name, got %s", v.Type)
+	}
+
+	tx := env.GetTx()
+	if tx == nil {
+		return "", nil, errors.New("sequences cannot be used outside of a transaction")
+	}
+
+	return v.V.(string), tx, nil
+}
+
 // CountFunc is the COUNT aggregator function. It aggregates documents
+// If Distinct is true, it only counts distinct values.
 type CountFunc struct {
 	Expr     Expr
 	Alias    string
 	Wildcard bool
+	Distinct bool
 }
 
 func (c *CountFunc) Eval(env *Environment) (document.Value, error) {
@@ -191,7 +489,7 @@ func (c *CountFunc) IsEqual(other Expr) bool {
 		return c.Expr == nil && o.Expr == nil
 	}
 
-	return Equal(c.Expr, o.Expr)
+	return c.Distinct == o.Distinct && Equal(c.Expr, o.Expr)
 }
 
 func (c *CountFunc) String() string {
@@ -203,6 +501,10 @@ func (c *CountFunc) String() string {
 		return "COUNT(*)"
 	}
 
+	if c.Distinct {
+		return fmt.Sprintf("COUNT(DISTINCT %v)", c.Expr)
+	}
+
 	return fmt.Sprintf("COUNT(%v)", c.Expr)
 }
 
@@ -210,6 +512,9 @@ func (c *CountFunc) String() string {
 type CountAggregator struct {
 	Fn    *CountFunc
 	Count int64
+
+	// encoded values already counted, when counting distinct values
+	seen map[string]struct{}
 }
 
 // Add increments the counter if the count expression evaluates to a non-null value.
@@ -223,10 +528,26 @@ func (c *CountAggregator) Add(d document.Document) error {
 	if err != nil && err != document.ErrFieldNotFound {
 		return err
 	}
-	if v != nullLitteral {
-		c.Count++
+	if v == nullLitteral {
+		return nil
 	}
 
+	if c.Fn.Distinct {
+		k, err := distinctKey(v)
+		if err != nil {
+			return err
+		}
+
+		if c.seen == nil {
+			c.seen = make(map[string]struct{})
+		}
+		if _, ok := c.seen[string(k)]; ok {
+			return nil
+		}
+		c.seen[string(k)] = struct{}{}
+	}
+
+	c.Count++
 	return nil
 }
 
@@ -649,3 +970,444 @@ func (s *AvgAggregator) Aggregate(fb *document.FieldBuffer) error {
 
 	return nil
 }
+
+// ArrayAggFunc is the ARRAY_AGG aggregator function.
+type ArrayAggFunc struct {
+	Expr  Expr
+	Alias string
+}
+
+// Eval extracts the array from the given document and returns it.
+func (a *ArrayAggFunc) Eval(env *Environment) (document.Value, error) {
+	v, ok := env.GetCurrentValue()
+	if !ok || v.Type != document.DocumentValue {
+		return document.Value{}, errors.New("misuse of aggregation function ARRAY_AGG()")
+	}
+
+	return v.V.(document.Document).GetByField(a.String())
+}
+
+// SetAlias implements the planner.AggregatorBuilder interface.
+func (a *ArrayAggFunc) SetAlias(alias string) {
+	a.Alias = alias
+}
+
+// Aggregator implements the planner.AggregatorBuilder interface.
+func (a *ArrayAggFunc) Aggregator(group document.Value) document.Aggregator {
+	return &ArrayAggAggregator{
+		Fn: a,
+	}
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *ArrayAggFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*ArrayAggFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(a.Expr, o.Expr)
+}
+
+// String returns the alias if non-zero, otherwise it returns a string representation
+// of the array_agg expression.
+func (a *ArrayAggFunc) String() string {
+	if a.Alias != "" {
+		return a.Alias
+	}
+
+	return fmt.Sprintf("ARRAY_AGG(%v)", a.Expr)
+}
+
+// ArrayAggAggregator is an aggregator that collects every value into an array, NULL values included.
+type ArrayAggAggregator struct {
+	Fn     *ArrayAggFunc
+	Values *document.ValueBuffer
+}
+
+// Add appends the value of the expression to the array.
+func (a *ArrayAggAggregator) Add(d document.Document) error {
+	v, err := a.Fn.Expr.Eval(NewEnvironment(document.NewDocumentValue(d)))
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == document.ErrFieldNotFound {
+		v = nullLitteral
+	}
+
+	v, err = copyValue(v)
+	if err != nil {
+		return err
+	}
+
+	if a.Values == nil {
+		a.Values = document.NewValueBuffer()
+	}
+	a.Values = a.Values.Append(v)
+	return nil
+}
+
+// Aggregate adds a field to the given buffer with the array of values.
+func (a *ArrayAggAggregator) Aggregate(fb *document.FieldBuffer) error {
+	if a.Values == nil {
+		fb.Add(a.Fn.String(), document.NewNullValue())
+	} else {
+		fb.Add(a.Fn.String(), document.NewArrayValue(a.Values))
+	}
+	return nil
+}
+
+// StringAggFunc is the STRING_AGG aggregator function.
+type StringAggFunc struct {
+	Expr      Expr
+	Separator Expr
+	Alias     string
+}
+
+// Eval extracts the concatenated string from the given document and returns it.
+func (s *StringAggFunc) Eval(env *Environment) (document.Value, error) {
+	v, ok := env.GetCurrentValue()
+	if !ok || v.Type != document.DocumentValue {
+		return document.Value{}, errors.New("misuse of aggregation function STRING_AGG()")
+	}
+
+	return v.V.(document.Document).GetByField(s.String())
+}
+
+// SetAlias implements the planner.AggregatorBuilder interface.
+func (s *StringAggFunc) SetAlias(alias string) {
+	s.Alias = alias
+}
+
+// Aggregator implements the planner.AggregatorBuilder interface.
+func (s *StringAggFunc) Aggregator(group document.Value) document.Aggregator {
+	return &StringAggAggregator{
+		Fn: s,
+	}
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (s *StringAggFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*StringAggFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(s.Expr, o.Expr) && Equal(s.Separator, o.Separator)
+}
+
+// String returns the alias if non-zero, otherwise it returns a string representation
+// of the string_agg expression.
+func (s *StringAggFunc) String() string {
+	if s.Alias != "" {
+		return s.Alias
+	}
+
+	return fmt.Sprintf("STRING_AGG(%v, %v)", s.Expr, s.Separator)
+}
+
+// StringAggAggregator is an aggregator that concatenates non-null values, separated by a separator.
+// Values that are not texts are converted to text.
+type StringAggAggregator struct {
+	Fn    *StringAggFunc
+	Count int64
+
+	b strings.Builder
+}
+
+// Add appends the value of the expression to the string, preceded by the separator
+// if it's not the first value.
+func (s *StringAggAggregator) Add(d document.Document) error {
+	env := NewEnvironment(document.NewDocumentValue(d))
+
+	v, err := s.Fn.Expr.Eval(env)
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == document.ErrFieldNotFound || v.Type == document.NullValue {
+		return nil
+	}
+
+	v, err = v.CastAsText()
+	if err != nil {
+		return err
+	}
+
+	if s.Count > 0 {
+		sep, err := s.Fn.Separator.Eval(env)
+		if err != nil && err != document.ErrFieldNotFound {
+			return err
+		}
+		if sep.Type == document.TextValue {
+			s.b.WriteString(sep.V.(string))
+		}
+	}
+
+	s.b.WriteString(v.V.(string))
+	s.Count++
+	return nil
+}
+
+// Aggregate adds a field to the given buffer with the concatenated string.
+func (s *StringAggAggregator) Aggregate(fb *document.FieldBuffer) error {
+	if s.Count == 0 {
+		fb.Add(s.Fn.String(), document.NewNullValue())
+	} else {
+		fb.Add(s.Fn.String(), document.NewTextValue(s.b.String()))
+	}
+	return nil
+}
+
+// VarianceFunc is the VARIANCE aggregator function.
+// If Stddev is true, it represents the STDDEV aggregator function.
+type VarianceFunc struct {
+	Expr   Expr
+	Stddev bool
+	Alias  string
+}
+
+// Eval extracts the variance or standard deviation from the given document and returns it.
+func (v *VarianceFunc) Eval(env *Environment) (document.Value, error) {
+	cv, ok := env.GetCurrentValue()
+	if !ok || cv.Type != document.DocumentValue {
+		return document.Value{}, fmt.Errorf("misuse of aggregation function %s()", v.name())
+	}
+
+	return cv.V.(document.Document).GetByField(v.String())
+}
+
+// SetAlias implements the planner.AggregatorBuilder interface.
+func (v *VarianceFunc) SetAlias(alias string) {
+	v.Alias = alias
+}
+
+// Aggregator implements the planner.AggregatorBuilder interface.
+func (v *VarianceFunc) Aggregator(group document.Value) document.Aggregator {
+	return &VarianceAggregator{
+		Fn: v,
+	}
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (v *VarianceFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*VarianceFunc)
+	if !ok {
+		return false
+	}
+
+	return v.Stddev == o.Stddev && Equal(v.Expr, o.Expr)
+}
+
+func (v *VarianceFunc) name() string {
+	if v.Stddev {
+		return "STDDEV"
+	}
+
+	return "VARIANCE"
+}
+
+// String returns the alias if non-zero, otherwise it returns a string representation
+// of the variance expression.
+func (v *VarianceFunc) String() string {
+	if v.Alias != "" {
+		return v.Alias
+	}
+
+	return fmt.Sprintf("%s(%v)", v.name(), v.Expr)
+}
+
+// VarianceAggregator is an aggregator that computes the sample variance of non-null numeric values.
+// It uses Welford's online algorithm, which only requires one pass over the values
+// and is numerically stable.
+type VarianceAggregator struct {
+	Fn    *VarianceFunc
+	Count int64
+	Mean  float64
+	M2    float64
+}
+
+// Add updates the mean and the sum of squared differences from the mean
+// with the value of the expression.
+func (v *VarianceAggregator) Add(d document.Document) error {
+	val, err := v.Fn.Expr.Eval(NewEnvironment(document.NewDocumentValue(d)))
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+
+	var x float64
+	switch val.Type {
+	case document.IntegerValue:
+		x = float64(val.V.(int64))
+	case document.DoubleValue:
+		x = val.V.(float64)
+	default:
+		return nil
+	}
+
+	v.Count++
+	delta := x - v.Mean
+	v.Mean += delta / float64(v.Count)
+	v.M2 += delta * (x - v.Mean)
+
+	return nil
+}
+
+// Aggregate adds a field to the given buffer with the variance or the standard deviation.
+// It requires at least two values, otherwise the result is NULL.
+func (v *VarianceAggregator) Aggregate(fb *document.FieldBuffer) error {
+	if v.Count < 2 {
+		fb.Add(v.Fn.String(), document.NewNullValue())
+		return nil
+	}
+
+	variance := v.M2 / float64(v.Count-1)
+	if v.Fn.Stddev {
+		fb.Add(v.Fn.String(), document.NewDoubleValue(math.Sqrt(variance)))
+	} else {
+		fb.Add(v.Fn.String(), document.NewDoubleValue(variance))
+	}
+
+	return nil
+}
+
+// ApproxCountDistinctFunc is the APPROX_COUNT_DISTINCT aggregator function.
+type ApproxCountDistinctFunc struct {
+	Expr  Expr
+	Alias string
+}
+
+// Eval extracts the approximate count from the given document and returns it.
+func (a *ApproxCountDistinctFunc) Eval(env *Environment) (document.Value, error) {
+	v, ok := env.GetCurrentValue()
+	if !ok || v.Type != document.DocumentValue {
+		return document.Value{}, errors.New("misuse of aggregation function APPROX_COUNT_DISTINCT()")
+	}
+
+	return v.V.(document.Document).GetByField(a.String())
+}
+
+// SetAlias implements the planner.AggregatorBuilder interface.
+func (a *ApproxCountDistinctFunc) SetAlias(alias string) {
+	a.Alias = alias
+}
+
+// Aggregator implements the planner.AggregatorBuilder interface.
+func (a *ApproxCountDistinctFunc) Aggregator(group document.Value) document.Aggregator {
+	return &ApproxCountDistinctAggregator{
+		Fn: a,
+	}
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *ApproxCountDistinctFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*ApproxCountDistinctFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(a.Expr, o.Expr)
+}
+
+// String returns the alias if non-zero, otherwise it returns a string representation
+// of the approx_count_distinct expression.
+func (a *ApproxCountDistinctFunc) String() string {
+	if a.Alias != "" {
+		return a.Alias
+	}
+
+	return fmt.Sprintf("APPROX_COUNT_DISTINCT(%v)", a.Expr)
+}
+
+// ApproxCountDistinctAggregator is an aggregator that estimates the number of distinct non-null values
+// using a HyperLogLog sketch. It uses a fixed amount of memory, regardless of the number of values,
+// and its standard error is about 0.8%.
+type ApproxCountDistinctAggregator struct {
+	Fn *ApproxCountDistinctFunc
+
+	sketch hyperLogLog
+}
+
+// Add adds the value of the expression to the sketch.
+func (a *ApproxCountDistinctAggregator) Add(d document.Document) error {
+	v, err := a.Fn.Expr.Eval(NewEnvironment(document.NewDocumentValue(d)))
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == document.ErrFieldNotFound || v.Type == document.NullValue {
+		return nil
+	}
+
+	k, err := distinctKey(v)
+	if err != nil {
+		return err
+	}
+
+	a.sketch.add(k)
+	return nil
+}
+
+// Aggregate adds a field to the given buffer with the estimated number of distinct values.
+func (a *ApproxCountDistinctAggregator) Aggregate(fb *document.FieldBuffer) error {
+	fb.Add(a.Fn.String(), document.NewIntegerValue(a.sketch.count()))
+	return nil
+}
+
+// distinctKey encodes v so that equal values have the same encoding.
+// Integers are converted to doubles so that 1 and 1.0 are considered equal.
+func distinctKey(v document.Value) ([]byte, error) {
+	var err error
+	if v.Type == document.IntegerValue {
+		v, err = v.CastAsDouble()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	err = document.NewValueEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+// copyValue returns a copy of v that doesn't share memory with the document it was read from.
+func copyValue(v document.Value) (document.Value, error) {
+	switch v.Type {
+	case document.DocumentValue:
+		var fb document.FieldBuffer
+		err := fb.Copy(v.V.(document.Document))
+		if err != nil {
+			return v, err
+		}
+		return document.NewDocumentValue(&fb), nil
+	case document.ArrayValue:
+		var vb document.ValueBuffer
+		err := vb.Copy(v.V.(document.Array))
+		if err != nil {
+			return v, err
+		}
+		return document.NewArrayValue(&vb), nil
+	case document.BlobValue:
+		return document.NewBlobValue(append([]byte(nil), v.V.([]byte)...)), nil
+	}
+
+	return v, nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPkExpr(t *testing.T) {
@@ -24,3 +25,19 @@ func TestPkExpr(t *testing.T) {
 		})
 	}
 }
+
+func TestRaiseExpr(t *testing.T) {
+	_, err := expr.RaiseFunc{Expr: expr.TextValue("rejected")}.Eval(&expr.Environment{})
+	require.EqualError(t, err, "rejected")
+
+	_, err = expr.RaiseFunc{Expr: expr.IntegerValue(10)}.Eval(&expr.Environment{})
+	require.EqualError(t, err, "10")
+}
+
+func TestSequenceExprWithoutTransaction(t *testing.T) {
+	_, err := expr.NextValueFunc{Expr: expr.TextValue("seq")}.Eval(&expr.Environment{})
+	require.Error(t, err)
+
+	_, err = expr.CurrentValueFunc{Expr: expr.TextValue("seq")}.Eval(&expr.Environment{})
+	require.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package expr
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// hllPrecision is the number of bits of the hash used to select a register.
+// With 2^14 registers, the standard error of the estimation is 1.04 / sqrt(2^14) ≈ 0.8%.
+const hllPrecision = 14
+
+// hyperLogLog is a HyperLogLog sketch, which estimates the number of distinct elements of a set
+// using a fixed amount of memory.
+// See http://algo.inria.fr/flajolet/Publications/FlFuGaMe07.pdf
+type hyperLogLog struct {
+	registers []uint8
+}
+
+// add adds an element to the sketch.
+func (h *hyperLogLog) add(data []byte) {
+	if h.registers == nil {
+		h.registers = make([]uint8, 1<<hllPrecision)
+	}
+
+	hash := fnv.New64a()
+	hash.Write(data)
+	x := mix64(hash.Sum64())
+
+	// the first bits select the register, the position of the
+	// leftmost 1 of the remaining bits is stored in the register
+	idx := x >> (64 - hllPrecision)
+	w := x<<hllPrecision | 1<<(hllPrecision-1)
+	rho := uint8(bits.LeadingZeros64(w) + 1)
+
+	if rho > h.registers[idx] {
+		h.registers[idx] = rho
+	}
+}
+
+// count returns the estimated number of distinct elements.
+func (h *hyperLogLog) count() int64 {
+	if h.registers == nil {
+		return 0
+	}
+
+	m := float64(len(h.registers))
+
+	var sum float64
+	var zeros int
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+
+	// for small cardinalities, linear counting is more accurate
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+
+	return int64(estimate + 0.5)
+}
+
+// mix64 is the finalizer of MurmurHash3. It improves the distribution
+// of the bits of the FNV hash, whose high bits depend poorly on the last bytes of the data.
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
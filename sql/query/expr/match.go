@@ -0,0 +1,101 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/index/fulltext"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+type matchOp struct {
+	*simpleOperator
+}
+
+// Match creates an expression that evaluates to true if the text a
+// contains every word of the text b.
+func Match(a, b Expr) Expr {
+	return &matchOp{&simpleOperator{a, b, scanner.MATCH}}
+}
+
+// Eval compares the words of both operands without reducing them to their stem.
+// When the operator is evaluated using a full-text index, the words are compared
+// the same way they were indexed.
+func (op matchOp) Eval(env *Environment) (document.Value, error) {
+	a, b, err := op.simpleOperator.eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if b.Type != document.TextValue {
+		return nullLitteral, errors.New("MATCH operator takes a text")
+	}
+
+	if a.Type != document.TextValue {
+		return falseLitteral, nil
+	}
+
+	if fulltext.Match(a.V.(string), b.V.(string), false) {
+		return trueLitteral, nil
+	}
+
+	return falseLitteral, nil
+}
+
+// IterateIndex searches the full-text index for the documents containing every word of v.
+// The relevance of each document can be read using the rank() function.
+func (op matchOp) IterateIndex(idx *database.Index, tb *database.Table, v document.Value, fn func(d document.Document) error) error {
+	ft := idx.FullText()
+	if ft == nil {
+		return errors.New("MATCH operator requires a full-text index")
+	}
+
+	if v.Type != document.TextValue {
+		return errors.New("MATCH operator takes a text")
+	}
+
+	return ft.Search(v.V.(string), func(k []byte, score float64) error {
+		d, err := idx.GetDocument(tb, k)
+		if err != nil {
+			return err
+		}
+
+		return fn(&rankedDocument{Document: d, score: score})
+	})
+}
+
+func (op matchOp) String() string {
+	return fmt.Sprintf("%v MATCH %v", op.a, op.b)
+}
+
+// IsMatchOperator reports if op is the MATCH operator.
+func IsMatchOperator(op Operator) bool {
+	_, ok := op.(*matchOp)
+	return ok
+}
+
+// rankedDocument is a document returned by a full-text search,
+// along with its relevance score.
+type rankedDocument struct {
+	document.Document
+
+	score float64
+}
+
+func (d *rankedDocument) RawKey() []byte {
+	if k, ok := d.Document.(document.Keyer); ok {
+		return k.RawKey()
+	}
+
+	return nil
+}
+
+func (d *rankedDocument) Key() (document.Value, error) {
+	if k, ok := d.Document.(document.Keyer); ok {
+		return k.Key()
+	}
+
+	return nullLitteral, nil
+}
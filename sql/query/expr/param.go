@@ -36,6 +36,50 @@ func (p NamedParam) String() string {
 	return fmt.Sprintf("$%s", string(p))
 }
 
+// ParamPath is an expression which represents a path to a value nested in a named parameter,
+// like new.a in the statement of a trigger, where new is the document being written.
+// If the path is empty, it evaluates to the parameter itself.
+type ParamPath struct {
+	Name string
+	Path document.Path
+}
+
+// Eval looks up for the parameter named p.Name in the env and returns the value found at p.Path.
+// If there is no such value, it returns NULL.
+func (p ParamPath) Eval(env *Environment) (document.Value, error) {
+	v, err := env.GetParamByName(p.Name)
+	if err != nil || len(p.Path) == 0 {
+		return v, err
+	}
+
+	v, err = p.Path.GetValue(v)
+	if err == document.ErrFieldNotFound {
+		return nullLitteral, nil
+	}
+
+	return v, err
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (p ParamPath) IsEqual(other Expr) bool {
+	o, ok := other.(ParamPath)
+	return ok && p.Name == o.Name && p.Path.IsEqual(o.Path)
+}
+
+// String implements the fmt.Stringer interface.
+func (p ParamPath) String() string {
+	if len(p.Path) == 0 {
+		return p.Name
+	}
+
+	if p.Path[0].FieldName == "" {
+		return p.Name + p.Path.String()
+	}
+
+	return p.Name + "." + p.Path.String()
+}
+
 // PositionalParam is an expression which represents the position of a parameter.
 type PositionalParam int
 
@@ -77,3 +77,36 @@ func TestPathIsEqual(t *testing.T) {
 		})
 	}
 }
+
+func TestParamPathExpr(t *testing.T) {
+	d := document.NewFromJSON([]byte(`{"a": 1, "b": [1, {"c": "d"}]}`))
+	env := expr.Environment{Params: []expr.Param{{Name: "new", Value: d}, {Name: "old", Value: nil}}}
+
+	tests := []struct {
+		name  string
+		p     expr.ParamPath
+		res   document.Value
+		fails bool
+	}{
+		{"field", expr.ParamPath{Name: "new", Path: document.NewPath("a")}, document.NewIntegerValue(1), false},
+		{"nested", expr.ParamPath{Name: "new", Path: document.Path{{FieldName: "b"}, {ArrayIndex: 1}, {FieldName: "c"}}}, document.NewTextValue("d"), false},
+		{"document", expr.ParamPath{Name: "new"}, document.NewDocumentValue(d), false},
+		{"missing field", expr.ParamPath{Name: "new", Path: document.NewPath("z")}, nullLitteral, false},
+		{"null param", expr.ParamPath{Name: "old", Path: document.NewPath("a")}, nullLitteral, false},
+		{"unknown param", expr.ParamPath{Name: "foo", Path: document.NewPath("a")}, nullLitteral, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := test.p.Eval(&env)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.res, v)
+		})
+	}
+
+	require.Equal(t, "new.b[1].c", expr.ParamPath{Name: "new", Path: document.Path{{FieldName: "b"}, {ArrayIndex: 1}, {FieldName: "c"}}}.String())
+}
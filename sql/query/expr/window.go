@@ -0,0 +1,414 @@
+package expr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+// A Window describes the documents a window function is computed over.
+// Documents are split into partitions using the PARTITION BY expressions,
+// and each partition is sorted using the ORDER BY expression.
+type Window struct {
+	PartitionBy      []Expr
+	OrderBy          Expr
+	OrderByDirection scanner.Token
+}
+
+// IsEqual compares this window with the other window and returns
+// true if they are equal.
+func (w Window) IsEqual(other Window) bool {
+	if len(w.PartitionBy) != len(other.PartitionBy) {
+		return false
+	}
+
+	for i := range w.PartitionBy {
+		if !Equal(w.PartitionBy[i], other.PartitionBy[i]) {
+			return false
+		}
+	}
+
+	if w.OrderBy == nil || other.OrderBy == nil {
+		return w.OrderBy == nil && other.OrderBy == nil
+	}
+
+	return Equal(w.OrderBy, other.OrderBy) && w.OrderByDirection == other.OrderByDirection
+}
+
+func (w Window) String() string {
+	var b strings.Builder
+
+	if len(w.PartitionBy) > 0 {
+		b.WriteString("PARTITION BY ")
+		for i, e := range w.PartitionBy {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "%v", e)
+		}
+	}
+
+	if w.OrderBy != nil {
+		if b.Len() > 0 {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "ORDER BY %v", w.OrderBy)
+		if w.OrderByDirection == scanner.DESC {
+			b.WriteString(" DESC")
+		}
+	}
+
+	return b.String()
+}
+
+// WindowFunc is a function computed over a window of documents.
+// Unlike aggregate functions, it returns one value per document,
+// which depends on the other documents of its partition.
+type WindowFunc struct {
+	Func   Expr
+	Window Window
+}
+
+// Eval extracts the value computed for the current document and returns it.
+func (w *WindowFunc) Eval(env *Environment) (document.Value, error) {
+	v, ok := env.GetCurrentValue()
+	if !ok || v.Type != document.DocumentValue {
+		return document.Value{}, errors.New("misuse of window function")
+	}
+
+	return v.V.(document.Document).GetByField(w.String())
+}
+
+// Compute returns the value of the window function for every document of a partition.
+// The documents must be sorted according to the ORDER BY clause of the window.
+// Documents with the same ORDER BY value are peers: they have the same rank and running aggregates
+// include all of them.
+func (w *WindowFunc) Compute(docs []document.Document, params []Param) ([]document.Value, error) {
+	peers, err := w.peerGroups(docs, params)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]document.Value, len(docs))
+
+	switch f := w.Func.(type) {
+	case RowNumberFunc:
+		for i := range docs {
+			values[i] = document.NewIntegerValue(int64(i + 1))
+		}
+	case RankFunc, DenseRankFunc:
+		_, dense := f.(DenseRankFunc)
+		var rank int64
+		for i, g := range peers {
+			if dense {
+				rank = int64(i + 1)
+			} else {
+				rank = int64(g[0] + 1)
+			}
+			for j := g[0]; j < g[1]; j++ {
+				values[j] = document.NewIntegerValue(rank)
+			}
+		}
+	case LagFunc:
+		return computeOffset(docs, params, f.Expr, f.Offset, f.Default, -1)
+	case LeadFunc:
+		return computeOffset(docs, params, f.Expr, f.Offset, f.Default, 1)
+	case document.AggregatorBuilder:
+		// aggregates are computed from the first document of the partition
+		// up to the last peer of the current document.
+		agg := f.Aggregator(nullLitteral)
+		for _, g := range peers {
+			for j := g[0]; j < g[1]; j++ {
+				err = agg.Add(docs[j])
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			var fb document.FieldBuffer
+			err = agg.Aggregate(&fb)
+			if err != nil {
+				return nil, err
+			}
+
+			var v document.Value
+			err = fb.Iterate(func(field string, value document.Value) error {
+				v = value
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			for j := g[0]; j < g[1]; j++ {
+				values[j] = v
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%v is not a window function", w.Func)
+	}
+
+	return values, nil
+}
+
+// peerGroups returns the boundaries of each group of peers.
+// If the window has no ORDER BY clause, all the documents are peers.
+func (w *WindowFunc) peerGroups(docs []document.Document, params []Param) ([][2]int, error) {
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	if w.Window.OrderBy == nil {
+		return [][2]int{{0, len(docs)}}, nil
+	}
+
+	var groups [][2]int
+	var prev []byte
+	for i, d := range docs {
+		v, err := evalOnDocument(w.Window.OrderBy, d, params)
+		if err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		err = document.NewValueEncoder(&buf).Encode(v)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 || !bytes.Equal(prev, buf.Bytes()) {
+			groups = append(groups, [2]int{i, i})
+		}
+		groups[len(groups)-1][1] = i + 1
+		prev = buf.Bytes()
+	}
+
+	return groups, nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (w *WindowFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*WindowFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(w.Func, o.Func) && w.Window.IsEqual(o.Window)
+}
+
+func (w *WindowFunc) String() string {
+	return fmt.Sprintf("%v OVER (%v)", w.Func, w.Window)
+}
+
+// IsWindowFunc reports whether e can be used with an OVER clause.
+func IsWindowFunc(e Expr) bool {
+	switch e.(type) {
+	case RowNumberFunc, RankFunc, DenseRankFunc, LagFunc, LeadFunc, document.AggregatorBuilder:
+		return true
+	}
+
+	return false
+}
+
+// RequiresWindow reports whether e can only be used with an OVER clause.
+func RequiresWindow(e Expr) bool {
+	switch e.(type) {
+	case RowNumberFunc, DenseRankFunc, LagFunc, LeadFunc:
+		return true
+	}
+
+	return false
+}
+
+// RowNumberFunc represents the row_number() window function.
+// It returns the number of the current document within its partition, starting at 1.
+type RowNumberFunc struct{}
+
+// Eval returns an error: row_number() must be computed over a window.
+func (r RowNumberFunc) Eval(env *Environment) (document.Value, error) {
+	return nullLitteral, errors.New("misuse of window function row_number()")
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (r RowNumberFunc) IsEqual(other Expr) bool {
+	_, ok := other.(RowNumberFunc)
+	return ok
+}
+
+func (r RowNumberFunc) String() string {
+	return "row_number()"
+}
+
+// DenseRankFunc represents the dense_rank() window function.
+// It returns the rank of the current document within its partition, without gaps.
+type DenseRankFunc struct{}
+
+// Eval returns an error: dense_rank() must be computed over a window.
+func (r DenseRankFunc) Eval(env *Environment) (document.Value, error) {
+	return nullLitteral, errors.New("misuse of window function dense_rank()")
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (r DenseRankFunc) IsEqual(other Expr) bool {
+	_, ok := other.(DenseRankFunc)
+	return ok
+}
+
+func (r DenseRankFunc) String() string {
+	return "dense_rank()"
+}
+
+// LagFunc represents the lag() window function.
+// It returns the value of Expr evaluated on the document Offset documents before the current one
+// within its partition, or Default if there is no such document.
+type LagFunc struct {
+	Expr    Expr
+	Offset  Expr
+	Default Expr
+}
+
+// Eval returns an error: lag() must be computed over a window.
+func (l LagFunc) Eval(env *Environment) (document.Value, error) {
+	return nullLitteral, errors.New("misuse of window function lag()")
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (l LagFunc) IsEqual(other Expr) bool {
+	o, ok := other.(LagFunc)
+	return ok && Equal(l.Expr, o.Expr) && equalOrNil(l.Offset, o.Offset) && equalOrNil(l.Default, o.Default)
+}
+
+func (l LagFunc) String() string {
+	return offsetFuncString("lag", l.Expr, l.Offset, l.Default)
+}
+
+// LeadFunc represents the lead() window function.
+// It returns the value of Expr evaluated on the document Offset documents after the current one
+// within its partition, or Default if there is no such document.
+type LeadFunc struct {
+	Expr    Expr
+	Offset  Expr
+	Default Expr
+}
+
+// Eval returns an error: lead() must be computed over a window.
+func (l LeadFunc) Eval(env *Environment) (document.Value, error) {
+	return nullLitteral, errors.New("misuse of window function lead()")
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (l LeadFunc) IsEqual(other Expr) bool {
+	o, ok := other.(LeadFunc)
+	return ok && Equal(l.Expr, o.Expr) && equalOrNil(l.Offset, o.Offset) && equalOrNil(l.Default, o.Default)
+}
+
+func (l LeadFunc) String() string {
+	return offsetFuncString("lead", l.Expr, l.Offset, l.Default)
+}
+
+// equalOrNil compares optional expressions.
+func equalOrNil(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return Equal(a, b)
+}
+
+func newOffsetFunc(name string, args []Expr) (e, offset, def Expr, err error) {
+	if len(args) < 1 || len(args) > 3 {
+		return nil, nil, nil, fmt.Errorf("%s() takes between 1 and 3 arguments", name)
+	}
+
+	e = args[0]
+	if len(args) > 1 {
+		offset = args[1]
+	}
+	if len(args) > 2 {
+		def = args[2]
+	}
+
+	return e, offset, def, nil
+}
+
+func offsetFuncString(name string, e, offset, def Expr) string {
+	args := []string{fmt.Sprintf("%v", e)}
+	if offset != nil {
+		args = append(args, fmt.Sprintf("%v", offset))
+	}
+	if def != nil {
+		args = append(args, fmt.Sprintf("%v", def))
+	}
+
+	return fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+}
+
+// computeOffset evaluates e on the document located offset documents before (dir = -1)
+// or after (dir = 1) each document.
+func computeOffset(docs []document.Document, params []Param, e, offset, def Expr, dir int) ([]document.Value, error) {
+	n := 1
+	if offset != nil {
+		v, err := offset.Eval(NewEnvironment(document.Value{}, params...))
+		if err != nil {
+			return nil, err
+		}
+
+		if !v.Type.IsNumber() {
+			return nil, fmt.Errorf("offset must evaluate to a number, got %q", v.Type)
+		}
+
+		v, err = v.CastAsInteger()
+		if err != nil {
+			return nil, err
+		}
+
+		n = int(v.V.(int64))
+		if n < 0 {
+			return nil, errors.New("offset must not be negative")
+		}
+	}
+
+	values := make([]document.Value, len(docs))
+	for i := range docs {
+		var err error
+
+		j := i + dir*n
+		switch {
+		case j >= 0 && j < len(docs):
+			values[i], err = evalOnDocument(e, docs[j], params)
+		case def != nil:
+			values[i], err = evalOnDocument(def, docs[i], params)
+		default:
+			values[i] = nullLitteral
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+// evalOnDocument evaluates e using d as the current document.
+// Missing fields evaluate to NULL.
+func evalOnDocument(e Expr, d document.Document, params []Param) (document.Value, error) {
+	v, err := e.Eval(NewEnvironment(document.NewDocumentValue(d), params...))
+	if err == document.ErrFieldNotFound {
+		return nullLitteral, nil
+	}
+
+	return v, err
+}
@@ -41,6 +41,7 @@ func (stmt InsertStmt) Run(tx *database.Transaction, args []expr.Param) (Result,
 
 	env := expr.Environment{
 		Params: args,
+		Tx:     tx,
 	}
 
 	if len(stmt.FieldNames) > 0 {
@@ -261,3 +261,86 @@ func TestInsertStmt(t *testing.T) {
 		}
 	})
 }
+
+func TestInsertStrict(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(a INTEGER PRIMARY KEY, b.c TEXT) STRICT")
+	require.NoError(t, err)
+
+	err = db.Exec("INSERT INTO test (a, b) VALUES (1, {c: 'foo'})")
+	require.NoError(t, err)
+
+	err = db.Exec("INSERT INTO test (a, bb) VALUES (2, 'typo')")
+	require.EqualError(t, err, `field "bb" is not declared`)
+
+	err = db.Exec("INSERT INTO test (a, b) VALUES (3, {c: 'foo', d: 1})")
+	require.EqualError(t, err, `field "b.d" is not declared`)
+
+	err = db.Exec("UPDATE test SET e = 1")
+	require.EqualError(t, err, `field "e" is not declared`)
+
+	err = db.Exec("ALTER TABLE test ADD FIELD e INTEGER")
+	require.NoError(t, err)
+	err = db.Exec("UPDATE test SET e = 1")
+	require.NoError(t, err)
+}
+
+func TestInsertGeneratedFields(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(id INTEGER PRIMARY KEY, price DOUBLE, qty INTEGER, total DOUBLE AS (price * qty) STORED, email TEXT, lemail AS (LOWER(email)));
+		CREATE UNIQUE INDEX idx_test_lemail ON test(lemail);
+		INSERT INTO test (id, price, qty, email) VALUES (1, 2.5, 4, 'Foo@Example.com'), (2, 1, 1, 'bar@example.com');
+	`)
+	require.NoError(t, err)
+
+	query := func(q string) string {
+		t.Helper()
+
+		res, err := db.Query(q)
+		require.NoError(t, err)
+		defer res.Close()
+
+		var buf bytes.Buffer
+		err = document.IteratorToJSONArray(&buf, res)
+		require.NoError(t, err)
+		return buf.String()
+	}
+
+	require.JSONEq(t, `[{"id": 1, "total": 10.0, "lemail": "foo@example.com"}]`, query("SELECT id, total, lemail FROM test WHERE lemail = 'foo@example.com'"))
+
+	// generated fields are indexed
+	err = db.Exec("INSERT INTO test (id, email) VALUES (3, 'FOO@example.com')")
+	require.Equal(t, database.ErrDuplicateDocument, err)
+
+	// direct writes are rejected
+	err = db.Exec("INSERT INTO test (id, price, qty, total) VALUES (4, 1, 1, 5)")
+	require.EqualError(t, err, `cannot write generated field "total"`)
+	err = db.Exec("UPDATE test SET total = 5")
+	require.EqualError(t, err, `cannot write generated field "total"`)
+
+	// unless the value is the computed one
+	err = db.Exec("INSERT INTO test (id, price, qty, total) VALUES (4, 1, 1, 1)")
+	require.NoError(t, err)
+
+	// generated fields are computed again when the document is replaced
+	err = db.Exec("UPDATE test SET qty = 10 WHERE id = 1")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"total": 25.0}]`, query("SELECT total FROM test WHERE id = 1"))
+
+	// generated fields added to an existing table are computed for every document
+	err = db.Exec("ALTER TABLE test ADD FIELD double_qty INTEGER AS (qty * 2)")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"double_qty": 20}, {"double_qty": 2}, {"double_qty": 2}]`, query("SELECT double_qty FROM test"))
+
+	err = db.Exec("CREATE TABLE invalid(a, b AS (a +))")
+	require.Error(t, err)
+	err = db.Exec("CREATE TABLE invalid(a.b AS (1))")
+	require.Error(t, err)
+}
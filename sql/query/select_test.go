@@ -53,6 +53,14 @@ func TestSelectStmt(t *testing.T) {
 		{"With group by", "SELECT color FROM test GROUP BY color", false, `[{"color":"red"},{"color":"blue"},{"color":null}]`, nil},
 		{"With group by and count", "SELECT COUNT(k) FROM test GROUP BY size", false, `[{"COUNT(k)":2},{"COUNT(k)":1}]`, nil},
 		{"With group by and count wildcard", "SELECT COUNT(*  ) FROM test GROUP BY size", false, `[{"COUNT(*  )":2},{"COUNT(*  )":1}]`, nil},
+		{"With group by multiple expressions", "SELECT size, color, COUNT(*) AS c FROM test GROUP BY size, color", false, `[{"size":10,"color":"red","c":1},{"size":10,"color":"blue","c":1},{"size":null,"color":null,"c":1}]`, nil},
+		{"With group by expression", "SELECT size + 1 FROM test GROUP BY size + 1", false, `[{"size + 1":11},{"size + 1":null}]`, nil},
+		{"With having", "SELECT size, COUNT(*) FROM test GROUP BY size HAVING COUNT(*) > 1", false, `[{"size":10,"COUNT(*)":2}]`, nil},
+		{"With having and alias", "SELECT size, COUNT(*) AS c FROM test GROUP BY size HAVING c < 2", false, `[{"size":null,"c":1}]`, nil},
+		{"With having on group by expression", "SELECT COUNT(*) AS c FROM test GROUP BY size HAVING size = 10", false, `[{"c":2}]`, nil},
+		{"With having on aggregate not selected", "SELECT size FROM test GROUP BY size HAVING MAX(weight) = 100", false, `[{"size":10}]`, nil},
+		{"With having without group by", "SELECT COUNT(*) FROM test HAVING COUNT(*) > 5", false, `[]`, nil},
+		{"With invalid having", "SELECT size FROM test GROUP BY size HAVING color = 'red'", true, ``, nil},
 		{"With order by", "SELECT * FROM test ORDER BY color", false, `[{"k":3,"height":100,"weight":200},{"k":2,"color":"blue","size":10,"weight":100},{"k":1,"color":"red","size":10,"shape":"square"}]`, nil},
 		{"With order by asc", "SELECT * FROM test ORDER BY color ASC", false, `[{"k":3,"height":100,"weight":200},{"k":2,"color":"blue","size":10,"weight":100},{"k":1,"color":"red","size":10,"shape":"square"}]`, nil},
 		{"With order by asc numeric", "SELECT * FROM test ORDER BY weight ASC", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":2,"color":"blue","size":10,"weight":100},{"k":3,"height":100,"weight":200}]`, nil},
@@ -243,6 +251,536 @@ func TestSelectStmt(t *testing.T) {
 		require.JSONEq(t, `{"MAX(a)": [1, 2, 3]}`, string(enc))
 	})
 
+	t.Run("with array elements indexes", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test;
+			CREATE INDEX idx_tags ON test(tags[*]);
+			INSERT INTO test (id, tags) VALUES (1, ['admin', 'user']), (2, ['user']), (3, [1, 2]), (4, 'admin');
+		`)
+		require.NoError(t, err)
+
+		query := func(q string, args ...interface{}) string {
+			st, err := db.Query(q, args...)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			return buf.String()
+		}
+
+		require.JSONEq(t, `[{"id": 1}]`, query("SELECT id FROM test WHERE 'admin' IN tags"))
+		require.JSONEq(t, `[{"id": 1}, {"id": 2}]`, query("SELECT id FROM test WHERE ? IN tags ORDER BY id", "user"))
+		require.JSONEq(t, `[{"id": 3}]`, query("SELECT id FROM test WHERE 2 IN tags"))
+		require.JSONEq(t, `[{"id": 2}]`, query("SELECT id FROM test WHERE tags[0] = 'user'"))
+
+		err = db.Exec("UPDATE test SET tags = ['guest'] WHERE id = 1")
+		require.NoError(t, err)
+		err = db.Exec("DELETE FROM test WHERE id = 2")
+		require.NoError(t, err)
+
+		require.JSONEq(t, `[]`, query("SELECT id FROM test WHERE 'user' IN tags"))
+		require.JSONEq(t, `[{"id": 1}]`, query("SELECT id FROM test WHERE 'guest' IN tags"))
+	})
+
+	t.Run("with partial indexes", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test;
+			CREATE INDEX idx_a ON test(a) WHERE status = 'open';
+			INSERT INTO test (a, status) VALUES (1, 'open'), (2, 'closed'), (3, 'open');
+			UPDATE test SET status = 'closed' WHERE a = 3;
+		`)
+		require.NoError(t, err)
+
+		query := func(q string) string {
+			st, err := db.Query(q)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			return buf.String()
+		}
+
+		require.JSONEq(t, `[{"a": 1}]`, query("SELECT a FROM test WHERE a > 0 AND status = 'open'"))
+		require.JSONEq(t, `[{"a": 2}, {"a": 3}]`, query("SELECT a FROM test WHERE a > 0 AND status = 'closed' ORDER BY a"))
+	})
+
+	t.Run("with expression indexes", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test;
+			CREATE INDEX idx_email ON test(lower(email));
+			CREATE INDEX idx_sum ON test(a + b);
+			INSERT INTO test (id, email, a, b) VALUES (1, 'Foo@Example.com', 1, 2), (2, 'bar@example.com', 2, 2), (3, 10, 3, 3);
+			UPDATE test SET email = 'Baz@Example.com' WHERE id = 2;
+		`)
+		require.NoError(t, err)
+
+		query := func(q string, args ...interface{}) string {
+			st, err := db.Query(q, args...)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			return buf.String()
+		}
+
+		require.JSONEq(t, `[{"id": 1}]`, query("SELECT id FROM test WHERE lower(email) = 'foo@example.com'"))
+		require.JSONEq(t, `[]`, query("SELECT id FROM test WHERE lower(email) = ?", "bar@example.com"))
+		require.JSONEq(t, `[{"id": 2}]`, query("SELECT id FROM test WHERE lower(email) = 'baz@example.com'"))
+		require.JSONEq(t, `[{"id": 2}]`, query("SELECT id FROM test WHERE a + b = 4"))
+		require.JSONEq(t, `[{"id": 2}, {"id": 3}]`, query("SELECT id FROM test WHERE a + b > 3"))
+
+		err = db.Exec("DELETE FROM test WHERE lower(email) = 'foo@example.com'")
+		require.NoError(t, err)
+		require.JSONEq(t, `[]`, query("SELECT id FROM test WHERE lower(email) = 'foo@example.com'"))
+	})
+
+	t.Run("with covering indexes", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test (id INTEGER PRIMARY KEY);
+			CREATE INDEX idx_a ON test(a) INCLUDE (b, c.d);
+			INSERT INTO test (id, a, b, c, e) VALUES (1, 1, 'foo', {d: 1, f: 2}, 10), (2, 2, 'bar', {d: 2}, 20), (3, 3, 'baz', 3, 30);
+			UPDATE test SET b = 'qux' WHERE id = 2;
+		`)
+		require.NoError(t, err)
+
+		query := func(q string, args ...interface{}) string {
+			st, err := db.Query(q, args...)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			return buf.String()
+		}
+
+		require.JSONEq(t, `[{"a": 2.0, "b": "qux"}, {"a": 3.0, "b": "baz"}]`, query("SELECT a, b FROM test WHERE a > 1"))
+		require.JSONEq(t, `[{"b": "foo", "c.f": 2.0}]`, query("SELECT b, c.f FROM test WHERE a = ?", 1))
+		require.JSONEq(t, `[{"b": "qux"}, {"b": "foo"}]`, query("SELECT b FROM test WHERE a < 3 AND c.d > 0 ORDER BY b DESC"))
+		require.JSONEq(t, `[{"e": 30.0}]`, query("SELECT e FROM test WHERE a = 3"))
+
+		err = db.Exec("DELETE FROM test WHERE a = 2")
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"b": "baz"}]`, query("SELECT b FROM test WHERE a > 1"))
+	})
+
+	t.Run("with full-text indexes", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test;
+			CREATE FULLTEXT INDEX idx_body ON test(body) WITH STEMMING;
+			INSERT INTO test (id, body) VALUES
+				(1, 'The quick brown fox jumps over the lazy dog'),
+				(2, 'Foxes are quick, foxes are clever'),
+				(3, 'A slow brown bear'),
+				(4, 42);
+			INSERT INTO test (id) VALUES (5);
+		`)
+		require.NoError(t, err)
+
+		query := func(q string, args ...interface{}) string {
+			st, err := db.Query(q, args...)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			return buf.String()
+		}
+
+		require.JSONEq(t, `[{"id": 1}, {"id": 3}]`, query("SELECT id FROM test WHERE body MATCH 'brown'"))
+		require.JSONEq(t, `[{"id": 1}, {"id": 2}]`, query("SELECT id FROM test WHERE body MATCH ?", "QUICK FOX"))
+
+		// documents are ranked by relevance
+		var ids []int
+		st, err := db.Query("SELECT id, rank() AS r FROM test WHERE body MATCH 'fox' ORDER BY r DESC")
+		require.NoError(t, err)
+		err = st.Iterate(func(d document.Document) error {
+			var id int
+			var r float64
+			err := document.Scan(d, &id, &r)
+			ids = append(ids, id)
+			return err
+		})
+		require.NoError(t, err)
+		require.NoError(t, st.Close())
+		require.Equal(t, []int{2, 1}, ids)
+
+		// documents can be sorted by relevance without projecting it
+		require.JSONEq(t, `[{"id": 2}, {"id": 1}]`, query("SELECT id FROM test WHERE body MATCH 'fox' ORDER BY rank() DESC"))
+		require.JSONEq(t, `[{"id": 1}, {"id": 2}]`, query("SELECT id FROM test WHERE body MATCH 'fox' ORDER BY rank()"))
+
+		require.JSONEq(t, `[]`, query("SELECT id FROM test WHERE body MATCH 'cat'"))
+		require.JSONEq(t, `[{"r": null}]`, query("SELECT rank() AS r FROM test WHERE id = 1"))
+
+		err = db.Exec("UPDATE test SET body = 'A quick brown bear' WHERE id = 3")
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"id": 2}, {"id": 3}]`, query("SELECT id FROM test WHERE body MATCH 'quick' AND id > 1"))
+
+		err = db.Exec("DELETE FROM test WHERE body MATCH 'fox'")
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"id": 3}]`, query("SELECT id FROM test WHERE body MATCH 'quick'"))
+	})
+
+	t.Run("with window functions", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test;
+			INSERT INTO test (id, team, score) VALUES
+				(1, 'a', 10),
+				(2, 'a', 30),
+				(3, 'a', 30),
+				(4, 'b', 20),
+				(5, 'b', 5);
+		`)
+		require.NoError(t, err)
+
+		query := func(q string, args ...interface{}) string {
+			st, err := db.Query(q, args...)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			return buf.String()
+		}
+
+		require.JSONEq(t, `[
+			{"id": 1, "n": 3, "r": 3, "dr": 2},
+			{"id": 2, "n": 1, "r": 1, "dr": 1},
+			{"id": 3, "n": 2, "r": 1, "dr": 1},
+			{"id": 4, "n": 1, "r": 1, "dr": 1},
+			{"id": 5, "n": 2, "r": 2, "dr": 2}
+		]`, query(`
+			SELECT id,
+				row_number() OVER (PARTITION BY team ORDER BY score DESC) AS n,
+				rank() OVER (PARTITION BY team ORDER BY score DESC) AS r,
+				dense_rank() OVER (PARTITION BY team ORDER BY score DESC) AS dr
+			FROM test ORDER BY id`))
+
+		require.JSONEq(t, `[
+			{"id": 1, "prev": null, "next": 30},
+			{"id": 2, "prev": 10, "next": 30},
+			{"id": 3, "prev": 30, "next": -1},
+			{"id": 4, "prev": null, "next": 5},
+			{"id": 5, "prev": 20, "next": -1}
+		]`, query(`
+			SELECT id,
+				lag(score) OVER (PARTITION BY team ORDER BY id) AS prev,
+				lead(score, 1, -1) OVER (PARTITION BY team ORDER BY id) AS next
+			FROM test ORDER BY id`))
+
+		// with an ORDER BY clause, aggregates are running aggregates and peers are aggregated together
+		require.JSONEq(t, `[
+			{"id": 1, "total": 10, "team_total": 70, "n": 5, "lo": 10, "hi": 10, "running": 10},
+			{"id": 2, "total": 70, "team_total": 70, "n": 5, "lo": 10, "hi": 30, "running": 40},
+			{"id": 3, "total": 70, "team_total": 70, "n": 5, "lo": 10, "hi": 30, "running": 70},
+			{"id": 4, "total": 25, "team_total": 25, "n": 5, "lo": 10, "hi": 30, "running": 90},
+			{"id": 5, "total": 5, "team_total": 25, "n": 5, "lo": 5, "hi": 30, "running": 95}
+		]`, query(`
+			SELECT id,
+				SUM(score) OVER (PARTITION BY team ORDER BY score) AS total,
+				SUM(score) OVER (PARTITION BY team) AS team_total,
+				COUNT(*) OVER () AS n,
+				MIN(score) OVER (ORDER BY id) AS lo,
+				MAX(score) OVER (ORDER BY id) AS hi,
+				SUM(score) OVER (ORDER BY id) AS running
+			FROM test ORDER BY id`))
+
+		require.JSONEq(t, `[{"team": "a", "avg": 70}, {"team": "b", "avg": 60}]`,
+			query("SELECT DISTINCT team, AVG(score) OVER (PARTITION BY team) * 3 AS avg FROM test WHERE id IN (1, 2, 3, 4)"))
+
+		require.JSONEq(t, `[{"id": 1, "r": "2"}, {"id": 2, "r": "1"}]`,
+			query("SELECT id, lower(CAST(rank() OVER (PARTITION BY team ORDER BY score DESC) AS TEXT)) AS r FROM test WHERE id IN (1, 2)"))
+
+		_, err = db.Query("SELECT row_number() FROM test")
+		require.Error(t, err)
+
+		// window functions are computed after the documents are filtered and grouped
+		tests := []struct {
+			query, err string
+		}{
+			{"SELECT id FROM test WHERE rank() OVER (ORDER BY score) = 1", "window functions are not allowed in WHERE at line 1, char 21"},
+			{"SELECT id FROM test WHERE id > 1 AND lower(row_number() OVER ()) = '1'", "window functions are not allowed in WHERE at line 1, char 21"},
+			{"SELECT COUNT(*) FROM test GROUP BY rank() OVER (ORDER BY score)", "window functions are not allowed in GROUP BY at line 1, char 27"},
+			{"SELECT team FROM test GROUP BY team HAVING rank() OVER () > 1", "window functions are not allowed in HAVING at line 1, char 37"},
+			{"UPDATE test SET score = 0 WHERE rank() OVER (ORDER BY score) = 1", "window functions are not allowed in WHERE at line 1, char 27"},
+			{"DELETE FROM test WHERE row_number() OVER () = 1", "window functions are not allowed in WHERE at line 1, char 18"},
+		}
+
+		for _, test := range tests {
+			err = db.Exec(test.query)
+			require.EqualError(t, err, test.err, test.query)
+		}
+	})
+
+	t.Run("with additional aggregators", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test;
+			INSERT INTO test (id, team, name, score) VALUES
+				(1, 'a', 'foo', 2),
+				(2, 'a', 'bar', 4),
+				(3, 'a', 'foo', 4),
+				(4, 'b', 'baz', 4),
+				(5, 'b', NULL, 5);
+		`)
+		require.NoError(t, err)
+
+		query := func(q string) string {
+			st, err := db.Query(q)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			return buf.String()
+		}
+
+		require.JSONEq(t, `[{"n": 3, "s": 3, "approx": 3}]`,
+			query("SELECT COUNT(DISTINCT score) AS n, COUNT(DISTINCT name) AS s, APPROX_COUNT_DISTINCT(name) AS approx FROM test"))
+
+		require.JSONEq(t, `[
+			{"team": "a", "ids": [1, 2, 3], "names": "foo, bar, foo", "n": 2, "variance": 1.3333333333333333, "stddev": 1.1547005383792515},
+			{"team": "b", "ids": [4, 5], "names": "baz", "n": 1, "variance": 0.5, "stddev": 0.7071067811865476}
+		]`, query(`
+			SELECT team,
+				ARRAY_AGG(id) AS ids,
+				STRING_AGG(name, ', ') AS names,
+				COUNT(DISTINCT name) AS n,
+				VARIANCE(score) AS variance,
+				STDDEV(score) AS stddev
+			FROM test GROUP BY team`))
+
+		require.JSONEq(t, `[{"ids": null, "names": null, "n": 0, "variance": null, "approx": 0}]`,
+			query("SELECT ARRAY_AGG(id) AS ids, STRING_AGG(name, ',') AS names, COUNT(DISTINCT id) AS n, VARIANCE(score) AS variance, APPROX_COUNT_DISTINCT(id) AS approx FROM test WHERE id > 10"))
+
+		require.JSONEq(t, `[{"team": "a"}]`, query("SELECT team FROM test GROUP BY team HAVING COUNT(DISTINCT name) > 1"))
+	})
+
+	t.Run("approximate count of distinct values", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test")
+		require.NoError(t, err)
+
+		const n = 20000
+		for i := 0; i < n; i++ {
+			err = db.Exec("INSERT INTO test (a) VALUES (?)", i%(n/2))
+			require.NoError(t, err)
+		}
+
+		d, err := db.QueryDocument("SELECT APPROX_COUNT_DISTINCT(a), COUNT(DISTINCT a) FROM test")
+		require.NoError(t, err)
+
+		var approx, exact int
+		err = document.Scan(d, &approx, &exact)
+		require.NoError(t, err)
+		require.Equal(t, n/2, exact)
+		require.InEpsilon(t, n/2, approx, 0.03)
+	})
+
+	t.Run("with set operations", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE foo;
+			CREATE TABLE bar;
+			INSERT INTO foo (a) VALUES (1), (2), (2), (3);
+			INSERT INTO bar (b) VALUES (2), (3), (4);
+		`)
+		require.NoError(t, err)
+
+		query := func(q string) string {
+			st, err := db.Query(q)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			return buf.String()
+		}
+
+		require.JSONEq(t, `[{"a": 1}, {"a": 2}, {"a": 2}, {"a": 3}, {"a": 2}, {"a": 3}, {"a": 4}]`,
+			query("SELECT a FROM foo UNION ALL SELECT b AS a FROM bar"))
+		require.JSONEq(t, `[{"a": 1}, {"a": 2}, {"a": 3}, {"a": 4}]`,
+			query("SELECT a FROM foo UNION SELECT b AS a FROM bar"))
+		require.JSONEq(t, `[{"a": 2}, {"a": 3}]`,
+			query("SELECT a FROM foo INTERSECT SELECT b AS a FROM bar"))
+		require.JSONEq(t, `[{"a": 1}]`,
+			query("SELECT a FROM foo EXCEPT SELECT b AS a FROM bar"))
+		require.JSONEq(t, `[{"a": 1}, {"a": 2}, {"a": 3}, {"a": 10}]`,
+			query("SELECT a FROM foo UNION SELECT 10 AS a UNION SELECT 1 AS a"))
+		require.JSONEq(t, `[{"a": 3}, {"a": 2}]`,
+			query("SELECT a FROM foo WHERE a > 1 UNION ALL SELECT b AS a FROM bar ORDER BY a DESC LIMIT 2 OFFSET 2"))
+		require.JSONEq(t, `[{"a": 1}, {"a": 2}]`,
+			query("SELECT a FROM foo UNION SELECT b AS a FROM bar EXCEPT SELECT b AS a FROM bar WHERE b != 2"))
+
+		_, err = db.Query("SELECT a FROM foo EXCEPT ALL SELECT b AS a FROM bar")
+		require.Error(t, err)
+
+		// both queries must project the same fields
+		_, err = db.Query("SELECT a FROM foo UNION SELECT a, b FROM bar")
+		require.EqualError(t, err, "each UNION query must have the same number of fields, got 1 and 2")
+		_, err = db.Query("SELECT a FROM foo INTERSECT SELECT b FROM bar")
+		require.EqualError(t, err, `each INTERSECT query must have the same field names, got "a" and "b"`)
+		_, err = db.Query("SELECT a FROM foo UNION SELECT 10 AS a UNION SELECT 1")
+		require.EqualError(t, err, `each UNION query must have the same field names, got "a" and "1"`)
+	})
+
+	t.Run("with common table expressions", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE categories;
+			INSERT INTO categories (id, name, parent) VALUES
+				(1, 'root', NULL),
+				(2, 'books', 1),
+				(3, 'music', 1),
+				(4, 'novels', 2),
+				(5, 'poetry', 2),
+				(6, 'sci-fi', 4);
+		`)
+		require.NoError(t, err)
+
+		query := func(q string) string {
+			st, err := db.Query(q)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = document.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			return buf.String()
+		}
+
+		require.JSONEq(t, `[{"name": "novels"}, {"name": "poetry"}]`, query(`
+			WITH books AS (SELECT id, name FROM categories WHERE parent = 2)
+			SELECT name FROM books ORDER BY name`))
+
+		require.JSONEq(t, `[{"n": 4}]`, query(`
+			WITH a AS (SELECT id FROM categories WHERE id > 1), b AS (SELECT id FROM a WHERE id < 6)
+			SELECT COUNT(*) AS n FROM b`))
+
+		require.JSONEq(t, `[{"n": 1}, {"n": 2}, {"n": 3}, {"n": 4}, {"n": 5}]`, query(`
+			WITH RECURSIVE cnt(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM cnt WHERE n < 5)
+			SELECT n FROM cnt`))
+
+		// descendants of the "books" category
+		require.JSONEq(t, `[
+			{"id": 2, "name": "books"},
+			{"id": 4, "name": "novels"},
+			{"id": 5, "name": "poetry"},
+			{"id": 6, "name": "sci-fi"}
+		]`, query(`
+			WITH RECURSIVE tree AS (
+				SELECT id, name FROM categories WHERE id = 2
+				UNION ALL
+				SELECT id, name FROM categories WHERE parent IN (SELECT id FROM tree)
+			)
+			SELECT * FROM tree ORDER BY id`))
+
+		// leaf categories
+		require.JSONEq(t, `[{"name": "music"}, {"name": "poetry"}, {"name": "sci-fi"}]`, query(`
+			SELECT name FROM categories WHERE id NOT IN (SELECT parent FROM categories WHERE parent IS NOT NULL) ORDER BY id`))
+
+		// subqueries are evaluated once per statement, not once per document
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		err = tx.Exec("CREATE SEQUENCE seq")
+		require.NoError(t, err)
+		d, err := tx.QueryDocument("SELECT COUNT(*) AS n FROM categories WHERE id IN (SELECT nextval('seq'))")
+		require.NoError(t, err)
+		require.JSONEq(t, `{"n": 1}`, document.NewDocumentValue(d).String())
+		d, err = tx.QueryDocument("SELECT currval('seq') AS v")
+		require.NoError(t, err)
+		require.JSONEq(t, `{"v": 1}`, document.NewDocumentValue(d).String())
+		require.NoError(t, tx.Rollback())
+
+		// UNION stops when no new documents are found, even if the data contains cycles
+		require.JSONEq(t, `[{"n": 0}, {"n": 1}, {"n": 2}]`, query(`
+			WITH RECURSIVE cycle(n) AS (SELECT 0 UNION SELECT (n + 1) % 3 FROM cycle)
+			SELECT n FROM cycle ORDER BY n`))
+
+		// recursive expressions that never stop generating documents fail instead of growing forever
+		_, err = db.QueryDocument(`
+			WITH RECURSIVE t(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM t)
+			SELECT * FROM t LIMIT 3`)
+		require.EqualError(t, err, `common table expression "t": too many recursions, the maximum is 1000`)
+		_, err = db.QueryDocument(`
+			WITH RECURSIVE cycle(n) AS (SELECT 0 UNION ALL SELECT (n + 1) % 3 FROM cycle)
+			SELECT n FROM cycle`)
+		require.Error(t, err)
+
+		// a common table expression is not visible outside of its statement
+		_, err = db.Query("WITH foo AS (SELECT 1) SELECT * FROM foo; SELECT * FROM foo")
+		require.Error(t, err)
+
+		_, err = db.Query("WITH RECURSIVE foo AS (SELECT * FROM foo) SELECT * FROM foo")
+		require.Error(t, err)
+
+		// IN subqueries must return a single column
+		_, err = db.QueryDocument("SELECT name FROM categories WHERE id IN (SELECT id, parent FROM categories)")
+		require.EqualError(t, err, "subquery must return one column")
+		_, err = db.QueryDocument("SELECT name FROM categories WHERE id IN (SELECT * FROM categories)")
+		require.EqualError(t, err, "subquery must return one column")
+
+		// subqueries are only allowed where they can be evaluated
+		for _, q := range []string{
+			"INSERT INTO categories (id, name, parent) VALUES (7, 'jazz', (SELECT id FROM categories WHERE name = 'music'))",
+			"UPDATE categories SET parent = (SELECT id FROM categories WHERE name = 'root') WHERE id = 6",
+			"CREATE TRIGGER t AFTER INSERT ON categories FOR EACH ROW WHEN new.parent IN (SELECT id FROM categories) DELETE FROM categories WHERE id = 1",
+			"CREATE TRIGGER t AFTER INSERT ON categories FOR EACH ROW INSERT INTO categories (id) VALUES ((SELECT 10))",
+			"CREATE INDEX idx ON categories (name) WHERE parent IN (SELECT id FROM categories)",
+		} {
+			err = db.Exec(q)
+			require.Error(t, err, q)
+		}
+		require.JSONEq(t, `[{"n": 6}]`, query("SELECT COUNT(*) AS n FROM categories"))
+	})
+
 	t.Run("empty table with aggregators", func(t *testing.T) {
 		db, err := genji.Open(":memory:")
 		require.NoError(t, err)
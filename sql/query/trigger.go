@@ -0,0 +1,67 @@
+package query
+
+import (
+	"errors"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+var errStop = errors.New("stop")
+
+// Trigger is the parsed condition and statement of a trigger.
+// It implements the database.Trigger interface.
+type Trigger struct {
+	// When is the optional condition of the trigger.
+	When expr.Expr
+
+	Statement Statement
+}
+
+// Fire runs the statement if the condition is true, with the new and old parameters
+// bound to newDoc and oldDoc. Missing documents are bound to NULL.
+// It returns a copy of the first document returned by the statement, if any.
+func (t *Trigger) Fire(tx *database.Transaction, newDoc, oldDoc document.Document) (document.Document, error) {
+	params := []expr.Param{
+		{Name: "new", Value: newDoc},
+		{Name: "old", Value: oldDoc},
+	}
+
+	if t.When != nil {
+		v, err := t.When.Eval(&expr.Environment{Params: params, Tx: tx})
+		if err != nil {
+			return nil, err
+		}
+
+		ok, err := v.IsTruthy()
+		if err != nil || !ok {
+			return nil, err
+		}
+	}
+
+	res, err := t.Statement.Run(tx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var fb *document.FieldBuffer
+	err = res.Iterate(func(d document.Document) error {
+		fb = document.NewFieldBuffer()
+		err := fb.Copy(d)
+		if err != nil {
+			return err
+		}
+
+		return errStop
+	})
+	if err != nil && err != errStop {
+		return nil, err
+	}
+
+	if fb == nil {
+		return nil, nil
+	}
+
+	return fb, nil
+}
@@ -55,6 +55,7 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `IN`, tok: scanner.IN, raw: `IN`},
 		{s: `IS`, tok: scanner.IS, raw: `IS`},
 		{s: `LIKE`, tok: scanner.LIKE, raw: `LIKE`},
+		{s: `MATCH`, tok: scanner.MATCH, raw: `MATCH`},
 
 		// Misc tokens
 		{s: `(`, tok: scanner.LPAREN, raw: `(`},
@@ -134,15 +135,24 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `DROP`, tok: scanner.DROP, raw: `DROP`},
 		{s: `FIELD`, tok: scanner.FIELD, raw: `FIELD`},
 		{s: `FROM`, tok: scanner.FROM, raw: `FROM`},
+		{s: `FULLTEXT`, tok: scanner.FULLTEXT, raw: `FULLTEXT`},
 		{s: `GROUP`, tok: scanner.GROUP, raw: `GROUP`},
+		{s: `HAVING`, tok: scanner.HAVING, raw: `HAVING`},
 		{s: `INSERT`, tok: scanner.INSERT, raw: `INSERT`},
+		{s: `INTERSECT`, tok: scanner.INTERSECT, raw: `INTERSECT`},
 		{s: `INTO`, tok: scanner.INTO, raw: `INTO`},
+		{s: `UNION`, tok: scanner.UNION, raw: `UNION`},
+		{s: `EXCEPT`, tok: scanner.EXCEPT, raw: `EXCEPT`},
+		{s: `ALL`, tok: scanner.ALL, raw: `ALL`},
 		{s: `LIMIT`, tok: scanner.LIMIT, raw: `LIMIT`},
 		{s: `ONLY`, tok: scanner.ONLY, raw: `ONLY`},
 		{s: `OFFSET`, tok: scanner.OFFSET, raw: `OFFSET`},
 		{s: `ORDER`, tok: scanner.ORDER, raw: `ORDER`},
+		{s: `OVER`, tok: scanner.OVER, raw: `OVER`},
+		{s: `PARTITION`, tok: scanner.PARTITION, raw: `PARTITION`},
 		{s: `PRIMARY`, tok: scanner.PRIMARY, raw: `PRIMARY`},
 		{s: `READ`, tok: scanner.READ, raw: `READ`},
+		{s: `RECURSIVE`, tok: scanner.RECURSIVE, raw: `RECURSIVE`},
 		{s: `REINDEX`, tok: scanner.REINDEX, raw: `REINDEX`},
 		{s: `RENAME`, tok: scanner.RENAME, raw: `RENAME`},
 		{s: `ROLLBACK`, tok: scanner.ROLLBACK, raw: `ROLLBACK`},
@@ -57,6 +57,7 @@ const (
 	IN       // IN
 	IS       // IS
 	LIKE     // LIKE
+	MATCH    // MATCH
 	operatorEnd
 
 	LPAREN      // (
@@ -74,6 +75,7 @@ const (
 	keywordBeg
 	// ALL and the following are Genji SQL Keywords
 	ADD_KEYWORD
+	ALL
 	ALTER
 	AS
 	ASC
@@ -87,14 +89,19 @@ const (
 	DESC
 	DISTINCT
 	DROP
+	EXCEPT
 	EXISTS
 	EXPLAIN
 	FIELD
 	FROM
+	FULLTEXT
 	GROUP
+	HAVING
 	IF
+	INCLUDE
 	INDEX
 	INSERT
+	INTERSECT
 	INTO
 	KEY
 	LIMIT
@@ -103,9 +110,12 @@ const (
 	ON
 	ONLY
 	ORDER
+	OVER
+	PARTITION
 	PRECISION
 	PRIMARY
 	READ
+	RECURSIVE
 	REINDEX
 	RENAME
 	ROLLBACK
@@ -114,11 +124,13 @@ const (
 	TABLE
 	TO
 	TRANSACTION
+	UNION
 	UNIQUE
 	UNSET
 	UPDATE
 	VALUES
 	WHERE
+	WITH
 	WRITE
 
 	// Aliases
@@ -183,6 +195,7 @@ var tokens = [...]string{
 	IN:       "IN",
 	IS:       "IS",
 	LIKE:     "LIKE",
+	MATCH:    "MATCH",
 
 	LPAREN:      "(",
 	RPAREN:      ")",
@@ -197,12 +210,14 @@ var tokens = [...]string{
 	DOT:         ".",
 
 	ADD_KEYWORD: "ADD",
+	ALL:         "ALL",
 	ALTER:       "ALTER",
 	AS:          "AS",
 	ASC:         "ASC",
 	BEGIN:       "BEGIN",
 	COMMIT:      "COMMIT",
 	GROUP:       "GROUP",
+	HAVING:      "HAVING",
 	BY:          "BY",
 	CREATE:      "CREATE",
 	CAST:        "CAST",
@@ -211,14 +226,18 @@ var tokens = [...]string{
 	DESC:        "DESC",
 	DISTINCT:    "DISTINCT",
 	DROP:        "DROP",
+	EXCEPT:      "EXCEPT",
 	EXISTS:      "EXISTS",
 	EXPLAIN:     "EXPLAIN",
 	KEY:         "KEY",
 	FIELD:       "FIELD",
 	FROM:        "FROM",
+	FULLTEXT:    "FULLTEXT",
 	IF:          "IF",
+	INCLUDE:     "INCLUDE",
 	INDEX:       "INDEX",
 	INSERT:      "INSERT",
+	INTERSECT:   "INTERSECT",
 	INTO:        "INTO",
 	LIMIT:       "LIMIT",
 	NOT:         "NOT",
@@ -226,9 +245,12 @@ var tokens = [...]string{
 	ON:          "ON",
 	ONLY:        "ONLY",
 	ORDER:       "ORDER",
+	OVER:        "OVER",
+	PARTITION:   "PARTITION",
 	PRECISION:   "PRECISION",
 	PRIMARY:     "PRIMARY",
 	READ:        "READ",
+	RECURSIVE:   "RECURSIVE",
 	REINDEX:     "REINDEX",
 	RENAME:      "RENAME",
 	ROLLBACK:    "ROLLBACK",
@@ -237,11 +259,13 @@ var tokens = [...]string{
 	TABLE:       "TABLE",
 	TO:          "TO",
 	TRANSACTION: "TRANSACTION",
+	UNION:       "UNION",
 	UNIQUE:      "UNIQUE",
 	UNSET:       "UNSET",
 	UPDATE:      "UPDATE",
 	VALUES:      "VALUES",
 	WHERE:       "WHERE",
+	WITH:        "WITH",
 	WRITE:       "WRITE",
 
 	TYPEARRAY:     "ARRAY",
@@ -271,7 +295,7 @@ func initKeywords() {
 	for tok := keywordBeg + 1; tok < keywordEnd; tok++ {
 		keywords[strings.ToLower(tokens[tok])] = tok
 	}
-	for _, tok := range []Token{AND, OR, TRUE, FALSE, NULL, IN, IS, LIKE} {
+	for _, tok := range []Token{AND, OR, TRUE, FALSE, NULL, IN, IS, LIKE, MATCH} {
 		keywords[strings.ToLower(tokens[tok])] = tok
 	}
 }
@@ -293,7 +317,7 @@ func (tok Token) Precedence() int {
 		return 2
 	case IN:
 		return 3
-	case EQ, NEQ, EQREGEX, NEQREGEX, LT, LTE, GT, GTE, IS, LIKE:
+	case EQ, NEQ, EQREGEX, NEQREGEX, LT, LTE, GT, GTE, IS, LIKE, MATCH:
 		return 4
 	case ADD, SUB, BITWISEOR, BITWISEXOR:
 		return 5